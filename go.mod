@@ -8,6 +8,8 @@ require (
 	github.com/gin-gonic/gin v1.10.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/google/uuid v1.5.0
+	github.com/pquerna/otp v1.4.0
+	github.com/shirou/gopsutil/v3 v3.20.10
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/crypto v0.31.0
 	gorm.io/driver/mysql v1.5.2
@@ -19,9 +21,7 @@ require (
 	github.com/boombuler/barcode v1.0.1-0.20190219062509-6c824513bacc // indirect
 	github.com/go-ole/go-ole v1.3.0 // indirect
 	github.com/kr/pretty v0.3.0 // indirect
-	github.com/pquerna/otp v1.4.0 // indirect
 	github.com/rogpeppe/go-internal v1.8.0 // indirect
-	github.com/shirou/gopsutil/v3 v3.20.10 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
 
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry 本地缓存的授权状态
+type cacheEntry struct {
+	Code              string             `json:"code"`
+	DeviceID          string             `json:"device_id"`
+	LicenseFile       *model.LicenseFile `json:"license_file"`
+	HeartbeatInterval int                `json:"heartbeat_interval"`
+	ServerTime        *model.LicenseFile `json:"server_time,omitempty"` // 最近一次在线校验时服务端签名的时间，用于离线宽限期和发现时钟回拨
+}
+
+// revocationCache 本地缓存的吊销列表，独立于授权缓存保存，清除授权后仍然保留
+// 只保存服务端签名的最近一次全量列表及其后的增量列表，加载时重新校验签名并重放，不信任本地汇总结果
+type revocationCache struct {
+	Lists    []*model.SignedRevocationList `json:"lists"`
+	SyncedAt time.Time                     `json:"synced_at"`
+
+	Version  int64           `json:"-"`
+	Licenses map[string]bool `json:"-"` // 被吊销的授权码
+	Devices  map[string]bool `json:"-"` // 被吊销的设备指纹
+}
+
+// newRevocationCache 创建空的吊销列表缓存
+func newRevocationCache() *revocationCache {
+	return &revocationCache{
+		Licenses: make(map[string]bool),
+		Devices:  make(map[string]bool),
+	}
+}
+
+// clone 复制吊销列表缓存，同步失败时不影响当前列表
+func (r *revocationCache) clone() *revocationCache {
+	next := newRevocationCache()
+	next.Lists = append(next.Lists, r.Lists...)
+	next.SyncedAt = r.SyncedAt
+	next.Version = r.Version
+	for code := range r.Licenses {
+		next.Licenses[code] = true
+	}
+	for fingerprint := range r.Devices {
+		next.Devices[fingerprint] = true
+	}
+	return next
+}
+
+// apply 应用已校验签名的吊销列表，file为该列表的签名文件
+// 全量列表替换本地内容，增量列表必须衔接本地版本
+func (r *revocationCache) apply(list *model.RevocationList, file *model.SignedRevocationList) error {
+	// 拒绝比本地更旧的列表，防止重放旧列表解除吊销
+	if list.Version < r.Version {
+		return errors.New("revocation list is older than the local copy")
+	}
+	if list.Full {
+		r.Lists = nil
+		r.Licenses = make(map[string]bool)
+		r.Devices = make(map[string]bool)
+	} else if len(r.Lists) == 0 || list.BaseVersion != r.Version {
+		return errors.New("revocation list does not follow the local version")
+	}
+
+	for _, entry := range list.Entries {
+		set := r.Licenses
+		if entry.Type == model.RevocationTypeDevice {
+			set = r.Devices
+		}
+		if entry.Action == model.RevocationActionRemove {
+			delete(set, entry.Value)
+		} else {
+			set[entry.Value] = true
+		}
+	}
+	r.Lists = append(r.Lists, file)
+	r.Version = list.Version
+	return nil
+}
+
+// loadCache 读取本地缓存，文件不存在时返回nil
+func loadCache(path string) (*cacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// saveCache 写入本地缓存
+func saveCache(path string, entry *cacheEntry) error {
+	return writeJSONFile(path, entry)
+}
+
+// writeJSONFile 写入JSON文件，先写临时文件再替换以避免缓存损坏
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeCache 删除本地缓存
+func removeCache(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
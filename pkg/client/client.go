@@ -0,0 +1,622 @@
+// Package client 是LVerity授权服务的Go客户端SDK，负责采集硬件标识、激活授权、
+// 缓存签名授权文件、定期在线校验和心跳，并在服务器不可达时使用本地缓存降级运行。
+package client
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout        = 15 * time.Second
+	defaultOfflineGracePeriod = 7 * 24 * time.Hour
+	defaultRevalidateInterval = 1 * time.Hour
+	defaultHeartbeatInterval  = 60 * time.Second
+	clockRollbackTolerance    = 5 * time.Minute // 允许的本地时钟回退，容忍正常的时钟校准
+	clientKeyHeader           = "X-Client-Key"
+)
+
+var (
+	ErrNotActivated        = errors.New("license is not activated on this device")
+	ErrLicenseExpired      = errors.New("license has expired")
+	ErrOfflineGraceExpired = errors.New("license could not be verified online within the offline grace period")
+	ErrLeaseExpired        = errors.New("floating license lease has expired")
+	ErrLicenseRevoked      = errors.New("license or device has been revoked")
+	ErrInvalidLicenseKey   = errors.New("license key is mistyped or malformed")
+	ErrClockTampered       = errors.New("system clock is earlier than the last trusted time")
+)
+
+// APIError 服务端返回的业务错误
+type APIError struct {
+	StatusCode int
+	Code       model.ClientCode
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Config 客户端配置
+type Config struct {
+	ServerURL          string              // 服务端地址，如 https://license.example.com
+	ClientKey          string              // 产品客户端密钥
+	PublicKey          string              // 内置的base64编码Ed25519根公钥，轮换后的密钥需由其背书
+	CachePath          string              // 本地授权缓存文件路径
+	Device             *model.ClientDevice // 设备硬件信息，为空时自动采集
+	HTTPClient         *http.Client
+	OfflineGracePeriod time.Duration // 服务器不可达时允许使用缓存的最长时间
+	RevalidateInterval time.Duration // 后台在线校验间隔
+}
+
+// Client 授权客户端
+type Client struct {
+	config    Config
+	publicKey ed25519.PublicKey // 内置根公钥
+	device    *model.ClientDevice
+	http      *http.Client
+
+	mu          sync.RWMutex
+	cache       *cacheEntry
+	claims      *model.LicenseClaims
+	revocations *revocationCache
+	keys        map[string]ed25519.PublicKey // 经根公钥背书的签名公钥
+
+	now      func() time.Time // 本地时钟
+	stopChan chan struct{}
+	running  bool
+}
+
+// New 创建授权客户端并加载本地缓存
+func New(config Config) (*Client, error) {
+	if config.ServerURL == "" {
+		return nil, errors.New("server url is required")
+	}
+	if config.CachePath == "" {
+		return nil, errors.New("cache path is required")
+	}
+
+	publicKey, err := utils.ParsePublicKey(config.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	device := config.Device
+	if device == nil {
+		if device, err = CollectDevice(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	if config.OfflineGracePeriod <= 0 {
+		config.OfflineGracePeriod = defaultOfflineGracePeriod
+	}
+	if config.RevalidateInterval <= 0 {
+		config.RevalidateInterval = defaultRevalidateInterval
+	}
+	config.ServerURL = strings.TrimRight(config.ServerURL, "/")
+
+	c := &Client{
+		config:    config,
+		publicKey: publicKey,
+		device:    device,
+		http:      config.HTTPClient,
+		now:       time.Now,
+	}
+
+	// 吊销列表按签名公钥重新校验，需先加载签名公钥
+	if c.keys, err = c.loadKeys(); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %v", err)
+	}
+	if c.revocations, err = c.loadRevocations(); err != nil {
+		return nil, fmt.Errorf("failed to load revocation list: %v", err)
+	}
+
+	entry, err := loadCache(config.CachePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load license cache: %v", err)
+	}
+	if entry != nil {
+		if trusted, err := c.trustedTime(entry); err == nil {
+			if claims, err := c.verifyFile(entry.LicenseFile, latestTime(c.now(), trusted)); err == nil {
+				c.cache = entry
+				c.claims = claims
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Fingerprint 返回本机设备指纹
+func (c *Client) Fingerprint() string {
+	return Fingerprint(c.device)
+}
+
+// License 返回当前缓存的授权内容，未激活时返回nil
+func (c *Client) License() *model.LicenseClaims {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.claims
+}
+
+// InGrace 当前授权是否已到期并处于宽限期，应用可据此提醒用户尽快续期
+func (c *Client) InGrace() bool {
+	claims := c.License()
+	return claims != nil && c.currentTime().After(claims.ExpireTime)
+}
+
+// currentTime 返回本地时钟与缓存中可信时间的较晚者，回拨本地时钟不能让授权回到到期之前
+func (c *Client) currentTime() time.Time {
+	now := c.now()
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry != nil {
+		if trusted, err := c.trustedTime(entry); err == nil {
+			now = latestTime(now, trusted)
+		}
+	}
+	return now
+}
+
+// HasFeature 根据缓存的授权文件离线检查功能是否开启，不包含同一客户的功能模块授权
+func (c *Client) HasFeature(feature string) bool {
+	claims := c.License()
+	if claims == nil {
+		return false
+	}
+	entitlement, ok := utils.ParseEntitlements(claims.Features)[feature]
+	return ok && entitlement.Enabled
+}
+
+// CheckEntitlement 在线查询功能是否开启及其数量限制，结果包含同一客户的功能模块授权
+func (c *Client) CheckEntitlement(ctx context.Context, feature string) (*model.Entitlement, error) {
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry == nil {
+		return nil, ErrNotActivated
+	}
+
+	var data model.ClientEntitlementData
+	if err := c.post(ctx, "/client/v1/entitlements/check", model.ClientEntitlementRequest{
+		Code:    entry.Code,
+		Device:  *c.device,
+		Feature: feature,
+	}, &data); err != nil {
+		return nil, err
+	}
+	return data.Entitlement, nil
+}
+
+// Activate 使用授权码在本机激活授权并缓存签名授权文件
+func (c *Client) Activate(ctx context.Context, code string) (*model.LicenseClaims, error) {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.call(ctx, "/client/v1/activate", code)
+	if err != nil {
+		return nil, err
+	}
+	return c.store(code, data)
+}
+
+// Checkout 签出浮动授权租约，租约需通过Heartbeat续约，退出时调用Release归还
+func (c *Client) Checkout(ctx context.Context, code string) (*model.LicenseClaims, error) {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.call(ctx, "/client/v1/checkout", code)
+	if err != nil {
+		return nil, err
+	}
+	return c.store(code, data)
+}
+
+// StartTrial 为本机申请产品的试用授权并缓存签名授权文件，email可为空
+// 本机、所在网段或邮箱已申请过试用时返回结果码为TRIAL_NOT_ALLOWED的APIError
+func (c *Client) StartTrial(ctx context.Context, email string) (*model.LicenseClaims, error) {
+	var data *model.ClientLicenseData
+	if err := c.post(ctx, "/client/v1/trial", model.ClientTrialRequest{
+		Device: *c.device,
+		Email:  email,
+	}, &data); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("server response does not contain a license")
+	}
+	return c.store(data.Code, data)
+}
+
+// Release 归还浮动授权租约并清除本地缓存
+func (c *Client) Release(ctx context.Context) error {
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry == nil {
+		return ErrNotActivated
+	}
+
+	if _, err := c.call(ctx, "/client/v1/release", entry.Code); err != nil {
+		return err
+	}
+	return c.clear()
+}
+
+// Verify 校验授权，优先在线校验；服务器不可达时使用本地缓存并受离线宽限期限制
+func (c *Client) Verify(ctx context.Context) (*model.LicenseClaims, error) {
+	return c.refresh(ctx, "/client/v1/verify")
+}
+
+// Heartbeat 发送心跳并刷新授权，服务器不可达时同Verify降级处理
+func (c *Client) Heartbeat(ctx context.Context) (*model.LicenseClaims, error) {
+	return c.refresh(ctx, "/client/v1/heartbeat")
+}
+
+// Deactivate 在服务端释放本机授权并清除本地缓存
+func (c *Client) Deactivate(ctx context.Context) error {
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry == nil {
+		return ErrNotActivated
+	}
+
+	if _, err := c.call(ctx, "/client/v1/deactivate", entry.Code); err != nil {
+		return err
+	}
+	return c.clear()
+}
+
+// ReportUsage 上报按量计费用量，idempotencyKey相同的上报只计量一次，失败后可使用同一个键重试
+func (c *Client) ReportUsage(ctx context.Context, idempotencyKey string, quantity int64) (*model.ClientUsageData, error) {
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry == nil {
+		return nil, ErrNotActivated
+	}
+
+	var data model.ClientUsageData
+	if err := c.post(ctx, "/client/v1/usage", model.ClientUsageRequest{
+		Code:           entry.Code,
+		Device:         *c.device,
+		IdempotencyKey: idempotencyKey,
+		Quantity:       quantity,
+	}, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Start 启动后台任务，定期发送心跳并在线校验授权
+func (c *Client) Start() {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = true
+	c.stopChan = make(chan struct{})
+	stopChan := c.stopChan
+	c.mu.Unlock()
+
+	go func() {
+		heartbeat := time.NewTicker(c.heartbeatInterval())
+		revalidate := time.NewTicker(c.config.RevalidateInterval)
+		defer heartbeat.Stop()
+		defer revalidate.Stop()
+
+		for {
+			select {
+			case <-heartbeat.C:
+				c.Heartbeat(context.Background())
+			case <-revalidate.C:
+				c.Verify(context.Background())
+				c.SyncRevocations(context.Background())
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台任务
+func (c *Client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+	close(c.stopChan)
+	c.running = false
+}
+
+// heartbeatInterval 返回服务端下发的心跳间隔
+func (c *Client) heartbeatInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cache == nil || c.cache.HeartbeatInterval <= 0 {
+		return defaultHeartbeatInterval
+	}
+	return time.Duration(c.cache.HeartbeatInterval) * time.Second
+}
+
+// refresh 在线刷新授权，网络不可用时回退到本地缓存
+func (c *Client) refresh(ctx context.Context, path string) (*model.LicenseClaims, error) {
+	c.mu.RLock()
+	entry := c.cache
+	c.mu.RUnlock()
+	if entry == nil {
+		return nil, ErrNotActivated
+	}
+
+	data, err := c.call(ctx, path, entry.Code)
+	if err == nil {
+		return c.store(entry.Code, data)
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && isRejection(apiErr.Code) {
+		// 服务端明确拒绝，清除缓存不再离线使用
+		if clearErr := c.clear(); clearErr != nil {
+			return nil, clearErr
+		}
+		return nil, err
+	}
+
+	return c.offline(entry)
+}
+
+// isRejection 判断结果码是否表示服务端明确拒绝了该授权
+func isRejection(code model.ClientCode) bool {
+	switch code {
+	case model.ClientCodeLicenseNotFound,
+		model.ClientCodeLicenseExpired,
+		model.ClientCodeLicenseDisabled,
+		model.ClientCodeLicenseNotActivated,
+		model.ClientCodeDeviceBlocked,
+		model.ClientCodeLeaseNotFound,
+		model.ClientCodeActivationDenied:
+		return true
+	}
+	return false
+}
+
+// offline 离线校验缓存的签名授权文件，浮动授权的租约到期后不可离线使用
+// 离线宽限期从最近一次服务端签名的时间起算，签名授权文件标记为离线激活的授权从未在线校验过，不受离线宽限期限制
+// 本地时钟早于该签名时间时视为时钟被回拨，拒绝离线使用
+func (c *Client) offline(entry *cacheEntry) (*model.LicenseClaims, error) {
+	trusted, err := c.trustedTime(entry)
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	if now.Before(trusted.Add(-clockRollbackTolerance)) {
+		return nil, ErrClockTampered
+	}
+	now = latestTime(now, trusted)
+
+	claims, err := c.verifyFile(entry.LicenseFile, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.Offline && now.Sub(trusted) > c.config.OfflineGracePeriod {
+		return nil, ErrOfflineGraceExpired
+	}
+	return claims, nil
+}
+
+// trustedTime 返回缓存中服务端签名的时间，没有有效的签名时间时使用授权文件的签发时间
+// 两者都经过签名校验，篡改缓存不能把可信时间推后
+func (c *Client) trustedTime(entry *cacheEntry) (time.Time, error) {
+	if entry.ServerTime != nil {
+		if publicKey, err := c.verificationKey(entry.ServerTime.KeyID); err == nil {
+			if serverTime, err := utils.VerifyServerTime(entry.ServerTime, publicKey, c.Fingerprint()); err == nil {
+				return serverTime.Time, nil
+			}
+		}
+	}
+	if entry.LicenseFile == nil {
+		return time.Time{}, errors.New("license file is empty")
+	}
+	publicKey, err := c.verificationKey(entry.LicenseFile.KeyID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims, err := utils.VerifyLicenseFile(entry.LicenseFile, publicKey, c.Fingerprint())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return claims.IssuedAt, nil
+}
+
+// latestTime 返回两个时间中较晚的一个
+func latestTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// verifyFile 按密钥ID选择受信任的公钥校验签名授权文件，并以now检查设备绑定、吊销列表和有效期(含宽限期)
+func (c *Client) verifyFile(file *model.LicenseFile, now time.Time) (*model.LicenseClaims, error) {
+	if file == nil {
+		return nil, errors.New("license file is empty")
+	}
+	publicKey, err := c.verificationKey(file.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := utils.VerifyLicenseFile(file, publicKey, c.Fingerprint())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Fingerprint == "" {
+		return nil, ErrNotActivated
+	}
+	if c.revoked(claims) {
+		return nil, ErrLicenseRevoked
+	}
+	if now.After(claims.ExpireTime) &&
+		(claims.GraceExpireTime == nil || now.After(*claims.GraceExpireTime)) {
+		return nil, ErrLicenseExpired
+	}
+	if claims.LeaseExpiresAt != nil && now.After(*claims.LeaseExpiresAt) {
+		return nil, ErrLeaseExpired
+	}
+	return claims, nil
+}
+
+// store 校验服务端返回的授权文件并写入缓存
+func (c *Client) store(code string, data *model.ClientLicenseData) (*model.LicenseClaims, error) {
+	if data == nil || data.LicenseFile == nil {
+		return nil, errors.New("server response does not contain a license file")
+	}
+
+	// 以服务端签名的时间作为可信时间，没有签名时间时使用本地时钟与授权文件签发时间的较晚者
+	trusted := c.now()
+	if data.SignedServerTime == nil {
+		if publicKey, err := c.verificationKey(data.LicenseFile.KeyID); err == nil {
+			if claims, err := utils.VerifyLicenseFile(data.LicenseFile, publicKey, c.Fingerprint()); err == nil {
+				trusted = latestTime(trusted, claims.IssuedAt)
+			}
+		}
+	} else {
+		publicKey, err := c.verificationKey(data.SignedServerTime.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server time: %v", err)
+		}
+		serverTime, err := utils.VerifyServerTime(data.SignedServerTime, publicKey, c.Fingerprint())
+		if err != nil {
+			return nil, fmt.Errorf("invalid server time: %v", err)
+		}
+		trusted = serverTime.Time
+	}
+
+	claims, err := c.verifyFile(data.LicenseFile, trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		Code:              code,
+		DeviceID:          data.DeviceID,
+		LicenseFile:       data.LicenseFile,
+		HeartbeatInterval: data.HeartbeatInterval,
+		ServerTime:        data.SignedServerTime,
+	}
+	if err := saveCache(c.config.CachePath, entry); err != nil {
+		return nil, fmt.Errorf("failed to save license cache: %v", err)
+	}
+
+	c.mu.Lock()
+	c.cache = entry
+	c.claims = claims
+	c.mu.Unlock()
+	return claims, nil
+}
+
+// normalizeCode 规范化用户输入的授权码，并在请求服务端前通过校验字符识别输错的授权码
+func normalizeCode(code string) (string, error) {
+	code = utils.NormalizeLicenseKey(code)
+	if err := utils.CheckLicenseCode(code); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidLicenseKey, err)
+	}
+	return code, nil
+}
+
+// clear 清除内存和磁盘上的授权缓存
+func (c *Client) clear() error {
+	c.mu.Lock()
+	c.cache = nil
+	c.claims = nil
+	c.mu.Unlock()
+	return removeCache(c.config.CachePath)
+}
+
+// call 调用客户端授权API，响应使用尚未信任的密钥签名时先同步签名公钥
+func (c *Client) call(ctx context.Context, path string, code string) (*model.ClientLicenseData, error) {
+	var data *model.ClientLicenseData
+	now := c.now()
+	err := c.post(ctx, path, model.ClientLicenseRequest{
+		Code:       code,
+		Device:     *c.device,
+		ClientTime: &now,
+	}, &data)
+	if err == nil && data != nil && (!c.knowsKey(data.LicenseFile) || !c.knowsKey(data.SignedServerTime)) {
+		if err := c.SyncKeys(ctx); err != nil {
+			return nil, fmt.Errorf("failed to sync signing keys: %v", err)
+		}
+	}
+	return data, err
+}
+
+// post 发送客户端API请求并解析响应中的data
+func (c *Client) post(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.ServerURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, out)
+}
+
+// get 发送客户端API查询请求并解析响应中的data
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.ServerURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+// do 附加客户端密钥发送请求并解析客户端API响应
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set(clientKeyHeader, c.config.ClientKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    model.ClientCode `json:"code"`
+		Message string           `json:"message"`
+		Data    json.RawMessage  `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Code: model.ClientCodeInternalError, Message: err.Error()}
+	}
+	if result.Code != model.ClientCodeOK && result.Code != model.ClientCodeLicenseInGrace {
+		return &APIError{StatusCode: resp.StatusCode, Code: result.Code, Message: result.Message}
+	}
+	if out == nil || len(result.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
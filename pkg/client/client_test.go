@@ -0,0 +1,623 @@
+package client_test
+
+import (
+	"LVerity/pkg/client"
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/router"
+	"LVerity/pkg/service"
+	"LVerity/pkg/utils"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupServer 使用临时SQLite数据库启动运行真实路由的测试服务器
+func setupServer(t *testing.T) (*httptest.Server, *model.Product, string) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+	database.SetDB(db)
+
+	require.NoError(t, service.InitLicenseSigner(filepath.Join(dir, "signing.key")))
+	publicKey, err := service.GetLicensePublicKey()
+	require.NoError(t, err)
+
+	product := &model.Product{ID: "p-test", Name: "Test Product"}
+	require.NoError(t, service.NewProductService().CreateProduct(product))
+
+	server := httptest.NewServer(router.SetupRouter())
+	t.Cleanup(server.Close)
+	return server, product, utils.EncodePublicKey(publicKey)
+}
+
+func newClient(t *testing.T, serverURL string, product *model.Product, publicKey string, cachePath string) *client.Client {
+	return newDeviceClient(t, serverURL, product, publicKey, cachePath, "001")
+}
+
+func newDeviceClient(t *testing.T, serverURL string, product *model.Product, publicKey string, cachePath string, serial string) *client.Client {
+	c, err := client.New(client.Config{
+		ServerURL: serverURL,
+		ClientKey: product.ClientKey,
+		PublicKey: publicKey,
+		CachePath: cachePath,
+		Device:    testDevice(serial),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func testDevice(serial string) *model.ClientDevice {
+	return &model.ClientDevice{
+		Name:        "test-device-" + serial,
+		DiskID:      "disk-" + serial,
+		BIOS:        "bios-" + serial,
+		Motherboard: "board-" + serial,
+	}
+}
+
+func TestClientActivateAndVerify(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", []string{"export"}, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Verify(context.Background())
+	assert.ErrorIs(t, err, client.ErrNotActivated)
+
+	claims, err := c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, claims.LicenseID)
+	assert.Equal(t, c.Fingerprint(), claims.Fingerprint)
+	assert.True(t, c.HasFeature("export"))
+
+	claims, err = c.Heartbeat(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, license.Code, claims.Code)
+
+	// 重新创建的客户端从磁盘缓存恢复授权
+	restored := newClient(t, server.URL, product, publicKey, cachePath)
+	require.NotNil(t, restored.License())
+	assert.Equal(t, license.ID, restored.License().LicenseID)
+
+	require.NoError(t, c.Deactivate(context.Background()))
+	assert.Nil(t, c.License())
+	_, err = os.Stat(cachePath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestClientOfflineFallback(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 服务器不可达时使用缓存的签名授权文件
+	server.Close()
+	claims, err := c.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, claims.LicenseID)
+
+	// 超过离线宽限期后拒绝
+	stale, err := client.New(client.Config{
+		ServerURL:          server.URL,
+		ClientKey:          product.ClientKey,
+		PublicKey:          publicKey,
+		CachePath:          cachePath,
+		Device:             testDevice("001"),
+		OfflineGracePeriod: time.Millisecond,
+	})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = stale.Verify(context.Background())
+	assert.ErrorIs(t, err, client.ErrOfflineGraceExpired)
+}
+
+func TestClientRejectedLicense(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DisableLicense(license.Code))
+
+	_, err = c.Verify(context.Background())
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeLicenseDisabled, apiErr.Code)
+	assert.Nil(t, c.License())
+
+	// 错误的客户端密钥
+	other := newClient(t, server.URL, &model.Product{ClientKey: "invalid"}, publicKey, filepath.Join(t.TempDir(), "other.json"))
+	_, err = other.Activate(context.Background(), license.Code)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeInvalidClientKey, apiErr.Code)
+}
+
+func TestClientDeviceLimit(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	dir := t.TempDir()
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	first := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "first.json"), "001")
+	second := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "second.json"), "002")
+	third := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "third.json"), "003")
+
+	_, err = first.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	_, err = second.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 重复激活同一设备不占用新名额
+	_, err = first.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	_, err = third.Activate(context.Background(), license.Code)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, apiErr.Code)
+
+	// 停用后释放名额
+	require.NoError(t, first.Deactivate(context.Background()))
+	claims, err := third.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.Equal(t, third.Fingerprint(), claims.Fingerprint)
+}
+
+func TestClientFloatingLease(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	dir := t.TempDir()
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.UpdateLicenseSeatMode(license.ID, model.LicenseSeatModeFloating, 300))
+
+	first := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "first.json"), "001")
+	second := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "second.json"), "002")
+
+	claims, err := first.Checkout(context.Background(), license.Code)
+	require.NoError(t, err)
+	require.NotNil(t, claims.LeaseExpiresAt)
+
+	_, err = first.Heartbeat(context.Background())
+	require.NoError(t, err)
+
+	_, err = second.Checkout(context.Background(), license.Code)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, apiErr.Code)
+
+	// 归还后其他设备可以签出
+	require.NoError(t, first.Release(context.Background()))
+	_, err = second.Checkout(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 租约超时后心跳失败，其他设备可以重新签出
+	require.NoError(t, database.GetDB().Model(&model.LicenseLease{}).
+		Where("license_id = ? AND status = ?", license.ID, model.LeaseStatusActive).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	_, err = second.Heartbeat(context.Background())
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeLeaseNotFound, apiErr.Code)
+
+	_, err = first.Checkout(context.Background(), license.Code)
+	require.NoError(t, err)
+}
+
+func TestClientReportUsage(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+
+	license, err := service.GenerateLicense(model.LicenseTypePay, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 5)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	usage, err := c.ReportUsage(context.Background(), "job-1", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), usage.UsageCount)
+	assert.Equal(t, int64(2), usage.Remaining)
+
+	// 相同幂等键重试不重复计量
+	usage, err = c.ReportUsage(context.Background(), "job-1", 3)
+	require.NoError(t, err)
+	assert.True(t, usage.Duplicate)
+	assert.Equal(t, int64(3), usage.UsageCount)
+
+	_, err = c.ReportUsage(context.Background(), "job-2", 3)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeUsageLimitReached, apiErr.Code)
+
+	usage, err = c.ReportUsage(context.Background(), "job-3", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Remaining)
+}
+
+func TestClientCheckEntitlement(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+
+	base, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", []string{"export_pdf", "max_users=50", "api=false"}, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	_, err = c.Activate(context.Background(), base.Code)
+	require.NoError(t, err)
+
+	assert.True(t, c.HasFeature("export_pdf"))
+	assert.False(t, c.HasFeature("api"))
+
+	users, err := c.CheckEntitlement(context.Background(), "max_users")
+	require.NoError(t, err)
+	assert.True(t, users.Enabled)
+	require.NotNil(t, users.Limit)
+	assert.Equal(t, int64(50), *users.Limit)
+	assert.Equal(t, []string{base.ID}, users.Sources)
+
+	api, err := c.CheckEntitlement(context.Background(), "api")
+	require.NoError(t, err)
+	assert.False(t, api.Enabled)
+
+	missing, err := c.CheckEntitlement(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.False(t, missing.Enabled)
+}
+
+func TestClientExpiryAndRenewal(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.False(t, c.InGrace())
+
+	// 到期后处于默认宽限期内，仍可使用但提示宽限期
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).
+		Update("expire_time", time.Now().AddDate(0, 0, -1)).Error)
+	_, _, err = service.ProcessLicenseExpiry()
+	require.NoError(t, err)
+
+	_, err = c.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, c.InGrace())
+
+	// 宽限期结束后过期
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).
+		Update("expire_time", time.Now().AddDate(0, 0, -30)).Error)
+	_, _, err = service.ProcessLicenseExpiry()
+	require.NoError(t, err)
+
+	_, err = c.Verify(context.Background())
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeLicenseExpired, apiErr.Code)
+
+	// 续期后恢复使用并签发新的到期时间
+	term, err := service.RenewLicense(license.ID, &service.LicenseRenewalRequest{ExtendDays: 30}, "", "")
+	require.NoError(t, err)
+
+	claims, err := c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.True(t, claims.ExpireTime.Equal(term.EndTime))
+	assert.False(t, c.InGrace())
+}
+
+func TestClientSyncRevocations(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	other, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	require.NoError(t, c.SyncRevocations(context.Background()))
+	assert.Equal(t, int64(0), c.RevocationVersion())
+
+	// 其他授权被吊销不影响本机授权
+	require.NoError(t, service.DisableLicense(other.Code))
+	require.NoError(t, c.SyncRevocations(context.Background()))
+	assert.Equal(t, int64(1), c.RevocationVersion())
+	assert.NotNil(t, c.License())
+
+	// 增量同步到本机授权被吊销后清除缓存
+	require.NoError(t, service.DisableLicense(license.Code))
+	err = c.SyncRevocations(context.Background())
+	assert.ErrorIs(t, err, client.ErrLicenseRevoked)
+	assert.Nil(t, c.License())
+
+	// 吊销列表持久化，重新创建客户端后仍然生效
+	reloaded := newClient(t, server.URL, product, publicKey, cachePath)
+	assert.Equal(t, int64(2), reloaded.RevocationVersion())
+}
+
+func TestClientOfflineActivation(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	req := c.NewOfflineActivationRequest(license.Code)
+	resp, err := service.ActivateOffline(license.ID, req, "", "")
+	require.NoError(t, err)
+	claims, err := c.ActivateOffline(resp)
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, claims.LicenseID)
+
+	// 其他设备的响应文件不能导入
+	other := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "other.json"), "002")
+	_, err = other.ActivateOffline(resp)
+	assert.ErrorIs(t, err, utils.ErrFingerprintMismatch)
+
+	// 离线激活的授权不受离线宽限期限制
+	server.Close()
+	airGapped, err := client.New(client.Config{
+		ServerURL:          server.URL,
+		ClientKey:          product.ClientKey,
+		PublicKey:          publicKey,
+		CachePath:          cachePath,
+		Device:             testDevice("001"),
+		OfflineGracePeriod: time.Millisecond,
+	})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	claims, err = airGapped.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, license.Code, claims.Code)
+}
+
+func TestClientLicensePlan(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	grace := 3
+	plan, err := service.CreateLicensePlan(product.ID, &service.LicensePlanRequest{
+		Name:            "Standard",
+		Type:            model.LicenseTypeStandard,
+		DurationDays:    30,
+		MaxDevices:      2,
+		Features:        []string{"export", "seats=5"},
+		GracePeriodDays: &grace,
+	}, "", "")
+	require.NoError(t, err)
+
+	license, err := service.CreateLicenseFromPlan(&service.LicenseFromPlanRequest{PlanID: plan.ID}, "", "")
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	claims, err := c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.True(t, c.HasFeature("export"))
+	require.NotNil(t, claims.GraceExpireTime)
+	assert.WithinDuration(t, claims.ExpireTime.AddDate(0, 0, 3), *claims.GraceExpireTime, time.Second)
+
+	// 方案变更同步到现有授权
+	_, _, err = service.UpdateLicensePlan(plan.ID, &service.LicensePlanRequest{
+		Name:         "Standard",
+		Type:         model.LicenseTypeStandard,
+		DurationDays: 30,
+		MaxDevices:   5,
+		Features:     []string{"export", "report"},
+	}, true, "", "")
+	require.NoError(t, err)
+
+	_, err = c.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, c.HasFeature("report"))
+	assert.Equal(t, 5, c.License().MaxDevices)
+}
+
+func TestClientLicenseKeyFormat(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	product.KeyPrefix = "LV"
+	require.NoError(t, service.NewProductService().UpdateProduct(product))
+	license, err := service.GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 输错的授权码在请求服务端前被拒绝
+	code := license.Code
+	last := code[len(code)-1]
+	mistyped := code[:len(code)-1] + string(utils.LicenseKeyAlphabet[(strings.IndexByte(utils.LicenseKeyAlphabet, last)+1)%len(utils.LicenseKeyAlphabet)])
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), mistyped)
+	assert.ErrorIs(t, err, client.ErrInvalidLicenseKey)
+
+	// 小写输入规范化后可以正常激活
+	claims, err := c.Activate(context.Background(), strings.ToLower(code))
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, claims.LicenseID)
+
+	// 旧版UUID授权码仍然可用
+	legacy, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	legacyCode := utils.GenerateUUID()
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", legacy.ID).Update("code", legacyCode).Error)
+	other := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "legacy.json"), "002")
+	_, err = other.Activate(context.Background(), legacyCode)
+	require.NoError(t, err)
+}
+
+func TestTolerantFingerprintMatching(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	original := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"), "001")
+	_, err = original.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 更换硬盘后三个组件匹配两个，仍识别为原设备，不占用新的名额
+	replaced, err := client.New(client.Config{
+		ServerURL: server.URL,
+		ClientKey: product.ClientKey,
+		PublicKey: publicKey,
+		CachePath: filepath.Join(t.TempDir(), "license.json"),
+		Device: &model.ClientDevice{
+			Name:        "test-device-001",
+			DiskID:      "disk-replaced",
+			BIOS:        "bios-001",
+			Motherboard: "board-001",
+		},
+	})
+	require.NoError(t, err)
+	claims, err := replaced.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	assert.Equal(t, replaced.Fingerprint(), claims.Fingerprint)
+	assert.NotEqual(t, original.Fingerprint(), claims.Fingerprint)
+}
+
+func TestClientTrial(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+
+	c := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	claims, err := c.StartTrial(context.Background(), "User@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTypeTrial, claims.Type)
+	assert.Equal(t, c.Fingerprint(), claims.Fingerprint)
+
+	// 同一设备再次申请被拒绝，即使换了邮箱
+	again := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	_, err = again.StartTrial(context.Background(), "other@example.com")
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, model.ClientCodeTrialNotAllowed, apiErr.Code)
+
+	// 转为正式授权后校验即可获取新的授权信息
+	_, err = service.ConvertTrialLicense(claims.LicenseID, &service.ConvertTrialRequest{
+		Type:       model.LicenseTypePro,
+		ExpireTime: time.Now().AddDate(1, 0, 0),
+		MaxDevices: 3,
+	}, "u-1", "admin")
+	require.NoError(t, err)
+	claims, err = c.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTypePro, claims.Type)
+	assert.Equal(t, 3, claims.MaxDevices)
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	dir := t.TempDir()
+
+	first, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	c := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "first.json"), "001")
+	_, err = c.Activate(context.Background(), first.Code)
+	require.NoError(t, err)
+
+	// 轮换默认密钥后，原密钥签发的授权文件仍可校验
+	_, err = service.RotateSigningKey(&service.RotateSigningKeyRequest{}, "", "")
+	require.NoError(t, err)
+	_, err = c.Verify(context.Background())
+	require.NoError(t, err)
+
+	// 新密钥签发的授权文件在同步根密钥背书的公钥后校验，重启后从缓存恢复
+	second, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	d := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "second.json"), "002")
+	_, err = d.Activate(context.Background(), second.Code)
+	require.NoError(t, err)
+	restored := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "second.json"), "002")
+	require.NotNil(t, restored.License())
+	require.NoError(t, d.SyncRevocations(context.Background()))
+
+	// 作废产品密钥后，校验时获取默认密钥重新签发的授权文件
+	productKey, err := service.RotateSigningKey(&service.RotateSigningKeyRequest{ProductID: product.ID}, "", "")
+	require.NoError(t, err)
+	third, err := service.GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	e := newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "third.json"), "003")
+	_, err = e.Activate(context.Background(), third.Code)
+	require.NoError(t, err)
+	_, err = service.RevokeSigningKey(productKey.ID, "", "")
+	require.NoError(t, err)
+	_, err = e.Verify(context.Background())
+	require.NoError(t, err)
+	restored = newDeviceClient(t, server.URL, product, publicKey, filepath.Join(dir, "third.json"), "003")
+	require.NotNil(t, restored.License())
+}
+
+func TestActivationPolicy(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	service.SetLocationResolver(func(ip string) (*model.Location, error) {
+		return &model.Location{Country: "Germany", CountryCode: "DE"}, nil
+	})
+	t.Cleanup(func() { service.SetLocationResolver(nil) })
+
+	plan, err := service.CreateLicensePlan(product.ID, &service.LicensePlanRequest{
+		Name:             "Export Controlled",
+		Type:             model.LicenseTypeStandard,
+		DurationDays:     30,
+		MaxDevices:       1,
+		ActivationPolicy: &model.ActivationPolicy{DeniedCountries: []string{"DE"}},
+	}, "", "")
+	require.NoError(t, err)
+	license, err := service.CreateLicenseFromPlan(&service.LicenseFromPlanRequest{PlanID: plan.ID}, "", "")
+	require.NoError(t, err)
+
+	// 授权方案禁止的国家不能激活，返回拒绝原因
+	c := newClient(t, server.URL, product, publicKey, filepath.Join(t.TempDir(), "license.json"))
+	_, err = c.Activate(context.Background(), license.Code)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeActivationDenied, apiErr.Code)
+	assert.Contains(t, apiErr.Message, "Germany")
+
+	// 授权上的策略优先于授权方案
+	_, err = service.SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{AllowedCIDRs: []string{"127.0.0.0/8"}}, "", "")
+	require.NoError(t, err)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 心跳时同样校验，被拒绝后清除本地缓存
+	_, err = service.SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}}, "", "")
+	require.NoError(t, err)
+	_, err = c.Heartbeat(context.Background())
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, model.ClientCodeActivationDenied, apiErr.Code)
+	assert.Nil(t, c.License())
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSignedCacheClient 使用测试根密钥签发授权文件和服务端时间，写入缓存后创建客户端
+func newSignedCacheClient(t *testing.T, serverTime time.Time) *Client {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	device := &model.ClientDevice{DiskID: "disk-001", BIOS: "bios-001", Motherboard: "board-001"}
+	fingerprint := Fingerprint(device)
+	file, err := utils.SignLicenseClaims(&model.LicenseClaims{
+		PayloadType: model.LicenseClaimsType,
+		LicenseID:   "l-1",
+		Code:        "CODE",
+		Fingerprint: fingerprint,
+		StartTime:   serverTime.AddDate(0, -1, 0),
+		ExpireTime:  serverTime.AddDate(1, 0, 0),
+		IssuedAt:    serverTime.AddDate(0, 0, -3),
+	}, privateKey)
+	require.NoError(t, err)
+	token, err := utils.SignServerTime(&model.ServerTimeClaims{
+		Type:        model.ServerTimeClaimsType,
+		Time:        serverTime,
+		Fingerprint: fingerprint,
+	}, privateKey)
+	require.NoError(t, err)
+
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+	require.NoError(t, saveCache(cachePath, &cacheEntry{Code: "CODE", LicenseFile: file, ServerTime: token}))
+	c, err := New(Config{
+		ServerURL: "http://127.0.0.1:0",
+		PublicKey: utils.EncodePublicKey(publicKey),
+		CachePath: cachePath,
+		Device:    device,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, c.cache)
+	return c
+}
+
+func TestOfflineClockRollback(t *testing.T) {
+	serverTime := time.Now()
+	c := newSignedCacheClient(t, serverTime)
+
+	c.now = func() time.Time { return serverTime.Add(time.Hour) }
+	_, err := c.offline(c.cache)
+	require.NoError(t, err)
+
+	// 本地时钟早于服务端签名的时间
+	c.now = func() time.Time { return serverTime.Add(-2 * time.Hour) }
+	_, err = c.offline(c.cache)
+	assert.ErrorIs(t, err, ErrClockTampered)
+
+	// 离线宽限期从服务端签名的时间起算
+	c.now = func() time.Time { return serverTime.Add(defaultOfflineGracePeriod + time.Hour) }
+	_, err = c.offline(c.cache)
+	assert.ErrorIs(t, err, ErrOfflineGraceExpired)
+}
+
+func TestOfflineForgedServerTime(t *testing.T) {
+	serverTime := time.Now()
+	c := newSignedCacheClient(t, serverTime)
+
+	// 使用其他密钥伪造更晚的服务端时间不被采信，回退到授权文件的签发时间
+	_, forgedKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	forged, err := utils.SignServerTime(&model.ServerTimeClaims{
+		Type:        model.ServerTimeClaimsType,
+		Time:        serverTime.AddDate(0, 0, 30),
+		Fingerprint: c.Fingerprint(),
+	}, forgedKey)
+	require.NoError(t, err)
+	c.cache.ServerTime = forged
+
+	c.now = func() time.Time { return serverTime.AddDate(0, 0, 30) }
+	_, err = c.offline(c.cache)
+	assert.ErrorIs(t, err, ErrOfflineGraceExpired)
+}
+
+func TestInGraceUsesClientClock(t *testing.T) {
+	serverTime := time.Now()
+	c := newSignedCacheClient(t, serverTime)
+	claims := c.License()
+	require.NotNil(t, claims)
+
+	c.now = func() time.Time { return serverTime }
+	assert.False(t, c.InGrace())
+
+	// 本地时钟越过到期时间后进入宽限期
+	c.now = func() time.Time { return claims.ExpireTime.Add(time.Hour) }
+	assert.True(t, c.InGrace())
+
+	// 回拨本地时钟不能早于缓存中的可信时间
+	c.now = func() time.Time { return serverTime.AddDate(-2, 0, 0) }
+	assert.Equal(t, serverTime.Unix(), c.currentTime().Unix())
+}
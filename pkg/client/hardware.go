@@ -0,0 +1,171 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// CollectDevice 采集本机硬件标识（硬盘ID、BIOS、主板），与服务端GenerateFingerprint使用相同的字段
+func CollectDevice() (*model.ClientDevice, error) {
+	var device *model.ClientDevice
+	switch runtime.GOOS {
+	case "linux":
+		device = collectLinux()
+	case "windows":
+		device = collectWindows()
+	case "darwin":
+		device = collectDarwin()
+	default:
+		return nil, errors.New("unsupported platform: " + runtime.GOOS)
+	}
+
+	if device.DiskID == "" && device.BIOS == "" && device.Motherboard == "" {
+		return nil, errors.New("failed to collect hardware identifiers")
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		device.Name = hostname
+	}
+	return device, nil
+}
+
+// Fingerprint 计算设备指纹
+func Fingerprint(device *model.ClientDevice) string {
+	return utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard)
+}
+
+// collectLinux 从sysfs读取硬件标识
+func collectLinux() *model.ClientDevice {
+	// 读取不到序列号时以厂商和型号代替，并标记为型号级信息
+	var fallback []string
+	bios := readFirst("/sys/class/dmi/id/product_uuid", "/sys/class/dmi/id/product_serial")
+	if bios == "" {
+		bios = strings.TrimSpace(readFirst("/sys/class/dmi/id/bios_vendor") + " " + readFirst("/sys/class/dmi/id/bios_version"))
+		fallback = append(fallback, model.FingerprintComponentBIOS)
+	}
+	motherboard := readFirst("/sys/class/dmi/id/board_serial")
+	if motherboard == "" {
+		motherboard = strings.TrimSpace(readFirst("/sys/class/dmi/id/board_vendor") + " " + readFirst("/sys/class/dmi/id/board_name"))
+		fallback = append(fallback, model.FingerprintComponentMotherboard)
+	}
+
+	return &model.ClientDevice{
+		DiskID:      linuxDiskID(),
+		BIOS:        bios,
+		Motherboard: motherboard,
+		Fallback:    fallback,
+	}
+}
+
+// linuxDiskID 获取第一块物理硬盘的标识
+func linuxDiskID() string {
+	entries, err := filepath.Glob("/dev/disk/by-id/*")
+	if err == nil {
+		sort.Strings(entries)
+		for _, entry := range entries {
+			name := filepath.Base(entry)
+			if strings.Contains(name, "-part") || strings.HasPrefix(name, "wwn-") {
+				continue
+			}
+			return name
+		}
+	}
+	return readFirst("/etc/machine-id", "/var/lib/dbus/machine-id")
+}
+
+// collectWindows 通过wmic读取硬件标识，厂商未写入序列号时BIOS和主板返回的占位值按型号级信息处理
+func collectWindows() *model.ClientDevice {
+	device := &model.ClientDevice{
+		DiskID:      wmicValue("diskdrive", "SerialNumber"),
+		BIOS:        wmicValue("bios", "SerialNumber"),
+		Motherboard: wmicValue("baseboard", "SerialNumber"),
+	}
+	if isPlaceholderSerial(device.BIOS) {
+		device.Fallback = append(device.Fallback, model.FingerprintComponentBIOS)
+	}
+	if isPlaceholderSerial(device.Motherboard) {
+		device.Fallback = append(device.Fallback, model.FingerprintComponentMotherboard)
+	}
+	return device
+}
+
+// placeholderSerials 常见的未写入序列号时的占位值
+var placeholderSerials = map[string]bool{
+	"to be filled by o.e.m.": true,
+	"default string":         true,
+	"system serial number":   true,
+	"not applicable":         true,
+	"none":                   true,
+	"0":                      true,
+}
+
+// isPlaceholderSerial 判断序列号是否为占位值
+func isPlaceholderSerial(value string) bool {
+	return placeholderSerials[strings.ToLower(strings.TrimSpace(value))]
+}
+
+// wmicValue 执行wmic查询并返回第一项结果
+func wmicValue(class, property string) string {
+	output, err := exec.Command("wmic", class, "get", property).Output()
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n")
+	for _, line := range lines[1:] {
+		if value := strings.TrimSpace(line); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// collectDarwin 通过ioreg读取硬件标识
+func collectDarwin() *model.ClientDevice {
+	output, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return &model.ClientDevice{}
+	}
+	// board-id标识的是机型而不是单台设备
+	return &model.ClientDevice{
+		DiskID:      ioregValue(string(output), "IOPlatformUUID"),
+		BIOS:        ioregValue(string(output), "IOPlatformSerialNumber"),
+		Motherboard: ioregValue(string(output), "board-id"),
+		Fallback:    []string{model.FingerprintComponentMotherboard},
+	}
+}
+
+// ioregValue 从ioreg输出中解析属性值
+func ioregValue(output, key string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, "\""+key+"\"") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(parts[1]), "<>\"")
+	}
+	return ""
+}
+
+// readFirst 返回第一个可读取且非空的文件内容
+func readFirst(paths ...string) string {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if value := strings.TrimSpace(string(data)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
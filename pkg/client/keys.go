@@ -0,0 +1,113 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrUnknownSigningKey = errors.New("license file is signed by an unknown key")
+
+// SyncKeys 从服务端同步根密钥签名的签名公钥集合，只信任内置根公钥背书的密钥，签名集合写入本地缓存
+// 服务端轮换密钥后，客户端据此校验新密钥签发的授权文件；已作废的密钥列在签名集合中，同步后不再信任
+func (c *Client) SyncKeys(ctx context.Context) error {
+	var file *model.SignedSigningKeySet
+	if err := c.get(ctx, "/client/v1/keys", &file); err != nil {
+		return err
+	}
+	if file == nil {
+		return errors.New("server response does not contain signing keys")
+	}
+	set, err := utils.VerifySigningKeySet(file, c.publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify signing keys: %v", err)
+	}
+
+	keys := c.trustKeys(set)
+	if err := writeJSONFile(c.keysPath(), file); err != nil {
+		return fmt.Errorf("failed to save signing keys: %v", err)
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	return nil
+}
+
+// trustKeys 返回已签名集合中经根公钥背书且未作废的密钥，根密钥本身不需要背书
+func (c *Client) trustKeys(set *model.SigningKeySet) map[string]ed25519.PublicKey {
+	revoked := make(map[string]bool, len(set.Revoked))
+	for _, keyID := range set.Revoked {
+		revoked[keyID] = true
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+		if jwk.Status == model.SigningKeyStatusRevoked || revoked[jwk.KeyID] {
+			continue
+		}
+		publicKey, err := utils.ParseSigningKeyJWK(jwk)
+		if err != nil || publicKey.Equal(c.publicKey) {
+			continue
+		}
+		if utils.VerifySigningKeyEndorsement(publicKey, jwk.ProductID, jwk.Endorsement, c.publicKey) != nil {
+			continue
+		}
+		keys[jwk.KeyID] = publicKey
+	}
+	return keys
+}
+
+// loadKeys 读取本地缓存的签名公钥集合，重新校验根密钥签名和背书，文件不存在时返回空集合
+// 签名校验失败时不信任缓存中的任何密钥，需要时重新同步
+func (c *Client) loadKeys() (map[string]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(c.keysPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]ed25519.PublicKey{}, nil
+		}
+		return nil, err
+	}
+	var file model.SignedSigningKeySet
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	set, err := utils.VerifySigningKeySet(&file, c.publicKey)
+	if err != nil {
+		return map[string]ed25519.PublicKey{}, nil
+	}
+	return c.trustKeys(set), nil
+}
+
+// verificationKey 按签名文件中的密钥ID选择校验公钥
+func (c *Client) verificationKey(keyID string) (ed25519.PublicKey, error) {
+	if keyID == utils.PublicKeyID(c.publicKey) {
+		return c.publicKey, nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if publicKey, ok := c.keys[keyID]; ok {
+		return publicKey, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// knowsKey 检查签名文件的密钥是否已被信任，未知时需先同步签名公钥
+func (c *Client) knowsKey(file *model.LicenseFile) bool {
+	if file == nil {
+		return true
+	}
+	_, err := c.verificationKey(file.KeyID)
+	return err == nil
+}
+
+// keysPath 返回签名公钥缓存文件路径
+func (c *Client) keysPath() string {
+	return c.config.CachePath + ".keys"
+}
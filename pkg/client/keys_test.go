@@ -0,0 +1,77 @@
+package client_test
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"LVerity/pkg/utils"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSignedKeySet(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+	root, err := utils.ParsePublicKey(publicKey)
+	require.NoError(t, err)
+
+	rotated, err := service.RotateSigningKey(&service.RotateSigningKeyRequest{ProductID: product.ID}, "", "")
+	require.NoError(t, err)
+	license, err := service.GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	require.NotNil(t, newClient(t, server.URL, product, publicKey, cachePath).License())
+
+	// 修改缓存的密钥集合后根密钥签名校验失败，新密钥签发的授权文件不再被信任
+	keysPath := cachePath + ".keys"
+	original, err := os.ReadFile(keysPath)
+	require.NoError(t, err)
+	var file model.SignedSigningKeySet
+	require.NoError(t, json.Unmarshal(original, &file))
+	set, err := utils.VerifySigningKeySet(&file, root)
+	require.NoError(t, err)
+	issuedAt := set.IssuedAt.Add(time.Hour)
+	set.IssuedAt = &issuedAt
+	payload, err := json.Marshal(set)
+	require.NoError(t, err)
+	file.Payload = base64.StdEncoding.EncodeToString(payload)
+	data, err := json.Marshal(file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keysPath, data, 0600))
+	assert.Nil(t, newClient(t, server.URL, product, publicKey, cachePath).License())
+	require.NoError(t, os.WriteFile(keysPath, original, 0600))
+
+	// 作废的密钥列在签名集合中，同步后不再信任其签发的授权文件
+	_, err = service.RevokeSigningKey(rotated.ID, "", "")
+	require.NoError(t, err)
+	signed, err := service.GetSignedSigningKeySet(product.ID)
+	require.NoError(t, err)
+	set, err = utils.VerifySigningKeySet(signed, root)
+	require.NoError(t, err)
+	assert.Contains(t, set.Revoked, rotated.ID)
+	for _, jwk := range set.Keys {
+		assert.NotEqual(t, rotated.ID, jwk.KeyID)
+	}
+	require.NoError(t, c.SyncKeys(context.Background()))
+	assert.Nil(t, newClient(t, server.URL, product, publicKey, cachePath).License())
+
+	// 非根密钥签名的集合不被接受
+	_, err = utils.VerifySigningKeySet(signed, ed25519PublicKey(t, rotated))
+	assert.Error(t, err)
+}
+
+// ed25519PublicKey 解析签名密钥记录中的公钥
+func ed25519PublicKey(t *testing.T, key *model.SigningKey) []byte {
+	publicKey, err := utils.ParsePublicKey(key.PublicKey)
+	require.NoError(t, err)
+	return publicKey
+}
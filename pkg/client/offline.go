@@ -0,0 +1,48 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"errors"
+	"fmt"
+)
+
+// NewOfflineActivationRequest 生成离线激活请求文件内容，由管理员上传到服务端换取激活响应文件
+func (c *Client) NewOfflineActivationRequest(code string) *model.OfflineActivationRequest {
+	return &model.OfflineActivationRequest{
+		Version:     model.OfflineActivationRequestVersion,
+		Code:        code,
+		Device:      *c.device,
+		Fingerprint: c.Fingerprint(),
+		CreatedAt:   c.now(),
+	}
+}
+
+// ActivateOffline 导入服务端签发的离线激活响应文件
+// 服务端签发时在授权文件中标记离线激活，这类授权不受OfflineGracePeriod限制，只受授权文件本身的有效期约束
+func (c *Client) ActivateOffline(resp *model.OfflineActivationResponse) (*model.LicenseClaims, error) {
+	if resp == nil || resp.LicenseFile == nil {
+		return nil, errors.New("offline activation response does not contain a license file")
+	}
+
+	claims, err := c.verifyFile(resp.LicenseFile, c.now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Code != resp.Code {
+		return nil, errors.New("offline activation response does not match its license file")
+	}
+
+	entry := &cacheEntry{
+		Code:        resp.Code,
+		LicenseFile: resp.LicenseFile,
+	}
+	if err := saveCache(c.config.CachePath, entry); err != nil {
+		return nil, fmt.Errorf("failed to save license cache: %v", err)
+	}
+
+	c.mu.Lock()
+	c.cache = entry
+	c.claims = claims
+	c.mu.Unlock()
+	return claims, nil
+}
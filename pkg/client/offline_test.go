@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"LVerity/pkg/client"
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientForgedOfflineCache(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+
+	// 在缓存中伪造离线激活标记不能绕过离线宽限期
+	server.Close()
+	data, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &entry))
+	entry["offline"] = true
+	data, err = json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cachePath, data, 0600))
+
+	forged, err := client.New(client.Config{
+		ServerURL:          server.URL,
+		ClientKey:          product.ClientKey,
+		PublicKey:          publicKey,
+		CachePath:          cachePath,
+		Device:             testDevice("001"),
+		OfflineGracePeriod: time.Millisecond,
+	})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = forged.Verify(context.Background())
+	assert.ErrorIs(t, err, client.ErrOfflineGraceExpired)
+}
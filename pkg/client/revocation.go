@@ -0,0 +1,142 @@
+package client
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// maxRevocationLists 本地保存的签名吊销列表数量上限，超过后改为拉取全量列表
+const maxRevocationLists = 32
+
+// SyncRevocations 从服务端增量同步签名的吊销列表，校验签名后写入本地缓存
+// 当前缓存的授权已被吊销时清除授权缓存并返回ErrLicenseRevoked
+func (c *Client) SyncRevocations(ctx context.Context) error {
+	c.mu.RLock()
+	current := c.revocations
+	c.mu.RUnlock()
+
+	since := current.Version
+	if len(current.Lists) >= maxRevocationLists {
+		since = 0
+	}
+	list, file, err := c.fetchRevocations(ctx, since)
+	if err != nil {
+		return err
+	}
+	// 增量列表无法衔接本地版本时改为拉取全量列表
+	if !list.Full && list.BaseVersion != current.Version {
+		if list, file, err = c.fetchRevocations(ctx, 0); err != nil {
+			return err
+		}
+	}
+
+	next := current.clone()
+	if err := next.apply(list, file); err != nil {
+		return err
+	}
+	next.SyncedAt = c.now()
+
+	if err := writeJSONFile(c.revocationPath(), next); err != nil {
+		return fmt.Errorf("failed to save revocation list: %v", err)
+	}
+
+	c.mu.Lock()
+	c.revocations = next
+	claims := c.claims
+	c.mu.Unlock()
+
+	if claims != nil && c.revoked(claims) {
+		if err := c.clear(); err != nil {
+			return err
+		}
+		return ErrLicenseRevoked
+	}
+	return nil
+}
+
+// RevocationVersion 返回本地吊销列表的版本
+func (c *Client) RevocationVersion() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.revocations.Version
+}
+
+// fetchRevocations 获取并校验since之后的吊销列表，签名密钥未知时先同步签名公钥
+func (c *Client) fetchRevocations(ctx context.Context, since int64) (*model.RevocationList, *model.SignedRevocationList, error) {
+	var file *model.SignedRevocationList
+	if err := c.get(ctx, "/client/v1/revocations?since="+strconv.FormatInt(since, 10), &file); err != nil {
+		return nil, nil, err
+	}
+	if file == nil {
+		return nil, nil, errors.New("revocation list is empty")
+	}
+	if !c.knowsKey(file) {
+		if err := c.SyncKeys(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to sync signing keys: %v", err)
+		}
+	}
+	list, err := c.verifyRevocationList(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, file, nil
+}
+
+// verifyRevocationList 按签名文件中的密钥ID校验吊销列表
+func (c *Client) verifyRevocationList(file *model.SignedRevocationList) (*model.RevocationList, error) {
+	if file == nil {
+		return nil, errors.New("revocation list is empty")
+	}
+	publicKey, err := c.verificationKey(file.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.VerifyRevocationList(file, publicKey)
+}
+
+// loadRevocations 读取本地缓存的签名吊销列表，重新校验签名后按顺序重放，文件不存在时返回空列表
+// 任一列表校验失败时丢弃本地列表，下次同步时重新拉取全量列表
+func (c *Client) loadRevocations() (*revocationCache, error) {
+	data, err := os.ReadFile(c.revocationPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newRevocationCache(), nil
+		}
+		return nil, err
+	}
+	var stored revocationCache
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+
+	cache := newRevocationCache()
+	for _, file := range stored.Lists {
+		list, err := c.verifyRevocationList(file)
+		if err != nil {
+			return newRevocationCache(), nil
+		}
+		if err := cache.apply(list, file); err != nil {
+			return newRevocationCache(), nil
+		}
+	}
+	cache.SyncedAt = stored.SyncedAt
+	return cache, nil
+}
+
+// revoked 判断授权码或本机指纹是否在本地吊销列表中
+func (c *Client) revoked(claims *model.LicenseClaims) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.revocations.Licenses[claims.Code] || c.revocations.Devices[claims.Fingerprint]
+}
+
+// revocationPath 返回吊销列表缓存文件路径
+func (c *Client) revocationPath() string {
+	return c.config.CachePath + ".revocations"
+}
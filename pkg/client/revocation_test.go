@@ -0,0 +1,81 @@
+package client_test
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revocationCacheFile 本地吊销列表缓存文件内容
+type revocationCacheFile struct {
+	Lists    []*model.SignedRevocationList `json:"lists"`
+	SyncedAt time.Time                     `json:"synced_at"`
+}
+
+func TestClientTamperedRevocationCache(t *testing.T) {
+	server, product, publicKey := setupServer(t)
+	cachePath := filepath.Join(t.TempDir(), "license.json")
+	revocationPath := cachePath + ".revocations"
+
+	license, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	first, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	second, err := service.GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	c := newClient(t, server.URL, product, publicKey, cachePath)
+	_, err = c.Activate(context.Background(), license.Code)
+	require.NoError(t, err)
+	require.NoError(t, service.DisableLicense(first.Code))
+	require.NoError(t, c.SyncRevocations(context.Background()))
+	require.NoError(t, service.DisableLicense(second.Code))
+	require.NoError(t, c.SyncRevocations(context.Background()))
+	assert.Equal(t, int64(2), newClient(t, server.URL, product, publicKey, cachePath).RevocationVersion())
+
+	data, err := os.ReadFile(revocationPath)
+	require.NoError(t, err)
+	var stored revocationCacheFile
+	require.NoError(t, json.Unmarshal(data, &stored))
+	require.Len(t, stored.Lists, 2)
+
+	// 修改签名内容删除吊销记录后签名校验失败，本地列表被丢弃
+	var list model.RevocationList
+	payload, err := base64.StdEncoding.DecodeString(stored.Lists[1].Payload)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &list))
+	list.Entries = nil
+	payload, err = json.Marshal(list)
+	require.NoError(t, err)
+	tampered := *stored.Lists[1]
+	tampered.Payload = base64.StdEncoding.EncodeToString(payload)
+	writeRevocationCache(t, revocationPath, stored.Lists[0], &tampered)
+	assert.Equal(t, int64(0), newClient(t, server.URL, product, publicKey, cachePath).RevocationVersion())
+
+	// 同一密钥签名的授权文件不能冒充吊销列表
+	file, err := service.GetLicenseFile(license.ID, "")
+	require.NoError(t, err)
+	writeRevocationCache(t, revocationPath, file)
+	assert.Equal(t, int64(0), newClient(t, server.URL, product, publicKey, cachePath).RevocationVersion())
+
+	// 同步后重新得到完整的签名列表
+	c = newClient(t, server.URL, product, publicKey, cachePath)
+	require.NoError(t, c.SyncRevocations(context.Background()))
+	assert.Equal(t, int64(2), c.RevocationVersion())
+}
+
+// writeRevocationCache 写入指定的签名吊销列表作为本地缓存
+func writeRevocationCache(t *testing.T, path string, lists ...*model.SignedRevocationList) {
+	data, err := json.Marshal(revocationCacheFile{Lists: lists, SyncedAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
@@ -15,6 +15,7 @@ type Config struct {
 	JWT      JWTConfig     `yaml:"jwt"`
 	CORS     CORSConfig    `yaml:"cors"`
 	Log      LogConfig     `yaml:"log"`
+	License  LicenseConfig `yaml:"license"`
 }
 
 // ServerConfig 服务器配置
@@ -61,6 +62,11 @@ type LogConfig struct {
 	Compress   bool   `yaml:"compress"`
 }
 
+// LicenseConfig 授权签名配置
+type LicenseConfig struct {
+	SigningKeyFile string `yaml:"signing_key_file"` // Ed25519签名私钥文件路径
+}
+
 // GlobalConfig 全局配置实例
 var GlobalConfig Config
 
@@ -142,6 +148,11 @@ func loadFromEnv() {
 		GlobalConfig.JWT.Issuer = issuer
 	}
 
+	// 授权签名配置
+	if keyFile := os.Getenv("LICENSE_SIGNING_KEY_FILE"); keyFile != "" {
+		GlobalConfig.License.SigningKeyFile = keyFile
+	}
+
 	// 服务器配置
 	if host := os.Getenv("SERVER_HOST"); host != "" {
 		GlobalConfig.Server.Host = host
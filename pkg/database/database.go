@@ -47,28 +47,7 @@ func InitDB(config *Config) error {
 		}
 
 		// 自动迁移数据库表结构
-		err = DB.AutoMigrate(
-			&model.User{},
-			&model.Permission{},
-			&model.Role{},
-			&model.RolePermission{},
-			&model.UserRole{},
-			&model.License{},
-			&model.LicenseActivation{}, // 添加许可证激活记录模型
-			&model.Device{},
-			&model.DeviceGroup{},
-			&model.DeviceLog{},
-			&model.SystemLog{},
-			&model.Alert{},
-			&model.SystemInfo{}, // 已实现的SystemInfo模型
-			&model.Setting{},    // 已实现的Setting模型
-			&model.Customer{},
-			&model.Product{},
-			&model.SystemBackup{}, // 系统备份模型
-			&model.BackupConfig{}, // 备份配置模型
-			&model.SystemConfig{}, // 系统配置模型
-		)
-
+		err = Migrate(DB)
 		if err != nil {
 			err = fmt.Errorf("数据库迁移失败: %v", err)
 			return
@@ -78,6 +57,61 @@ func InitDB(config *Config) error {
 	return err
 }
 
+// Migrate 自动迁移数据库表结构
+func Migrate(db *gorm.DB) error {
+	// 授权与标签的关联表使用LicenseTagMapping，记录打标签的时间
+	if err := db.SetupJoinTable(&model.License{}, "Tags", &model.LicenseTagMapping{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&model.LicenseTag{}, "Licenses", &model.LicenseTagMapping{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(
+		&model.User{},
+		&model.Permission{},
+		&model.Role{},
+		&model.RolePermission{},
+		&model.UserRole{},
+		&model.License{},
+		&model.LicenseActivation{}, // 添加许可证激活记录模型
+		&model.LicenseLease{},      // 浮动授权租约
+		&model.LicenseUsage{},      // 授权使用记录
+		&model.LicenseTransfer{},   // 授权转移记录
+		&model.MeteredUsage{},      // 按量计费用量记录
+		&model.UsageBucket{},       // 按天汇总的用量
+		&model.LicenseStatusTransition{}, // 授权状态变更记录
+		&model.LicenseTerm{},             // 授权有效期记录
+		&model.RevocationEntry{},         // 吊销列表变更记录
+		&model.RevocationCounter{},       // 吊销列表版本计数
+		&model.LicensePlan{},             // 授权方案
+		&model.LicenseGroup{},            // 授权组
+		&model.LicenseTag{},              // 授权标签
+		&model.LicenseTagMapping{},       // 授权标签关联
+		&model.LicenseVersion{},          // 授权变更历史
+		&model.LicenseTrial{},            // 自助试用记录
+		&model.SigningKey{},              // 授权签名密钥
+		&model.Partner{},                 // 合作伙伴
+		&model.PartnerQuota{},            // 合作伙伴授权配额
+		&model.Device{},
+		&model.DeviceGroup{},
+		&model.DeviceLog{},
+		&model.AbnormalBehavior{},
+		&model.SystemLog{},
+		&model.OperationLog{},
+		&model.Alert{},
+		&model.SystemInfo{}, // 已实现的SystemInfo模型
+		&model.Setting{},    // 已实现的Setting模型
+		&model.Customer{},
+		&model.Product{},
+		&model.SystemBackup{}, // 系统备份模型
+		&model.BackupConfig{}, // 备份配置模型
+		&model.SystemConfig{}, // 系统配置模型
+	); err != nil {
+		return err
+	}
+	return runDataMigrations(db)
+}
+
 // GetDB 获取数据库连接
 func GetDB() *gorm.DB {
 	if DB == nil {
@@ -1,12 +1,18 @@
 package database
 
 import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // RunMigrations 执行数据库迁移
@@ -126,3 +132,103 @@ func RunMigrations(migrationsDir string) error {
 
 	return nil
 }
+
+// dataMigration 只执行一次的数据迁移，执行记录与SQL迁移共用migrations表
+type dataMigration struct {
+	ID      string
+	Migrate func(tx *gorm.DB) error
+}
+
+// dataMigrations 按顺序执行的数据迁移，已发布的迁移不能修改或调整顺序
+var dataMigrations = []dataMigration{
+	{ID: "data_001_backfill_license_activations", Migrate: backfillLicenseActivations},
+	{ID: "data_002_backfill_license_customers", Migrate: backfillLicenseCustomers},
+}
+
+// runDataMigrations 依次执行尚未执行过的数据迁移，每个迁移及其执行记录在同一事务中提交
+func runDataMigrations(db *gorm.DB) error {
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS migrations (
+			id VARCHAR(255) PRIMARY KEY,
+			executed_at DATETIME NOT NULL
+		)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+
+	for _, migration := range dataMigrations {
+		var count int64
+		if err := db.Table("migrations").Where("id = ?", migration.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check migration status: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Migrate(tx); err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO migrations (id, executed_at) VALUES (?, ?)", migration.ID, time.Now()).Error
+		}); err != nil {
+			return fmt.Errorf("failed to execute migration %s: %v", migration.ID, err)
+		}
+		log.Printf("Completed migration: %s", migration.ID)
+	}
+	return nil
+}
+
+// backfillLicenseActivations 为早期版本激活的授权补建激活记录，并按占用名额的激活记录重算已用设备数
+// 早期版本只在授权的device_id上记录激活设备，没有激活记录时设备数校验和停用都无法识别该设备
+func backfillLicenseActivations(tx *gorm.DB) error {
+	var licenses []struct {
+		ID        string
+		DeviceID  string
+		UpdatedAt time.Time
+	}
+	if err := tx.Table("licenses").
+		Select("id, device_id, updated_at").
+		Where("device_id <> '' AND device_id IS NOT NULL").
+		Scan(&licenses).Error; err != nil {
+		return fmt.Errorf("failed to get activated licenses: %v", err)
+	}
+
+	now := time.Now()
+	for _, license := range licenses {
+		var count int64
+		if err := tx.Model(&model.LicenseActivation{}).
+			Where("license_id = ? AND device_id = ?", license.ID, license.DeviceID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check license activation: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if err := tx.Create(&model.LicenseActivation{
+			ID:          utils.GenerateUUID(),
+			LicenseID:   license.ID,
+			DeviceID:    license.DeviceID,
+			ActivatedAt: license.UpdatedAt,
+			Status:      model.ActivationStatusActive,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to create license activation: %v", err)
+		}
+	}
+
+	// 浮动授权按租约占用名额，不在此重算
+	return tx.Exec(`UPDATE licenses SET used_devices = (
+			SELECT COUNT(*) FROM license_activations
+			WHERE license_activations.license_id = licenses.id AND license_activations.status IN ?
+		) WHERE seat_mode <> ? OR seat_mode IS NULL`,
+		[]string{model.ActivationStatusActive, model.ActivationStatusOffline}, model.LicenseSeatModeFloating).Error
+}
+
+// backfillLicenseCustomers 将早期存放在group_id中的客户ID迁移到customer_id
+// 早期版本的group_id保存授权所属客户(前端的customerId)，引入授权组后group_id只表示授权组，客户改存customer_id
+// 只执行一次：迁移之后group_id中的值都按授权组ID处理，不再因为与客户ID相同而被改写
+func backfillLicenseCustomers(tx *gorm.DB) error {
+	return tx.Exec(`UPDATE licenses SET customer_id = group_id, group_id = ''
+		WHERE (customer_id = '' OR customer_id IS NULL) AND group_id IN (SELECT id FROM customers)`).Error
+}
@@ -0,0 +1,253 @@
+// handler/client.go
+package handler
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientCodeStatus 客户端API结果码对应的HTTP状态码
+var clientCodeStatus = map[model.ClientCode]int{
+	model.ClientCodeOK:                  http.StatusOK,
+	model.ClientCodeLicenseInGrace:      http.StatusOK,
+	model.ClientCodeInvalidRequest:      http.StatusBadRequest,
+	model.ClientCodeInvalidClientKey:    http.StatusUnauthorized,
+	model.ClientCodeInvalidLicenseKey:   http.StatusBadRequest,
+	model.ClientCodeLicenseNotFound:     http.StatusNotFound,
+	model.ClientCodeLicenseExpired:      http.StatusForbidden,
+	model.ClientCodeLicenseDisabled:     http.StatusForbidden,
+	model.ClientCodeLicenseNotActivated: http.StatusForbidden,
+	model.ClientCodeDeviceLimitReached:  http.StatusConflict,
+	model.ClientCodeDeviceBlocked:       http.StatusForbidden,
+	model.ClientCodeLeaseNotFound:       http.StatusForbidden,
+	model.ClientCodeUsageLimitReached:   http.StatusForbidden,
+	model.ClientCodeTrialNotAllowed:     http.StatusForbidden,
+	model.ClientCodeActivationDenied:    http.StatusForbidden,
+	model.ClientCodeInternalError:       http.StatusInternalServerError,
+}
+
+// clientRespond 以客户端API响应结构返回结果
+func clientRespond(c *gin.Context, code model.ClientCode, message string, data interface{}) {
+	status, ok := clientCodeStatus[code]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, model.ClientResponse{
+		Code:       code,
+		Message:    message,
+		Data:       data,
+		ServerTime: time.Now(),
+	})
+}
+
+// clientRespondLicense 返回授权信息，授权处于宽限期时使用宽限期结果码提醒客户端
+func clientRespondLicense(c *gin.Context, message string, data *model.ClientLicenseData) {
+	if data.InGrace {
+		clientRespond(c, model.ClientCodeLicenseInGrace, "license has expired and is in its grace period", data)
+		return
+	}
+	clientRespond(c, model.ClientCodeOK, message, data)
+}
+
+// clientRespondError 将服务层错误转换为客户端API响应
+func clientRespondError(c *gin.Context, err error) {
+	var clientErr *service.ClientError
+	if errors.As(err, &clientErr) {
+		clientRespond(c, clientErr.Code, clientErr.Message, nil)
+		return
+	}
+	clientRespond(c, model.ClientCodeInternalError, err.Error(), nil)
+}
+
+// bindClientRequest 解析客户端授权请求
+func bindClientRequest(c *gin.Context) (*model.ClientLicenseRequest, bool) {
+	var req model.ClientLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		clientRespond(c, model.ClientCodeInvalidRequest, err.Error(), nil)
+		return nil, false
+	}
+	return &req, true
+}
+
+// ClientActivate 客户端激活授权
+func ClientActivate(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	data, err := service.ClientActivate(c.GetString("productID"), req, c.ClientIP())
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespondLicense(c, "license activated", data)
+}
+
+// ClientStartTrial 客户端申请试用授权，试用授权属于客户端密钥对应的产品
+func ClientStartTrial(c *gin.Context) {
+	var req model.ClientTrialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		clientRespond(c, model.ClientCodeInvalidRequest, err.Error(), nil)
+		return
+	}
+
+	data, err := service.ClientStartTrial(c.GetString("productID"), &req, c.ClientIP())
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespondLicense(c, "trial started", data)
+}
+
+// ClientCheckout 客户端签出浮动授权租约
+func ClientCheckout(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	data, err := service.ClientCheckout(c.GetString("productID"), req, c.ClientIP())
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespondLicense(c, "lease checked out", data)
+}
+
+// ClientVerify 客户端校验授权
+func ClientVerify(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	data, err := service.ClientVerify(c.GetString("productID"), req)
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespondLicense(c, "license is valid", data)
+}
+
+// ClientHeartbeat 客户端心跳
+func ClientHeartbeat(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	data, err := service.ClientHeartbeat(c.GetString("productID"), req, c.ClientIP())
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespondLicense(c, "heartbeat accepted", data)
+}
+
+// ClientDeactivate 客户端停用授权
+func ClientDeactivate(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := service.ClientDeactivate(c.GetString("productID"), req); err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespond(c, model.ClientCodeOK, "license deactivated", nil)
+}
+
+// ClientRelease 客户端归还浮动授权租约
+func ClientRelease(c *gin.Context) {
+	req, ok := bindClientRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := service.ClientRelease(c.GetString("productID"), req); err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespond(c, model.ClientCodeOK, "lease released", nil)
+}
+
+// ClientReportUsage 客户端上报按量计费用量
+func ClientReportUsage(c *gin.Context) {
+	var req model.ClientUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		clientRespond(c, model.ClientCodeInvalidRequest, err.Error(), nil)
+		return
+	}
+
+	data, err := service.ClientReportUsage(c.GetString("productID"), &req)
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	message := "usage recorded"
+	if data.Duplicate {
+		message = "usage already recorded"
+	}
+	clientRespond(c, model.ClientCodeOK, message, data)
+}
+
+// ClientCheckEntitlement 客户端查询授权权益
+func ClientCheckEntitlement(c *gin.Context) {
+	var req model.ClientEntitlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		clientRespond(c, model.ClientCodeInvalidRequest, err.Error(), nil)
+		return
+	}
+
+	data, err := service.ClientCheckEntitlement(c.GetString("productID"), &req)
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespond(c, model.ClientCodeOK, "entitlements resolved", data)
+}
+
+// ClientGetRevocations 客户端获取签名的吊销列表，since为客户端已同步的版本，为0时返回全量列表
+func ClientGetRevocations(c *gin.Context) {
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		clientRespond(c, model.ClientCodeInvalidRequest, "invalid since version", nil)
+		return
+	}
+
+	data, err := service.GetSignedRevocationList(since)
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespond(c, model.ClientCodeOK, "revocation list issued", data)
+}
+
+// ClientGetSigningKeys 客户端获取根密钥签名的产品可用签名公钥集合，非根密钥附带根密钥背书
+func ClientGetSigningKeys(c *gin.Context) {
+	data, err := service.GetSignedSigningKeySet(c.GetString("productID"))
+	if err != nil {
+		clientRespondError(c, err)
+		return
+	}
+
+	clientRespond(c, model.ClientCodeOK, "signing keys issued", data)
+}
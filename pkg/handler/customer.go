@@ -4,6 +4,7 @@ package handler
 import (
 	"LVerity/pkg/model"
 	"LVerity/pkg/service"
+	"errors"
 	"net/http"
 	"time"
 
@@ -224,6 +225,7 @@ func UpdateCustomer(c *gin.Context) {
 }
 
 // DeleteCustomer 删除客户
+// 客户名下仍有授权时返回409，可通过reassign_to参数先将其授权改归其他客户再删除
 func DeleteCustomer(c *gin.Context) {
 	id := c.Param("id")
 	customerService := service.NewCustomerService()
@@ -239,8 +241,28 @@ func DeleteCustomer(c *gin.Context) {
 		return
 	}
 
+	// 转移客户名下的授权
+	if reassignTo := c.Query("reassign_to"); reassignTo != "" {
+		if _, err := customerService.ReassignLicenses(id, reassignTo, c.GetString("userID"), c.GetString("username")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "转移客户授权失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
 	// 删除客户
 	if err := customerService.DeleteCustomer(id); err != nil {
+		if errors.Is(err, service.ErrCustomerHasLicenses) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "客户名下仍有授权，请先转移或删除授权",
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "删除客户失败",
@@ -256,3 +278,25 @@ func DeleteCustomer(c *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// GetCustomerSummary 获取客户名下的授权和设备汇总
+func GetCustomerSummary(c *gin.Context) {
+	id := c.Param("id")
+	customerService := service.NewCustomerService()
+	summary, err := customerService.GetCustomerSummary(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "客户不存在",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "success",
+		"code":    200,
+		"data":    summary,
+	})
+}
@@ -4,9 +4,15 @@ package handler
 import (
 	"LVerity/pkg/model"
 	"LVerity/pkg/service"
+	"LVerity/pkg/utils"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +29,10 @@ type GenerateLicenseRequest struct {
 	UsageLimit int64            `json:"usage_limit"`
 	Tags       []string         `json:"tags"`
 	Metadata   string           `json:"metadata"`
+	SeatMode      model.LicenseSeatMode `json:"seat_mode"`      // named或floating，默认named
+	LeaseDuration int                   `json:"lease_duration"` // 浮动授权租约时长(秒)
+	CustomerID    string                `json:"customer_id"`    // 授权所属客户
+	ProductID     string                `json:"product_id"`     // 授权所属产品
 }
 
 // ActivateLicenseRequest 激活授权码请求
@@ -37,7 +47,9 @@ type BatchGenerateLicenseRequest struct {
 	ExpireDays int              `json:"expire_days"`
 	MaxDevices int              `json:"max_devices"`
 	Count      int              `json:"count"`
-	GroupID    string           `json:"group_id"`
+	GroupID    string           `json:"group_id"`    // 授权组
+	CustomerID string           `json:"customer_id"` // 授权所属客户
+	ProductID  string           `json:"product_id"`  // 授权所属产品，授权码使用产品的前缀
 	Features   []string         `json:"features" binding:"required"`
 	ExpiresAt  string           `json:"expires_at" binding:"required"`
 	UsageLimit int64            `json:"usage_limit"`
@@ -136,12 +148,17 @@ func ActivateLicense(c *gin.Context) {
 		return
 	}
 
-	if err := service.ActivateLicense(req.Code, req.DeviceID); err != nil {
+	activation, err := service.ActivateLicense(req.Code, req.DeviceID, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrDeviceLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "License activated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "License activated successfully", "activation": activation})
 }
 
 // VerifyLicense 验证授权码
@@ -154,8 +171,19 @@ func VerifyLicense(c *gin.Context) {
 		return
 	}
 
+	// 数据库中存在的授权码总是有效，不存在时输错的授权码按格式错误返回
+	license, err := service.FindLicenseByInputCode(req.Code)
+	if err != nil {
+		if service.IsLicenseCodeFormatError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 验证授权码
-	valid, err := service.VerifyLicense(req.Code)
+	valid, err := service.VerifyLicense(license.Code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -197,9 +225,19 @@ func BatchGenerateLicense(c *gin.Context) {
 		return
 	}
 
+	customerID, groupID, err := service.ResolveLegacyLicenseCustomer(req.CustomerID, req.GroupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.ValidateLicenseRelations(customerID, req.ProductID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	startTime := time.Now()
 	expireTime := startTime.AddDate(0, 0, req.ExpireDays)
-	codes, err := service.BatchCreateLicense(req.Count, req.Type, req.MaxDevices, startTime, expireTime, req.GroupID, req.Features, req.UsageLimit)
+	codes, err := service.BatchCreateLicense(req.ProductID, req.Count, req.Type, req.MaxDevices, startTime, expireTime, groupID, customerID, req.Features, req.UsageLimit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -297,68 +335,81 @@ func ExportLicenses(c *gin.Context) {
 	}
 }
 
-// ImportLicenses 导入授权码
+// ImportLicenses 导入授权记录，支持上传CSV或JSON文件，也可直接提交JSON请求体
+// dry_run=true时只校验并返回逐行错误报告，不写入数据；legacy_codes=true时接受其他系统迁移的授权码
 func ImportLicenses(c *gin.Context) {
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
-		return
-	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	legacyCodes, _ := strconv.ParseBool(c.Query("legacy_codes"))
+
+	var rows []model.LicenseImportRow
+	var parseErrors []model.LicenseImportError
+	var err error
+	if fileHeader, fileErr := c.FormFile("file"); fileErr == nil {
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "读取导入文件失败: " + openErr.Error(),
+				"code":    400,
+			})
+			return
+		}
+		defer file.Close()
 
-	// 打开文件
-	src, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
-		return
+		format := model.ExportFormat(strings.ToLower(c.PostForm("format")))
+		if format == "" {
+			format = model.ExportFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")))
+		}
+		if format == model.ExportFormatJSON {
+			var data []byte
+			if data, err = io.ReadAll(file); err == nil {
+				rows, err = service.ParseLicenseImportJSON(data)
+			}
+		} else {
+			rows, parseErrors, err = service.ParseLicenseImportCSV(file)
+		}
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(c.Request.Body); err == nil {
+			rows, err = service.ParseLicenseImportJSON(data)
+		}
 	}
-	defer src.Close()
-
-	// 读取CSV文件
-	reader := csv.NewReader(src)
-	records, err := reader.ReadAll()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read CSV file"})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "解析导入数据失败: " + err.Error(),
+			"code":    400,
+		})
 		return
 	}
-
-	// 跳过表头
-	if len(records) < 2 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Empty or invalid CSV file"})
+	if len(rows) == 0 && len(parseErrors) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "导入数据为空",
+			"code":    400,
+		})
 		return
 	}
-	records = records[1:]
 
-	// 解析授权码记录
-	var licenses []model.License
-	for _, record := range records {
-		if len(record) < 9 {
-			continue
-		}
-
-		maxDevices, _ := strconv.Atoi(record[4])
-		startTime, _ := time.Parse(time.RFC3339, record[5])
-		expireTime, _ := time.Parse(time.RFC3339, record[6])
-
-		license := model.License{
-			Code:       record[1],
-			Type:       model.LicenseType(record[2]),
-			Status:     model.LicenseStatus(record[3]),
-			MaxDevices: maxDevices,
-			StartTime:  startTime,
-			ExpireTime: expireTime,
-		}
-		licenses = append(licenses, license)
-	}
-
-	// 导入授权码
-	if err := service.ImportLicenses(licenses); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := service.ImportLicenses(rows, parseErrors, dryRun, legacyCodes, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "导入授权失败: " + err.Error(),
+			"code":    500,
+		})
 		return
 	}
 
+	message := "导入授权完成"
+	if dryRun {
+		message = "导入预检完成"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Licenses imported successfully",
-		"count":   len(licenses),
+		"success": true,
+		"message": message,
+		"code":    200,
+		"data":    result,
 	})
 }
 
@@ -390,7 +441,7 @@ func CreateLicenseGroup(c *gin.Context) {
 	}
 
 	// 获取当前用户ID
-	userID := c.GetString("user_id")
+	userID := c.GetString("userID")
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
@@ -495,8 +546,11 @@ func ListLicenses(c *gin.Context) {
     // 获取筛选条件
     status := c.DefaultQuery("status", "")
     groupID := c.DefaultQuery("group_id", "")
+    customerID := c.DefaultQuery("customer_id", "")
+    productID := c.DefaultQuery("product_id", "")
+    tagID := c.DefaultQuery("tag_id", "")
     
-    licenses, total, err := service.ListLicenses(page, pageSize, status, groupID)
+    licenses, total, err := service.ListLicenses(page, pageSize, status, groupID, customerID, productID, tagID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "success": false,
@@ -534,9 +588,38 @@ func CreateLicense(c *gin.Context) {
         })
         return
     }
+
+    // 校验所属客户和产品，早期前端在group_id中传客户ID
+    req.CustomerID, req.GroupID, err = service.ResolveLegacyLicenseCustomer(req.CustomerID, req.GroupID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "error_message": err.Error(),
+        })
+        return
+    }
+    if err := service.ValidateLicenseRelations(req.CustomerID, req.ProductID); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "error_message": err.Error(),
+        })
+        return
+    }
+
+    // 校验席位模式，创建授权前拒绝无效参数
+    if req.SeatMode != "" {
+        if err := service.ValidateSeatMode(req.SeatMode, req.LeaseDuration); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error_message": err.Error(),
+            })
+            return
+        }
+    }
     
     startTime := time.Now()
-    license, err := service.GenerateLicense(
+    license, err := service.GenerateLicenseWithOptions(
+        req.ProductID,
         req.Type,
         req.MaxDevices,
         startTime,
@@ -544,6 +627,11 @@ func CreateLicense(c *gin.Context) {
         req.GroupID,
         req.Features,
         req.UsageLimit,
+        service.LicenseOptions{
+            SeatMode:      req.SeatMode,
+            LeaseDuration: req.LeaseDuration,
+            CustomerID:    req.CustomerID,
+        },
     )
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
@@ -552,7 +640,7 @@ func CreateLicense(c *gin.Context) {
         })
         return
     }
-    
+
     c.JSON(http.StatusOK, gin.H{
         "success": true,
         "data": license,
@@ -601,8 +689,8 @@ func UpdateLicense(c *gin.Context) {
     // 确保ID匹配
     license.ID = id
     
-    // 调用服务更新许可证
-    updatedLicense, err := service.UpdateLicenseComprehensive(license)
+    // 调用服务更新许可证，变更原因通过reason查询参数传入
+    updatedLicense, err := service.UpdateLicenseComprehensive(license, c.Query("reason"), c.GetString("userID"), c.GetString("username"))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "success": false,
@@ -700,3 +788,552 @@ func GetLicenseActivations(c *gin.Context) {
 		"data":    activations,
 	})
 }
+
+// GetLicenseLeases 获取浮动授权当前的租约持有者
+func GetLicenseLeases(c *gin.Context) {
+	licenseID := c.Param("id")
+
+	leases, err := service.GetLicenseLeases(licenseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取租约记录失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取租约记录成功",
+		"code":    200,
+		"data":    leases,
+	})
+}
+
+// TransferLicense 转移授权到其他设备或客户
+func TransferLicense(c *gin.Context) {
+	licenseID := c.Param("id")
+
+	var req service.LicenseTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	transfer, err := service.TransferLicense(licenseID, &req, c.GetString("userID"), c.GetString("username"))
+	if errors.Is(err, service.ErrTransferActivationFailed) {
+		// 授权已转移，只有转入设备激活失败，可以在新授权上重新激活
+		c.JSON(http.StatusOK, gin.H{
+			"success":          true,
+			"message":          "授权已转移，但激活转入设备失败",
+			"code":             200,
+			"data":             transfer,
+			"activation_error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrTransferLimitReached) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "转移授权失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "转移授权成功",
+		"code":    200,
+		"data":    transfer,
+	})
+}
+
+// CreateOfflineActivation 上传离线激活请求文件，返回签名的离线激活响应文件
+// 请求文件可以作为multipart表单的file字段上传，也可以直接作为JSON请求体提交
+func CreateOfflineActivation(c *gin.Context) {
+	licenseID := c.Param("id")
+
+	var data []byte
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err == nil {
+			data, err = io.ReadAll(file)
+			file.Close()
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "读取请求文件失败: " + err.Error(),
+				"code":    400,
+			})
+			return
+		}
+	} else if data, err = io.ReadAll(c.Request.Body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "读取请求数据失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	var req model.OfflineActivationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "离线激活请求文件无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	resp, err := service.ActivateOffline(licenseID, &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrDeviceLimitReached) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "离线激活失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "离线激活成功",
+		"code":    200,
+		"data":    resp,
+	})
+}
+
+// GetLicenseTransfers 获取授权的转移记录
+func GetLicenseTransfers(c *gin.Context) {
+	licenseID := c.Param("id")
+
+	transfers, err := service.GetLicenseTransfers(licenseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取转移记录失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取转移记录成功",
+		"code":    200,
+		"data":    transfers,
+	})
+}
+
+// GetLicenseUsage 获取授权按周期和设备汇总的用量
+func GetLicenseUsage(c *gin.Context) {
+	licenseID := c.Param("id")
+
+	var startTime, endTime time.Time
+	var err error
+	if value := c.Query("start_time"); value != "" {
+		if startTime, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "start_time格式错误，应为YYYY-MM-DD",
+				"code":    400,
+			})
+			return
+		}
+	}
+	if value := c.Query("end_time"); value != "" {
+		if endTime, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "end_time格式错误，应为YYYY-MM-DD",
+				"code":    400,
+			})
+			return
+		}
+	}
+
+	summary, err := service.GetLicenseUsageSummary(licenseID, c.Query("device_id"), startTime, endTime, c.Query("granularity"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取用量失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取用量成功",
+		"code":    200,
+		"data":    summary,
+	})
+}
+
+// GetLicenseEntitlements 获取授权合并功能模块后的权益
+func GetLicenseEntitlements(c *gin.Context) {
+	license, err := service.GetLicenseByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "许可证不存在",
+			"code":    404,
+		})
+		return
+	}
+
+	entitlements, err := service.GetLicenseEntitlements(license)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取权益失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取权益成功",
+		"code":    200,
+		"data":    entitlements,
+	})
+}
+
+// GetLicenseStatusHistory 获取授权的状态变更记录
+func GetLicenseStatusHistory(c *gin.Context) {
+	transitions, err := service.GetLicenseStatusTransitions(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取状态变更记录失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取状态变更记录成功",
+		"code":    200,
+		"data":    transitions,
+	})
+}
+
+// RenewLicense 续期授权
+func RenewLicense(c *gin.Context) {
+	var req service.LicenseRenewalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	term, err := service.RenewLicense(c.Param("id"), &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "续期授权失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "续期授权成功",
+		"code":    200,
+		"data":    term,
+	})
+}
+
+// BatchRenewLicenses 按授权组或标签批量续期
+func BatchRenewLicenses(c *gin.Context) {
+	var req service.BulkRenewalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	results, err := service.BulkRenewLicenses(&req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "批量续期失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "批量续期完成",
+		"code":    200,
+		"data":    results,
+	})
+}
+
+// GetLicenseTerms 获取授权的有效期历史
+func GetLicenseTerms(c *gin.Context) {
+	terms, err := service.GetLicenseTerms(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取有效期记录失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取有效期记录成功",
+		"code":    200,
+		"data":    terms,
+	})
+}
+
+// DownloadLicenseFile 下载签名的离线授权文件
+func DownloadLicenseFile(c *gin.Context) {
+	licenseID := c.Param("id")
+	fingerprint := c.Query("fingerprint")
+
+	file, err := service.GetLicenseFile(licenseID, fingerprint)
+	if errors.Is(err, service.ErrLicenseNotActivated) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "该设备未激活此授权",
+			"code":    404,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "生成离线授权文件失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment;filename=license-"+licenseID+".lic")
+	c.JSON(http.StatusOK, file)
+}
+
+// GetLicensePublicKey 获取离线授权文件校验公钥
+func GetLicensePublicKey(c *gin.Context) {
+	publicKey, err := service.GetLicensePublicKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取授权公钥失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权公钥成功",
+		"code":    200,
+		"data": gin.H{
+			"algorithm":  model.LicenseFileAlgorithm,
+			"key_id":     utils.PublicKeyID(publicKey),
+			"public_key": utils.EncodePublicKey(publicKey),
+		},
+	})
+}
+
+// GetRevocationEntries 获取吊销列表变更记录
+func GetRevocationEntries(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	entries, total, err := service.GetRevocationEntries(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取吊销列表失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取吊销列表成功",
+		"code":    200,
+		"data": gin.H{
+			"list":  entries,
+			"total": total,
+		},
+	})
+}
+
+// SearchLicenses 按过滤表达式搜索授权，支持多字段排序和游标分页
+func SearchLicenses(c *gin.Context) {
+	var req model.LicenseSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	result, err := service.SearchLicenses(&req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidLicenseFilter) || errors.Is(err, service.ErrInvalidLicenseCursor) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "搜索授权失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "搜索授权成功",
+		"code":    200,
+		"data":    result,
+	})
+}
+
+// GetLicenseVersions 获取授权的变更历史
+func GetLicenseVersions(c *gin.Context) {
+	versions, err := service.GetLicenseVersions(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取授权变更历史失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权变更历史成功",
+		"code":    200,
+		"data":    versions,
+	})
+}
+
+// RevertLicenseVersionRequest 回滚授权版本请求
+type RevertLicenseVersionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RevertLicenseVersion 将授权回滚到指定的历史版本
+func RevertLicenseVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "无效的版本号",
+			"code":    400,
+		})
+		return
+	}
+
+	var req RevertLicenseVersionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "请求数据无效: " + err.Error(),
+				"code":    400,
+			})
+			return
+		}
+	}
+
+	reverted, err := service.RevertLicenseVersion(c.Param("id"), version, req.Reason, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrLicenseVersionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "回滚授权失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	message := "回滚授权成功"
+	if reverted == nil {
+		message = "授权已处于该版本的状态"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"code":    200,
+		"data":    reverted,
+	})
+}
+
+// SetLicenseActivationPolicy 设置授权的激活限制，请求体为空对象时清除并改用授权方案的策略
+func SetLicenseActivationPolicy(c *gin.Context) {
+	var policy model.ActivationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	license, err := service.SetLicenseActivationPolicy(c.Param("id"), &policy, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "设置激活限制失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "设置激活限制成功",
+		"code":    200,
+		"data":    license,
+	})
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignLicensesToGroupRequest 批量移入授权组请求
+type AssignLicensesToGroupRequest struct {
+	LicenseIDs []string `json:"license_ids" binding:"required"`
+}
+
+// ListLicenseGroups 获取授权组列表
+func ListLicenseGroups(c *gin.Context) {
+	groups, err := service.ListLicenseGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取授权组列表失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权组列表成功",
+		"code":    200,
+		"data":    groups,
+	})
+}
+
+// GetLicenseGroup 获取授权组详情
+func GetLicenseGroup(c *gin.Context) {
+	group, err := service.GetLicenseGroup(c.Param("id"))
+	if err != nil {
+		respondLicenseGroupError(c, "获取授权组失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权组成功",
+		"code":    200,
+		"data":    group,
+	})
+}
+
+// UpdateLicenseGroup 更新授权组
+func UpdateLicenseGroup(c *gin.Context) {
+	var req CreateLicenseGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	group, err := service.UpdateLicenseGroup(c.Param("id"), req.Name, req.Description)
+	if err != nil {
+		respondLicenseGroupError(c, "更新授权组失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "更新授权组成功",
+		"code":    200,
+		"data":    group,
+	})
+}
+
+// DeleteLicenseGroup 删除授权组，组内授权移出该组
+func DeleteLicenseGroup(c *gin.Context) {
+	id := c.Param("id")
+	ungrouped, err := service.DeleteLicenseGroup(id)
+	if err != nil {
+		respondLicenseGroupError(c, "删除授权组失败", err)
+		return
+	}
+
+	service.LogOperation(c.GetString("userID"), c.GetString("username"), "delete", "license_group", id, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除授权组成功",
+		"code":    200,
+		"data":    gin.H{"ungrouped": ungrouped},
+	})
+}
+
+// AssignLicensesToGroup 批量将授权移入授权组
+func AssignLicensesToGroup(c *gin.Context) {
+	var req AssignLicensesToGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	count, err := service.AssignLicensesToGroup(c.Param("id"), req.LicenseIDs)
+	if err != nil {
+		respondLicenseGroupError(c, "移入授权组失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "移入授权组成功",
+		"code":    200,
+		"data":    gin.H{"count": count},
+	})
+}
+
+// GetLicenseGroupStats 获取授权组统计
+func GetLicenseGroupStats(c *gin.Context) {
+	stats, err := service.GetLicenseGroupStats(c.Param("id"))
+	if err != nil {
+		respondLicenseGroupError(c, "获取授权组统计失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权组统计成功",
+		"code":    200,
+		"data":    stats,
+	})
+}
+
+// ListLicenseTags 获取授权标签列表
+func ListLicenseTags(c *gin.Context) {
+	tags, err := service.ListLicenseTags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取授权标签列表失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权标签列表成功",
+		"code":    200,
+		"data":    tags,
+	})
+}
+
+// UpdateLicenseTag 更新授权标签
+func UpdateLicenseTag(c *gin.Context) {
+	var req CreateLicenseTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	tag, err := service.UpdateLicenseTag(c.Param("id"), req.Name, req.Color)
+	if err != nil {
+		respondLicenseGroupError(c, "更新授权标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "更新授权标签成功",
+		"code":    200,
+		"data":    tag,
+	})
+}
+
+// DeleteLicenseTag 删除授权标签
+func DeleteLicenseTag(c *gin.Context) {
+	id := c.Param("id")
+	if err := service.DeleteLicenseTag(id); err != nil {
+		respondLicenseGroupError(c, "删除授权标签失败", err)
+		return
+	}
+
+	service.LogOperation(c.GetString("userID"), c.GetString("username"), "delete", "license_tag", id, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除授权标签成功",
+		"code":    200,
+	})
+}
+
+// BulkTagLicenses 批量为授权添加或移除标签
+func BulkTagLicenses(c *gin.Context) {
+	var req service.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	added, removed, err := service.BulkTagLicenses(&req)
+	if err != nil {
+		respondLicenseGroupError(c, "批量设置标签失败", err)
+		return
+	}
+
+	service.LogOperation(c.GetString("userID"), c.GetString("username"), "bulk_tag", "license", "", req)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "批量设置标签成功",
+		"code":    200,
+		"data":    gin.H{"added": added, "removed": removed},
+	})
+}
+
+// respondLicenseGroupError 返回授权组和标签操作的错误，不存在时返回404
+func respondLicenseGroupError(c *gin.Context, message string, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrLicenseGroupNotFound) || errors.Is(err, service.ErrLicenseTagNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message + ": " + err.Error(),
+		"code":    status,
+	})
+}
@@ -0,0 +1,176 @@
+// handler/license_plan.go
+package handler
+
+import (
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateLicensePlanRequest 更新授权方案请求
+type UpdateLicensePlanRequest struct {
+	service.LicensePlanRequest
+	Propagate bool `json:"propagate"` // 是否同步到按该方案创建的现有授权
+}
+
+// GetProductLicensePlans 获取产品下的授权方案
+func GetProductLicensePlans(c *gin.Context) {
+	plans, err := service.GetProductLicensePlans(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取授权方案失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权方案成功",
+		"code":    200,
+		"data":    plans,
+	})
+}
+
+// CreateLicensePlan 为产品创建授权方案
+func CreateLicensePlan(c *gin.Context) {
+	var req service.LicensePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	plan, err := service.CreateLicensePlan(c.Param("id"), &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "创建授权方案失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "创建授权方案成功",
+		"code":    201,
+		"data":    plan,
+	})
+}
+
+// GetLicensePlan 获取授权方案详情
+func GetLicensePlan(c *gin.Context) {
+	plan, err := service.GetLicensePlan(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "授权方案不存在: " + err.Error(),
+			"code":    404,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取授权方案成功",
+		"code":    200,
+		"data":    plan,
+	})
+}
+
+// UpdateLicensePlan 更新授权方案，可选同步到现有授权
+func UpdateLicensePlan(c *gin.Context) {
+	var req UpdateLicensePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	plan, propagated, err := service.UpdateLicensePlan(c.Param("id"), &req.LicensePlanRequest, req.Propagate, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		// 同步会使已激活的授权超出设备数上限时返回冲突，消息中列出这些授权码
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrLicensePlanSeatsInUse) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "更新授权方案失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "更新授权方案成功",
+		"code":    200,
+		"data": gin.H{
+			"plan":       plan,
+			"propagated": propagated,
+		},
+	})
+}
+
+// DeleteLicensePlan 删除授权方案
+func DeleteLicensePlan(c *gin.Context) {
+	if err := service.DeleteLicensePlan(c.Param("id"), c.GetString("userID"), c.GetString("username")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrLicensePlanInUse) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "删除授权方案失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除授权方案成功",
+		"code":    200,
+	})
+}
+
+// CreateLicenseFromPlan 按授权方案为客户创建授权
+func CreateLicenseFromPlan(c *gin.Context) {
+	var req service.LicenseFromPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	license, err := service.CreateLicenseFromPlan(&req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "创建授权失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "创建授权成功",
+		"code":    201,
+		"data":    license,
+	})
+}
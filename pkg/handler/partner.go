@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PartnerQuotaRequest 分配合作伙伴配额请求
+type PartnerQuotaRequest struct {
+	PlanID   string `json:"plan_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+}
+
+// respondPartnerError 根据合作伙伴操作错误返回对应的HTTP状态码
+func respondPartnerError(c *gin.Context, message string, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrPartnerNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrPartnerAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrPartnerQuotaExceeded), errors.Is(err, service.ErrPartnerDisabled):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message + ": " + err.Error(),
+		"code":    status,
+	})
+}
+
+// respondPartnerOK 返回合作伙伴操作成功的响应
+func respondPartnerOK(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"code":    200,
+		"data":    data,
+	})
+}
+
+// bindPartnerRequest 解析请求体，失败时返回400
+func bindPartnerRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return false
+	}
+	return true
+}
+
+// currentPartnerID 获取当前合作伙伴用户所属的合作伙伴，非合作伙伴用户返回403
+func currentPartnerID(c *gin.Context) (string, bool) {
+	partnerID := c.GetString("partnerID")
+	if partnerID == "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "当前用户不是合作伙伴用户",
+			"code":    403,
+		})
+		return "", false
+	}
+	return partnerID, true
+}
+
+// ListPartners 获取全部合作伙伴
+func ListPartners(c *gin.Context) {
+	partners, err := service.ListPartners("")
+	if err != nil {
+		respondPartnerError(c, "获取合作伙伴失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取合作伙伴成功", partners)
+}
+
+// CreatePartner 创建合作伙伴，parent_id不为空时创建下级合作伙伴
+func CreatePartner(c *gin.Context) {
+	var req service.PartnerRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	partner, err := service.CreatePartner(&req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "创建合作伙伴失败", err)
+		return
+	}
+	respondPartnerOK(c, "创建合作伙伴成功", partner)
+}
+
+// GetPartner 获取合作伙伴及其配额
+func GetPartner(c *gin.Context) {
+	partner, err := service.GetPartner(c.Param("id"))
+	if err != nil {
+		respondPartnerError(c, "获取合作伙伴失败", err)
+		return
+	}
+	quotas, err := service.GetPartnerQuotas(partner.ID)
+	if err != nil {
+		respondPartnerError(c, "获取合作伙伴配额失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取合作伙伴成功", gin.H{
+		"partner": partner,
+		"quotas":  quotas,
+	})
+}
+
+// UpdatePartner 更新合作伙伴
+func UpdatePartner(c *gin.Context) {
+	var req service.PartnerRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	partner, err := service.UpdatePartner(c.Param("id"), &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "更新合作伙伴失败", err)
+		return
+	}
+	respondPartnerOK(c, "更新合作伙伴成功", partner)
+}
+
+// AllocatePartnerQuota 调整合作伙伴某个授权方案的配额，quantity为负数时收回未使用的名额
+func AllocatePartnerQuota(c *gin.Context) {
+	var req PartnerQuotaRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	quota, err := service.AllocatePartnerQuota(c.Param("id"), req.PlanID, req.Quantity, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "分配配额失败", err)
+		return
+	}
+	respondPartnerOK(c, "分配配额成功", quota)
+}
+
+// AddPartnerUser 将用户设为合作伙伴用户
+func AddPartnerUser(c *gin.Context) {
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	if err := service.AddPartnerUser(c.Param("id"), req.UserID, c.GetString("userID"), c.GetString("username")); err != nil {
+		respondPartnerError(c, "添加合作伙伴用户失败", err)
+		return
+	}
+	respondPartnerOK(c, "添加合作伙伴用户成功", nil)
+}
+
+// GetPartnerConsumption 按合作伙伴查看配额消耗
+func GetPartnerConsumption(c *gin.Context) {
+	consumption, err := service.GetPartnerConsumption(c.Query("partner_id"))
+	if err != nil {
+		respondPartnerError(c, "获取配额消耗失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取配额消耗成功", consumption)
+}
+
+// PartnerGetQuotas 合作伙伴查看自己的配额
+func PartnerGetQuotas(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	quotas, err := service.GetPartnerQuotas(partnerID)
+	if err != nil {
+		respondPartnerError(c, "获取配额失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取配额成功", quotas)
+}
+
+// PartnerGetConsumption 合作伙伴查看自己及下级的配额消耗
+func PartnerGetConsumption(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	consumption, err := service.GetPartnerConsumption(partnerID)
+	if err != nil {
+		respondPartnerError(c, "获取配额消耗失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取配额消耗成功", consumption)
+}
+
+// PartnerListSubPartners 合作伙伴查看自己及全部下级
+func PartnerListSubPartners(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	partners, err := service.ListPartners(partnerID)
+	if err != nil {
+		respondPartnerError(c, "获取下级合作伙伴失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取下级合作伙伴成功", partners)
+}
+
+// PartnerCreateSubPartner 合作伙伴创建直属下级
+func PartnerCreateSubPartner(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	var req service.PartnerRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	req.ParentID = partnerID
+	partner, err := service.CreatePartner(&req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "创建下级合作伙伴失败", err)
+		return
+	}
+	respondPartnerOK(c, "创建下级合作伙伴成功", partner)
+}
+
+// PartnerDelegateQuota 合作伙伴将自己的配额分配给直属下级
+func PartnerDelegateQuota(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	var req PartnerQuotaRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	quota, err := service.DelegatePartnerQuota(partnerID, c.Param("id"), req.PlanID, req.Quantity, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "分配配额失败", err)
+		return
+	}
+	respondPartnerOK(c, "分配配额成功", quota)
+}
+
+// PartnerListCustomers 合作伙伴查看自己及下级的客户
+func PartnerListCustomers(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	customers, err := service.ListPartnerCustomers(partnerID)
+	if err != nil {
+		respondPartnerError(c, "获取客户失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取客户成功", customers)
+}
+
+// PartnerCreateCustomer 合作伙伴创建自己的客户
+func PartnerCreateCustomer(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	var req CreateCustomerRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+
+	customer := model.Customer{
+		ID:           "c-" + uuid.New().String()[:8],
+		Name:         req.Name,
+		ContactName:  req.ContactName,
+		ContactEmail: req.ContactEmail,
+		ContactPhone: req.ContactPhone,
+		Address:      req.Address,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := service.CreatePartnerCustomer(partnerID, &customer); err != nil {
+		respondPartnerError(c, "创建客户失败", err)
+		return
+	}
+	respondPartnerOK(c, "创建客户成功", customer)
+}
+
+// PartnerListLicenses 合作伙伴查看自己及下级签发的授权
+func PartnerListLicenses(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	licenses, total, err := service.ListPartnerLicenses(partnerID,
+		c.DefaultQuery("page", "1"),
+		c.DefaultQuery("pageSize", "20"),
+		c.Query("customer_id"),
+	)
+	if err != nil {
+		respondPartnerError(c, "获取授权失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取授权成功", gin.H{
+		"list":  licenses,
+		"total": total,
+	})
+}
+
+// PartnerGetLicense 合作伙伴查看自己或下级签发的授权
+func PartnerGetLicense(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	license, err := service.GetPartnerLicense(partnerID, c.Param("id"))
+	if err != nil {
+		respondPartnerError(c, "获取授权失败", err)
+		return
+	}
+	respondPartnerOK(c, "获取授权成功", license)
+}
+
+// PartnerCreateLicenses 合作伙伴按授权方案为自己的客户签发授权，占用对应配额
+func PartnerCreateLicenses(c *gin.Context) {
+	partnerID, ok := currentPartnerID(c)
+	if !ok {
+		return
+	}
+	var req service.PartnerLicenseRequest
+	if !bindPartnerRequest(c, &req) {
+		return
+	}
+	licenses, err := service.CreatePartnerLicenses(partnerID, &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		respondPartnerError(c, "签发授权失败", err)
+		return
+	}
+	respondPartnerOK(c, "签发授权成功", licenses)
+}
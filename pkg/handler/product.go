@@ -4,7 +4,10 @@ package handler
 import (
 	"LVerity/pkg/model"
 	"LVerity/pkg/service"
+	"LVerity/pkg/utils"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +19,7 @@ type CreateProductRequest struct {
 	Name        string   `json:"name" binding:"required"`
 	Description string   `json:"description"`
 	Features    []string `json:"features"`
+	KeyPrefix   string   `json:"keyPrefix"` // 授权码前缀，为空时生成不带前缀的授权码
 }
 
 // UpdateProductRequest 更新产品请求结构
@@ -23,6 +27,7 @@ type UpdateProductRequest struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Features    []string `json:"features"`
+	KeyPrefix   *string  `json:"keyPrefix"`
 }
 
 // GetProducts 获取产品列表
@@ -125,11 +130,22 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	keyPrefix := strings.ToUpper(strings.TrimSpace(req.KeyPrefix))
+	if err := utils.ValidateLicenseKeyPrefix(keyPrefix); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "无效的授权码前缀",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	product := model.Product{
 		ID:          "p-" + uuid.New().String()[:8],
 		Name:        req.Name,
 		Description: req.Description,
 		Features:    req.Features,
+		KeyPrefix:   keyPrefix,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -186,6 +202,19 @@ func UpdateProduct(c *gin.Context) {
 	if len(req.Features) > 0 {
 		product.Features = req.Features
 	}
+	// 修改前缀只影响之后生成的授权码，已有授权码的校验字符与前缀一起计算，仍然有效
+	if req.KeyPrefix != nil {
+		keyPrefix := strings.ToUpper(strings.TrimSpace(*req.KeyPrefix))
+		if err := utils.ValidateLicenseKeyPrefix(keyPrefix); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "无效的授权码前缀",
+				"error":   err.Error(),
+			})
+			return
+		}
+		product.KeyPrefix = keyPrefix
+	}
 	product.UpdatedAt = time.Now()
 
 	if err := productService.UpdateProduct(product); err != nil {
@@ -223,6 +252,14 @@ func DeleteProduct(c *gin.Context) {
 
 	// 删除产品
 	if err := productService.DeleteProduct(id); err != nil {
+		if errors.Is(err, service.ErrProductHasLicenses) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "产品下仍有授权，无法删除",
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "删除产品失败",
@@ -238,3 +275,25 @@ func DeleteProduct(c *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// RegenerateProductClientKey 重新生成产品客户端密钥
+func RegenerateProductClientKey(c *gin.Context) {
+	id := c.Param("id")
+	productService := service.NewProductService()
+	product, err := productService.RegenerateClientKey(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "重新生成客户端密钥失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "客户端密钥已重新生成",
+		"code":    200,
+		"data":    product,
+	})
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signingKeyErrorStatus 根据签名密钥操作错误选择HTTP状态码
+func signingKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrSigningKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrLastSigningKey), errors.Is(err, service.ErrRootSigningKey):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ListSigningKeys 获取签名密钥列表
+func ListSigningKeys(c *gin.Context) {
+	keys, err := service.ListSigningKeys(c.Query("product_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取签名密钥失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取签名密钥成功",
+		"code":    200,
+		"data":    keys,
+	})
+}
+
+// RotateSigningKey 轮换签名密钥，新密钥启用后原密钥停止签发但仍可校验
+func RotateSigningKey(c *gin.Context) {
+	var req service.RotateSigningKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	key, err := service.RotateSigningKey(&req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "轮换签名密钥失败: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "签名密钥轮换成功",
+		"code":    200,
+		"data":    key,
+	})
+}
+
+// RetireSigningKey 停用签名密钥，retires_at为空时立即停用
+func RetireSigningKey(c *gin.Context) {
+	var req struct {
+		RetiresAt *time.Time `json:"retires_at"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "请求数据无效: " + err.Error(),
+				"code":    400,
+			})
+			return
+		}
+	}
+
+	key, err := service.RetireSigningKey(c.Param("id"), req.RetiresAt, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		status := signingKeyErrorStatus(err)
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "停用签名密钥失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "签名密钥已停用",
+		"code":    200,
+		"data":    key,
+	})
+}
+
+// RevokeSigningKey 作废签名密钥，其签发的授权文件不再被信任
+func RevokeSigningKey(c *gin.Context) {
+	key, err := service.RevokeSigningKey(c.Param("id"), c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		status := signingKeyErrorStatus(err)
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "作废签名密钥失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "签名密钥已作废",
+		"code":    200,
+		"data":    key,
+	})
+}
+
+// GetSigningKeySet 以JWKS格式公开签名公钥，product_id不为空时只返回该产品可用的密钥
+func GetSigningKeySet(c *gin.Context) {
+	set, err := service.GetSigningKeySet(c.Query("product_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"LVerity/pkg/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListLicenseTrials 获取自助试用记录列表
+func ListLicenseTrials(c *gin.Context) {
+	trials, total, err := service.ListLicenseTrials(
+		c.DefaultQuery("page", "1"),
+		c.DefaultQuery("pageSize", "20"),
+		c.Query("product_id"),
+		c.Query("status"),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取试用记录失败: " + err.Error(),
+			"code":    500,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "获取试用记录成功",
+		"code":    200,
+		"data": gin.H{
+			"list":  trials,
+			"total": total,
+		},
+	})
+}
+
+// ConvertTrialLicense 将试用授权转为正式授权，保留设备激活和变更历史
+func ConvertTrialLicense(c *gin.Context) {
+	var req service.ConvertTrialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据无效: " + err.Error(),
+			"code":    400,
+		})
+		return
+	}
+
+	license, err := service.ConvertTrialLicense(c.Param("id"), &req, c.GetString("userID"), c.GetString("username"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrNotTrialLicense) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "试用授权转正式授权失败: " + err.Error(),
+			"code":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "试用授权已转为正式授权",
+		"code":    200,
+		"data":    license,
+	})
+}
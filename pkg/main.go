@@ -7,6 +7,7 @@ import (
 	"LVerity/pkg/config"
 	"LVerity/pkg/database"
 	"LVerity/pkg/router"
+	"LVerity/pkg/scheduler"
 	"LVerity/pkg/service"
 )
 
@@ -28,11 +29,26 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// 初始化授权签名密钥
+	signingKeyFile := config.GetConfig().License.SigningKeyFile
+	if signingKeyFile == "" {
+		signingKeyFile = "data/license_signing.key"
+	}
+	if err := service.InitLicenseSigner(signingKeyFile); err != nil {
+		log.Fatalf("Failed to initialize license signer: %v", err)
+	}
+
 	// 初始化默认权限
 	if err := service.InitDefaultPermissions(); err != nil {
 		log.Printf("Warning: Failed to initialize default permissions: %v", err)
 	}
 
+	// 启动设备监控，定期清理离线设备并回收过期租约
+	service.GetDeviceMonitor().Start()
+
+	// 启动授权到期状态任务
+	scheduler.StartLicenseExpiry()
+
 	// 创建路由
 	r := router.SetupRouter()
 
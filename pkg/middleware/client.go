@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKeyHeader 客户端API认证请求头
+const ClientKeyHeader = "X-Client-Key"
+
+// ClientKeyAuth 客户端API认证中间件，使用产品的客户端密钥而非用户令牌
+func ClientKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		product, err := service.NewProductService().GetProductByClientKey(c.GetHeader(ClientKeyHeader))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, model.ClientResponse{
+				Code:       model.ClientCodeInvalidClientKey,
+				Message:    "invalid client key",
+				ServerTime: time.Now(),
+			})
+			c.Abort()
+			return
+		}
+
+		// 将产品信息存储到上下文
+		c.Set("productID", product.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientKeyAuth(t *testing.T) {
+	setupTest(t)
+
+	product := &model.Product{ID: "p-auth", Name: "Auth Product"}
+	require.NoError(t, service.NewProductService().CreateProduct(product))
+
+	r := gin.New()
+	r.GET("/client/v1/ping", ClientKeyAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("productID"))
+	})
+	request := func(clientKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/client/v1/ping", nil)
+		if clientKey != "" {
+			req.Header.Set(ClientKeyHeader, clientKey)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 有效密钥通过认证，并在上下文中记录所属产品
+	w := request(product.ClientKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, product.ID, w.Body.String())
+
+	// 缺少或未知的密钥返回401和统一的客户端结果码
+	for _, clientKey := range []string{"", "ck_unknown"} {
+		w := request(clientKey)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var resp model.ClientResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, model.ClientCodeInvalidClientKey, resp.Code)
+	}
+}
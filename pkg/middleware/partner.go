@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"LVerity/pkg/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// partnerAllowedPrefixes 合作伙伴用户可以访问的接口前缀
+var partnerAllowedPrefixes = []string{"/api/partner/", "/api/user/"}
+
+// PartnerScope 识别合作伙伴用户并将合作伙伴ID存储到上下文
+// 合作伙伴用户只能访问/api/partner下的接口和个人资料，不能访问管理接口
+func PartnerScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		partnerID, err := service.GetUserPartnerID(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+			c.Abort()
+			return
+		}
+		if partnerID == "" {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		for _, prefix := range partnerAllowedPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				c.Set("partnerID", partnerID)
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "没有操作权限"})
+		c.Abort()
+	}
+}
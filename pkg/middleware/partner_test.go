@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartnerScope(t *testing.T) {
+	setupTest(t)
+	partner, err := service.CreatePartner(&service.PartnerRequest{Name: "Reseller"}, "", "")
+	require.NoError(t, err)
+	for _, user := range []*model.User{
+		{ID: "u-admin", Username: "admin", Password: "-"},
+		{ID: "u-partner", Username: "partner", Password: "-"},
+	} {
+		require.NoError(t, database.GetDB().Create(user).Error)
+	}
+	require.NoError(t, service.AddPartnerUser(partner.ID, "u-partner", "", ""))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+		c.Next()
+	}, PartnerScope())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("partnerID"))
+	}
+	r.GET("/api/licenses", handler)
+	r.GET("/api/partner/licenses", handler)
+	request := func(userID string, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 合作伙伴用户不能访问管理接口，访问/api/partner时记录所属合作伙伴
+	assert.Equal(t, http.StatusForbidden, request("u-partner", "/api/licenses").Code)
+	w := request("u-partner", "/api/partner/licenses")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, partner.ID, w.Body.String())
+
+	// 其他用户不受限制
+	w = request("u-admin", "/api/licenses")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
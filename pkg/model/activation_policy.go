@@ -0,0 +1,47 @@
+// model/activation_policy.go
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ActivationPolicy 激活限制策略，可设置在授权或授权方案上，授权上的策略优先
+// 激活、签出和心跳时校验，各项为空表示不限制
+type ActivationPolicy struct {
+	AllowedCountries []string `json:"allowed_countries,omitempty"` // 允许的国家，名称或ISO代码
+	DeniedCountries  []string `json:"denied_countries,omitempty"`  // 禁止的国家，优先于允许列表
+	AllowedCIDRs     []string `json:"allowed_cidrs,omitempty"`     // 允许的客户端IP网段
+	AllowedHours     []string `json:"allowed_hours,omitempty"`     // 允许的时段，如09:00-18:00，结束早于开始时跨越午夜
+	Timezone         string   `json:"timezone,omitempty"`          // 时段所用的IANA时区，为空时使用UTC
+}
+
+// IsEmpty 策略是否没有任何限制
+func (p *ActivationPolicy) IsEmpty() bool {
+	return p == nil || (len(p.AllowedCountries) == 0 && len(p.DeniedCountries) == 0 &&
+		len(p.AllowedCIDRs) == 0 && len(p.AllowedHours) == 0)
+}
+
+// Value 实现driver.Valuer接口
+func (p ActivationPolicy) Value() (driver.Value, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (p *ActivationPolicy) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case nil:
+		*p = ActivationPolicy{}
+		return nil
+	}
+	return errors.New("类型断言到[]byte失败")
+}
@@ -0,0 +1,108 @@
+// model/client.go
+package model
+
+import (
+	"time"
+)
+
+// ClientCode 客户端API结果码
+type ClientCode string
+
+const (
+	ClientCodeOK                  ClientCode = "OK"
+	ClientCodeLicenseInGrace      ClientCode = "LICENSE_IN_GRACE" // 授权已到期但处于宽限期，仍可使用
+	ClientCodeInvalidRequest      ClientCode = "INVALID_REQUEST"
+	ClientCodeInvalidClientKey    ClientCode = "INVALID_CLIENT_KEY"
+	ClientCodeInvalidLicenseKey   ClientCode = "INVALID_LICENSE_KEY" // 授权码格式或校验字符错误，通常为输入错误
+	ClientCodeLicenseNotFound     ClientCode = "LICENSE_NOT_FOUND"
+	ClientCodeLicenseExpired      ClientCode = "LICENSE_EXPIRED"
+	ClientCodeLicenseDisabled     ClientCode = "LICENSE_DISABLED"
+	ClientCodeLicenseNotActivated ClientCode = "LICENSE_NOT_ACTIVATED"
+	ClientCodeDeviceLimitReached  ClientCode = "DEVICE_LIMIT_REACHED"
+	ClientCodeDeviceBlocked       ClientCode = "DEVICE_BLOCKED"
+	ClientCodeLeaseNotFound       ClientCode = "LEASE_NOT_FOUND"
+	ClientCodeUsageLimitReached   ClientCode = "USAGE_LIMIT_REACHED"
+	ClientCodeTrialNotAllowed     ClientCode = "TRIAL_NOT_ALLOWED" // 试用已关闭，或该设备、网络、邮箱已申请过试用
+	ClientCodeActivationDenied    ClientCode = "ACTIVATION_DENIED" // 违反授权的激活限制，如所在国家、IP网段或时段不允许
+	ClientCodeInternalError       ClientCode = "INTERNAL_ERROR"
+)
+
+// ClientResponse 客户端API统一响应结构
+type ClientResponse struct {
+	Code       ClientCode  `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	ServerTime time.Time   `json:"server_time"`
+}
+
+// ClientDevice 客户端上报的设备硬件信息
+type ClientDevice struct {
+	Name        string   `json:"name"`
+	DiskID      string   `json:"disk_id" binding:"required"`
+	BIOS        string   `json:"bios" binding:"required"`
+	Motherboard string   `json:"motherboard" binding:"required"`
+	Fallback    []string `json:"fallback,omitempty"` // 采集不到序列号、以型号级信息代替的组件，同型号设备取值相同，不参与容错匹配
+}
+
+// ClientLicenseRequest 客户端激活、校验、心跳、停用请求
+type ClientLicenseRequest struct {
+	Code       string       `json:"code" binding:"required"`
+	Device     ClientDevice `json:"device"`
+	ClientTime *time.Time   `json:"client_time,omitempty"` // 客户端本地时间，用于发现时钟回拨
+}
+
+// ClientTrialRequest 客户端申请试用授权请求，试用授权属于客户端密钥对应的产品
+type ClientTrialRequest struct {
+	Device ClientDevice `json:"device"`
+	Email  string       `json:"email" binding:"omitempty,email"`
+}
+
+// ClientLicenseData 客户端API返回的授权信息
+type ClientLicenseData struct {
+	LicenseID         string          `json:"license_id"`
+	Code              string          `json:"code"`
+	Type              LicenseType     `json:"type"`
+	Status            LicenseStatus   `json:"status"`
+	Features          []string        `json:"features"`
+	MaxDevices        int             `json:"max_devices"`
+	UsedDevices       int             `json:"used_devices"`
+	StartTime         time.Time       `json:"start_time"`
+	ExpireTime        time.Time       `json:"expire_time"`
+	DeviceID          string          `json:"device_id"`
+	HeartbeatInterval int             `json:"heartbeat_interval"` // 心跳间隔(秒)
+	SeatMode          LicenseSeatMode `json:"seat_mode"`
+	LeaseExpiresAt    *time.Time      `json:"lease_expires_at,omitempty"`  // 浮动授权租约到期时间
+	InGrace           bool            `json:"in_grace"`                    // 授权已到期，处于宽限期内
+	GraceExpireTime   *time.Time      `json:"grace_expire_time,omitempty"` // 宽限期结束时间
+	LicenseFile       *LicenseFile    `json:"license_file,omitempty"`
+	SignedServerTime  *LicenseFile    `json:"signed_server_time,omitempty"` // 签名的服务端时间，客户端据此发现时钟回拨
+}
+
+// ServerTimeClaimsType 签名时间戳的内容类型，避免与授权文件等其他签名内容混用
+const ServerTimeClaimsType = "server_time"
+
+// ServerTimeClaims 服务端签名的时间戳，绑定设备指纹
+type ServerTimeClaims struct {
+	Type        string    `json:"typ"`
+	Time        time.Time `json:"time"`
+	Fingerprint string    `json:"fingerprint"`
+}
+
+// ClientUsageRequest 客户端用量上报请求
+type ClientUsageRequest struct {
+	Code           string       `json:"code" binding:"required"`
+	Device         ClientDevice `json:"device"`
+	IdempotencyKey string       `json:"idempotency_key" binding:"required,max=191"`
+	Quantity       int64        `json:"quantity" binding:"min=0"` // 为0时按1计量
+}
+
+// ClientUsageData 客户端用量上报结果
+type ClientUsageData struct {
+	LicenseID  string `json:"license_id"`
+	UsageID    string `json:"usage_id"`
+	Quantity   int64  `json:"quantity"`
+	UsageCount int64  `json:"usage_count"`
+	UsageLimit int64  `json:"usage_limit"` // 0表示不限制
+	Remaining  int64  `json:"remaining"`   // 不限制时为-1
+	Duplicate  bool   `json:"duplicate"`   // 幂等键已上报过，本次未重复计量
+}
@@ -11,6 +11,7 @@ type Customer struct {
 	ContactEmail string    `json:"contactEmail"`
 	ContactPhone string    `json:"contactPhone"`
 	Address      string    `json:"address"`
+	PartnerID    string    `json:"partnerId" gorm:"index"` // 所属合作伙伴，为空表示直销客户
 	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt    time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
 }
@@ -19,3 +20,28 @@ type Customer struct {
 func (Customer) TableName() string {
 	return "customers"
 }
+
+// CustomerSummary 客户名下的授权与设备汇总
+type CustomerSummary struct {
+	Customer      *Customer               `json:"customer"`
+	LicenseCount  int64                   `json:"licenseCount"`
+	StatusCounts  map[LicenseStatus]int64 `json:"statusCounts"`
+	ProductCounts map[string]int64        `json:"productCounts"` // 按产品ID统计的授权数
+	MaxDevices    int64                   `json:"maxDevices"`    // 有效授权的设备名额合计
+	UsedDevices   int64                   `json:"usedDevices"`   // 已占用的设备名额合计
+	ExpiringSoon  int64                   `json:"expiringSoon"`  // 30天内到期的有效授权数
+	DeviceCount   int                     `json:"deviceCount"`
+	Devices       []CustomerDevice        `json:"devices"`
+}
+
+// CustomerDevice 客户名下已激活授权的设备
+type CustomerDevice struct {
+	DeviceID         string     `json:"deviceId"`
+	DeviceName       string     `json:"deviceName"`
+	DeviceStatus     string     `json:"deviceStatus"`
+	LicenseID        string     `json:"licenseId"`
+	LicenseCode      string     `json:"licenseCode"`
+	ActivationStatus string     `json:"activationStatus"`
+	ActivatedAt      time.Time  `json:"activatedAt"`
+	LastSeen         *time.Time `json:"lastSeen"`
+}
@@ -54,6 +54,7 @@ type Device struct {
 	HeartbeatRate   int            `gorm:"column:heartbeat_rate;default:60" json:"heartbeat_rate"`
 	LastHeartbeat   *time.Time     `gorm:"column:last_heartbeat" json:"last_heartbeat"`
 	LastSeen        *time.Time     `gorm:"column:last_seen" json:"last_seen"`
+	LastClientTime  *time.Time     `gorm:"column:last_client_time" json:"last_client_time"` // 设备上报过的最晚本地时间
 	LastCommandAt   *time.Time     `gorm:"column:last_command_at" json:"last_command_at"`
 	LastCommand     string         `gorm:"column:last_command;type:varchar(50)" json:"last_command"`
 	LicenseID       string         `gorm:"column:license_id;type:varchar(191)" json:"license_id"`
@@ -88,6 +89,11 @@ type BlacklistRule struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// 异常行为类型
+const (
+	AbnormalBehaviorClockTamper = "clock_tamper" // 设备上报的本地时间早于此前上报的时间，可能回拨了系统时钟
+)
+
 // AbnormalBehavior 异常行为记录
 type AbnormalBehavior struct {
 	ID          string         `gorm:"primaryKey;type:varchar(191)" json:"id"`
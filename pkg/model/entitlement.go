@@ -0,0 +1,27 @@
+// model/entitlement.go
+package model
+
+// Entitlement 授权包含的功能权益
+// Features中的每一项解析为一个权益："export_pdf"或"export_pdf=true"表示开启，
+// "export_pdf=false"表示关闭，"max_users=50"表示开启并限制数量为50，其他取值保存在Value中
+type Entitlement struct {
+	Key     string   `json:"key"`
+	Enabled bool     `json:"enabled"`
+	Limit   *int64   `json:"limit,omitempty"` // 数量限制，为空表示不限制
+	Value   string   `json:"value,omitempty"` // 非布尔、非数值的原始取值
+	Sources []string `json:"sources"`         // 提供该权益的授权ID
+}
+
+// ClientEntitlementRequest 客户端权益查询请求
+type ClientEntitlementRequest struct {
+	Code    string       `json:"code" binding:"required"`
+	Device  ClientDevice `json:"device"`
+	Feature string       `json:"feature"` // 为空时返回全部权益
+}
+
+// ClientEntitlementData 客户端权益查询结果
+type ClientEntitlementData struct {
+	LicenseID    string        `json:"license_id"`
+	Entitlement  *Entitlement  `json:"entitlement,omitempty"` // 请求的功能，未授权时Enabled为false
+	Entitlements []Entitlement `json:"entitlements"`
+}
@@ -0,0 +1,36 @@
+// model/fingerprint.go
+package model
+
+// 参与设备指纹匹配的硬件组件
+const (
+	FingerprintComponentDiskID      = "disk_id"
+	FingerprintComponentBIOS        = "bios"
+	FingerprintComponentMotherboard = "motherboard"
+)
+
+// FingerprintComponents 按顺序列出所有指纹组件
+var FingerprintComponents = []string{
+	FingerprintComponentDiskID,
+	FingerprintComponentBIOS,
+	FingerprintComponentMotherboard,
+}
+
+// FingerprintPolicy 设备指纹容错匹配策略
+// 匹配上的组件权重之和不低于MinScore时视为同一台设备，如各组件权重为1、MinScore为2即"三选二"
+type FingerprintPolicy struct {
+	Weights  map[string]int `json:"weights"`
+	MinScore int            `json:"min_score"`
+}
+
+// FingerprintMatch 设备指纹容错匹配结果
+type FingerprintMatch struct {
+	Matched   []string `json:"matched"`
+	Unmatched []string `json:"unmatched"`
+	Score     int      `json:"score"`     // 匹配组件的权重之和
+	MinScore  int      `json:"min_score"` // 策略要求的最低分
+}
+
+// IsMatch 判断是否达到策略要求的匹配分数
+func (m *FingerprintMatch) IsMatch() bool {
+	return m.MinScore > 0 && m.Score >= m.MinScore
+}
@@ -19,6 +19,17 @@ const (
 	LicenseTypeModule     LicenseType = "module"   // 功能模块授权码
 )
 
+// IsValid 检查授权类型是否有效
+func (t LicenseType) IsValid() bool {
+	switch t {
+	case LicenseTypeBasic, LicenseTypeStandard, LicenseTypePro, LicenseTypeEnterprise,
+		LicenseTypeTrial, LicenseTypeOfficial, LicenseTypePay, LicenseTypeModule:
+		return true
+	default:
+		return false
+	}
+}
+
 // LicenseStatus 授权状态
 type LicenseStatus string
 
@@ -27,12 +38,21 @@ const (
 	LicenseStatusUsed      LicenseStatus = "used"      // 已使用
 	LicenseStatusDisabled  LicenseStatus = "disabled"  // 已禁用
 	LicenseStatusExpired   LicenseStatus = "expired"   // 已过期
+	LicenseStatusGrace     LicenseStatus = "grace"     // 已到期，处于宽限期内仍可使用
 	LicenseStatusRevoked   LicenseStatus = "revoked"   // 已撤销
 	LicenseStatusTransferred LicenseStatus = "transferred" // 已转移
 	LicenseStatusActive   LicenseStatus = "active"
 	LicenseStatusInactive LicenseStatus = "inactive"
 )
 
+// LicenseSeatMode 授权席位模式
+type LicenseSeatMode string
+
+const (
+	LicenseSeatModeNamed    LicenseSeatMode = "named"    // 按设备激活，名额长期占用
+	LicenseSeatModeFloating LicenseSeatMode = "floating" // 浮动授权，按租约占用并发名额
+)
+
 // LicenseGroup 授权组
 type LicenseGroup struct {
 	ID          string    `json:"id" gorm:"primaryKey"`
@@ -41,6 +61,20 @@ type LicenseGroup struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	CreatedBy   string    `json:"created_by" gorm:"type:varchar(191)"`
+	LicenseCount int64    `json:"license_count" gorm:"->;-:migration"` // 非数据库字段，组内授权数
+}
+
+// LicenseGroupStats 授权组统计
+type LicenseGroupStats struct {
+	GroupID       string                  `json:"group_id"`
+	Name          string                  `json:"name"`
+	Total         int64                   `json:"total"`
+	ByStatus      map[LicenseStatus]int64 `json:"by_status"`
+	ByType        map[LicenseType]int64   `json:"by_type"`
+	MaxDevices    int64                   `json:"max_devices"`    // 组内授权的设备名额合计
+	UsedDevices   int64                   `json:"used_devices"`   // 组内授权已占用的设备名额合计
+	ExpiringSoon  int64                   `json:"expiring_soon"`  // 30天内到期的有效授权数
+	ActivatedRate float64                 `json:"activated_rate"` // 已激活授权占比
 }
 
 // LicenseTag 授权标签
@@ -50,7 +84,8 @@ type LicenseTag struct {
 	Color     string       `json:"color" gorm:"type:varchar(50)"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
-	Licenses  []License    `json:"licenses" gorm:"many2many:license_tag_mapping;joinForeignKey:tag_id;joinReferences:license_id"`
+	Licenses  []License    `json:"licenses,omitempty" gorm:"many2many:license_tag_mapping;joinForeignKey:tag_id;joinReferences:license_id"`
+	LicenseCount int64     `json:"license_count" gorm:"->;-:migration"` // 非数据库字段，打了该标签的授权数
 }
 
 // LicenseTagMapping 授权标签映射
@@ -60,17 +95,25 @@ type LicenseTagMapping struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// TableName 指定表名，与License.Tags的多对多关联表一致
+func (LicenseTagMapping) TableName() string {
+	return "license_tag_mapping"
+}
+
 // License 授权记录
 type License struct {
 	ID          string        `json:"id" gorm:"primaryKey"`
 	Code        string        `json:"code" gorm:"uniqueIndex:idx_license_code,length:191;type:varchar(191)"`
-	Type        LicenseType   `json:"type" gorm:"type:varchar(20)"`
-	Status      LicenseStatus `json:"status" gorm:"type:varchar(20)"`
+	Type        LicenseType   `json:"type" gorm:"type:varchar(20);index"`
+	Status      LicenseStatus `json:"status" gorm:"type:varchar(20);index"`
 	DeviceID    string        `json:"device_id" gorm:"type:varchar(191);index"`
 	MaxDevices  int           `json:"max_devices"`
+	UsedDevices int           `json:"used_devices" gorm:"default:0"` // 当前已激活的设备数，浮动授权为当前租约数
+	SeatMode    LicenseSeatMode `json:"seat_mode" gorm:"type:varchar(20);default:'named'"`
+	LeaseDuration int         `json:"lease_duration" gorm:"default:0"` // 浮动授权租约时长(秒)，0表示使用默认值
 	StartTime   time.Time     `json:"start_time"`
-	ExpireTime  time.Time     `json:"expire_time"`
-	CreatedAt   time.Time     `json:"created_at"`
+	ExpireTime  time.Time     `json:"expire_time" gorm:"index"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"index"`
 	UpdatedAt   time.Time     `json:"updated_at"`
 	Description string        `json:"description" gorm:"type:text"`
 	Deleted     bool          `json:"deleted" gorm:"default:false"`
@@ -78,26 +121,48 @@ type License struct {
 	UpdatedBy   string        `json:"updated_by" gorm:"type:varchar(191)"`
 	DeletedAt   *time.Time    `json:"deleted_at,omitempty" gorm:"index"`
 	GroupID     string        `json:"group_id" gorm:"type:varchar(191);index"` // 新增：授权组ID
+	CustomerID  string        `json:"customer_id" gorm:"type:varchar(191);index"` // 授权所属客户
+	ProductID   string        `json:"product_id" gorm:"type:varchar(191);index"`  // 授权所属产品
 	Tags        []LicenseTag  `json:"tags" gorm:"many2many:license_tag_mapping;joinForeignKey:license_id;joinReferences:tag_id"`
 	Metadata    string        `json:"metadata" gorm:"type:text"` // 新增：JSON格式的元数据
 	Features    []string      `json:"features" gorm:"-"` // 新增：支持的功能列表
 	FeaturesStr string        `json:"-" gorm:"column:features;type:text"` // 存储Features的JSON字符串
 	UsageLimit  int64         `json:"usage_limit" gorm:"default:0"` // 新增：使用次数限制，0表示无限制
 	UsageCount  int64         `json:"usage_count" gorm:"default:0"` // 新增：已使用次数
+	SignedFile  string        `json:"-" gorm:"type:text"`           // 最近签发的离线授权文件(JSON)
+	PlanID      string        `json:"plan_id" gorm:"type:varchar(191);index"` // 创建授权所用的授权方案
+	PartnerID   string        `json:"partner_id" gorm:"type:varchar(191);index"` // 签发授权的合作伙伴
+	ActivationPolicy *ActivationPolicy `json:"activation_policy,omitempty" gorm:"type:text"` // 激活限制，为空时使用授权方案的策略
+	GracePeriodDays *int      `json:"grace_period_days,omitempty"`            // 到期宽限期天数，为空时使用系统配置
 }
 
-// LicenseActivation 许可证激活记录
+// 激活记录状态
+const (
+	ActivationStatusActive   = "active"   // 已激活，占用一个设备名额
+	ActivationStatusInactive = "inactive" // 已停用，释放设备名额
+	ActivationStatusTransferred = "transferred" // 已转移到其他设备或客户
+	ActivationStatusOffline     = "offline"     // 离线激活，占用一个设备名额，设备不与服务端通信
+)
+
+// LicenseActivation 许可证激活记录，每个授权在每台设备上最多一条
 type LicenseActivation struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	LicenseID   string    `json:"license_id" gorm:"index"`
-	DeviceID    string    `json:"device_id" gorm:"index"`
-	DeviceName  string    `json:"device_name" gorm:"-"` // 非数据库字段，用于关联查询
-	ActivatedAt time.Time `json:"activated_at"`
-	Status      string    `json:"status" gorm:"type:varchar(20)"`
-	IPAddress   string    `json:"ip_address" gorm:"type:varchar(50)"`
-	Location    string    `json:"location" gorm:"type:varchar(100)"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            string     `json:"id" gorm:"primaryKey"`
+	LicenseID     string     `json:"license_id" gorm:"uniqueIndex:idx_license_device;type:varchar(191)"`
+	DeviceID      string     `json:"device_id" gorm:"uniqueIndex:idx_license_device;type:varchar(191);index"`
+	DeviceName    string     `json:"device_name" gorm:"-"` // 非数据库字段，用于关联查询
+	ActivatedAt   time.Time  `json:"activated_at"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	Status        string     `json:"status" gorm:"type:varchar(20)"`
+	IPAddress     string     `json:"ip_address" gorm:"type:varchar(50)"`
+	Location      string     `json:"location" gorm:"type:varchar(100)"`
+	SignedFile    string     `json:"-" gorm:"type:text"` // 绑定该设备指纹的离线授权文件(JSON)
+	// 最近一次硬件变更时容错匹配的结果，设备指纹完全一致时为空
+	MatchedComponents   StringArray `json:"matched_components,omitempty" gorm:"type:text"`
+	UnmatchedComponents StringArray `json:"unmatched_components,omitempty" gorm:"type:text"`
+	MatchScore          int         `json:"match_score,omitempty"`
+	MatchedAt           *time.Time  `json:"matched_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -105,6 +170,49 @@ func (LicenseActivation) TableName() string {
 	return "license_activations"
 }
 
+// 浮动授权租约状态
+const (
+	LeaseStatusActive   = "active"   // 持有中
+	LeaseStatusReleased = "released" // 客户端主动归还
+	LeaseStatusExpired  = "expired"  // 超时未续约被回收
+)
+
+// LicenseLease 浮动授权租约，持有期间占用一个并发名额
+type LicenseLease struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	LicenseID    string     `json:"license_id" gorm:"type:varchar(191);index"`
+	DeviceID     string     `json:"device_id" gorm:"type:varchar(191);index"`
+	DeviceName   string     `json:"device_name" gorm:"->;-:migration"` // 非数据库字段，用于关联查询
+	Status       string     `json:"status" gorm:"type:varchar(20);index"`
+	IPAddress    string     `json:"ip_address" gorm:"type:varchar(50)"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	RenewedAt    time.Time  `json:"renewed_at"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"index"`
+	ReleasedAt   *time.Time `json:"released_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (LicenseLease) TableName() string {
+	return "license_leases"
+}
+
+// LicenseStatusTransition 授权状态变更记录
+type LicenseStatusTransition struct {
+	ID         string        `json:"id" gorm:"primaryKey"`
+	LicenseID  string        `json:"license_id" gorm:"type:varchar(191);index"`
+	FromStatus LicenseStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   LicenseStatus `json:"to_status" gorm:"type:varchar(20)"`
+	Reason     string        `json:"reason" gorm:"type:varchar(255)"`
+	CreatedAt  time.Time     `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (LicenseStatusTransition) TableName() string {
+	return "license_status_transitions"
+}
+
 // LicenseUsage 授权使用记录
 type LicenseUsage struct {
 	ID         string    `json:"id" gorm:"primaryKey"`
@@ -131,6 +239,7 @@ type LicenseStats struct {
 	UsedCount    int64                       `json:"used_count"`    // 已使用数
 	UnusedCount  int64                       `json:"unused_count"`  // 未使用数
 	ExpiredCount int64                       `json:"expired_count"` // 已过期数
+	GraceCount   int64                       `json:"grace_count"`   // 宽限期内数
 	TypeStats    map[LicenseType]int64       `json:"type_stats"`   // 各类型数量
 }
 
@@ -0,0 +1,41 @@
+// model/license_file.go
+package model
+
+import (
+	"time"
+)
+
+// LicenseFileAlgorithm 离线授权文件签名算法
+const LicenseFileAlgorithm = "Ed25519"
+
+// LicenseFileVersion 离线授权文件格式版本
+const LicenseFileVersion = 1
+
+// LicenseClaimsType 授权内容的签名内容类型，避免与吊销列表等其他签名内容混用
+const LicenseClaimsType = "license"
+
+// LicenseClaims 离线授权文件中被签名的授权内容
+type LicenseClaims struct {
+	PayloadType     string      `json:"typ"`
+	LicenseID       string      `json:"license_id"`
+	Code            string      `json:"code"`
+	Type            LicenseType `json:"type"`
+	Features        []string    `json:"features"`
+	MaxDevices      int         `json:"max_devices"`
+	StartTime       time.Time   `json:"start_time"`
+	ExpireTime      time.Time   `json:"expire_time"`
+	GraceExpireTime *time.Time  `json:"grace_expire_time,omitempty"` // 宽限期结束时间，到期后在此之前仍可使用
+	Fingerprint     string      `json:"fingerprint,omitempty"`       // 绑定的设备指纹，为空表示未绑定
+	LeaseExpiresAt  *time.Time  `json:"lease_expires_at,omitempty"`  // 浮动授权租约到期时间，到期后需续约
+	Offline         bool        `json:"offline,omitempty"`           // 离线激活签发，客户端不受离线宽限期限制
+	IssuedAt        time.Time   `json:"issued_at"`
+}
+
+// LicenseFile 离线授权文件，客户端只需内置公钥即可校验
+type LicenseFile struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Payload   string `json:"payload"`   // base64编码的LicenseClaims JSON
+	Signature string `json:"signature"` // 对Payload原文的base64编码签名
+}
@@ -0,0 +1,39 @@
+// model/license_import.go
+package model
+
+// LicenseImportRow 导入文件中的一行授权记录
+// 时间字段保留原始文本，由导入校验逐行解析，支持RFC3339和2006-01-02格式
+type LicenseImportRow struct {
+	Row         int      `json:"row"`  // 行号，CSV从数据行开始计为2(表头为第1行)，JSON从1开始
+	Code        string   `json:"code"` // 为空时按产品格式自动生成
+	Type        string   `json:"type"`
+	Status      string   `json:"status"` // 为空时为unused，仅支持unused、disabled、expired
+	MaxDevices  int      `json:"max_devices"`
+	StartTime   string   `json:"start_time"`
+	ExpireTime  string   `json:"expire_time"`
+	CustomerID  string   `json:"customer_id"`
+	ProductID   string   `json:"product_id"`
+	GroupID     string   `json:"group_id"`
+	Features    []string `json:"features"` // CSV中以分号分隔
+	UsageLimit  int64    `json:"usage_limit"`
+	Description string   `json:"description"`
+}
+
+// LicenseImportError 导入校验错误
+type LicenseImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// LicenseImportResult 导入结果，预检时只返回校验报告不写入数据
+type LicenseImportResult struct {
+	DryRun   bool                 `json:"dry_run"`
+	Total    int                  `json:"total"`
+	Valid    int                  `json:"valid"`
+	Invalid  int                  `json:"invalid"`
+	Imported int                  `json:"imported"`
+	Errors   []LicenseImportError `json:"errors"`
+	Licenses []License            `json:"licenses,omitempty"` // 已导入的授权
+}
@@ -0,0 +1,31 @@
+// model/license_plan.go
+package model
+
+import (
+	"time"
+)
+
+// LicensePlan 授权方案，挂在产品下作为创建授权的模板
+type LicensePlan struct {
+	ID               string            `json:"id" gorm:"primaryKey"`
+	ProductID        string            `json:"product_id" gorm:"type:varchar(191);index"`
+	Name             string            `json:"name" gorm:"type:varchar(100);not null"`
+	Description      string            `json:"description" gorm:"type:text"`
+	Type             LicenseType       `json:"type" gorm:"type:varchar(20)"`
+	DurationDays     int               `json:"duration_days"` // 授权有效天数，从创建授权时起算
+	MaxDevices       int               `json:"max_devices"`
+	SeatMode         LicenseSeatMode   `json:"seat_mode" gorm:"type:varchar(20);default:'named'"`
+	LeaseDuration    int               `json:"lease_duration"`            // 浮动授权租约时长(秒)，0表示使用默认值
+	Features         StringArray       `json:"features" gorm:"type:json"` // 授权权益，格式同授权的Features
+	UsageLimit       int64             `json:"usage_limit"`
+	GracePeriodDays  *int              `json:"grace_period_days,omitempty"`                  // 到期宽限期天数，为空时使用系统配置
+	ActivationPolicy *ActivationPolicy `json:"activation_policy,omitempty" gorm:"type:text"` // 按该方案创建的授权的激活限制
+	CreatedBy        string            `json:"created_by" gorm:"type:varchar(191)"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (LicensePlan) TableName() string {
+	return "license_plans"
+}
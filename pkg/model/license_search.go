@@ -0,0 +1,21 @@
+// model/license_search.go
+package model
+
+// LicenseSearchRequest 授权搜索请求
+// Query为过滤表达式，如 `type:pro,enterprise status:used expiring:30 tag:reseller feature:export meta.region:eu -customer:c-1`
+// Sort为逗号分隔的排序字段，前缀-表示降序，如 `expire_time,-created_at`
+type LicenseSearchRequest struct {
+	Query     string `form:"q" json:"q"`
+	Sort      string `form:"sort" json:"sort"`
+	Cursor    string `form:"cursor" json:"cursor"` // 上一页返回的next_cursor，为空时从第一条开始
+	Limit     int    `form:"limit" json:"limit"`
+	WithTotal bool   `form:"with_total" json:"with_total"` // 是否统计符合条件的总数，数据量大时会变慢
+}
+
+// LicenseSearchResult 授权搜索结果
+type LicenseSearchResult struct {
+	Items      []License `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+	Total      *int64    `json:"total,omitempty"`
+}
@@ -0,0 +1,34 @@
+// model/license_term.go
+package model
+
+import (
+	"time"
+)
+
+// LicenseTerm 授权有效期记录，每次续期追加一条，保留完整的期限历史
+type LicenseTerm struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	LicenseID    string    `json:"license_id" gorm:"type:varchar(191);uniqueIndex:idx_license_term"`
+	Sequence     int       `json:"sequence" gorm:"uniqueIndex:idx_license_term"` // 期次，从1开始
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	Reason       string    `json:"reason" gorm:"type:text"`
+	OrderRef     string    `json:"order_ref" gorm:"type:varchar(191);index"` // 关联的订单号
+	OperatorID   string    `json:"operator_id" gorm:"type:varchar(191)"`
+	OperatorName string    `json:"operator_name" gorm:"type:varchar(191)"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (LicenseTerm) TableName() string {
+	return "license_terms"
+}
+
+// LicenseRenewalResult 批量续期中单个授权的结果
+type LicenseRenewalResult struct {
+	LicenseID string       `json:"license_id"`
+	Code      string       `json:"code"`
+	Success   bool         `json:"success"`
+	Error     string       `json:"error,omitempty"`
+	Term      *LicenseTerm `json:"term,omitempty"`
+}
@@ -0,0 +1,37 @@
+// model/license_transfer.go
+package model
+
+import (
+	"time"
+)
+
+// LicenseTransferType 授权转移类型
+type LicenseTransferType string
+
+const (
+	LicenseTransferDevice   LicenseTransferType = "device"   // 设备间转移激活
+	LicenseTransferCustomer LicenseTransferType = "customer" // 转移给其他客户，重新签发授权
+	LicenseTransferReassign LicenseTransferType = "reassign" // 删除客户时改归其他客户，不重新签发授权
+)
+
+// LicenseTransfer 授权转移记录，按OriginLicenseID串联同一授权的流转链
+type LicenseTransfer struct {
+	ID              string              `json:"id" gorm:"primaryKey"`
+	OriginLicenseID string              `json:"origin_license_id" gorm:"type:varchar(191);index"` // 流转链中最初的授权
+	LicenseID       string              `json:"license_id" gorm:"type:varchar(191);index"`        // 转出的授权
+	NewLicenseID    string              `json:"new_license_id" gorm:"type:varchar(191);index"`    // 转移给其他客户时重新签发的授权
+	Type            LicenseTransferType `json:"type" gorm:"type:varchar(20)"`
+	FromDeviceID    string              `json:"from_device_id" gorm:"type:varchar(191)"`
+	ToDeviceID      string              `json:"to_device_id" gorm:"type:varchar(191)"`
+	FromCustomerID  string              `json:"from_customer_id" gorm:"type:varchar(191)"`
+	ToCustomerID    string              `json:"to_customer_id" gorm:"type:varchar(191)"`
+	Reason          string              `json:"reason" gorm:"type:text"`
+	OperatorID      string              `json:"operator_id" gorm:"type:varchar(191)"`
+	OperatorName    string              `json:"operator_name" gorm:"type:varchar(191)"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (LicenseTransfer) TableName() string {
+	return "license_transfers"
+}
@@ -0,0 +1,128 @@
+// model/license_version.go
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// 授权变更类型
+const (
+	LicenseChangeInitial   = "initial"   // 首次记录变更前补记的初始状态
+	LicenseChangeUpdate    = "update"    // 管理端编辑授权
+	LicenseChangeStatus    = "status"    // 状态流转，如到期、宽限期
+	LicenseChangeDisable   = "disable"   // 禁用授权
+	LicenseChangeMetadata  = "metadata"  // 更新元数据
+	LicenseChangeFeatures  = "features"  // 更新功能列表
+	LicenseChangeSeatMode  = "seat_mode" // 切换席位模式
+	LicenseChangeRelations = "relations" // 变更所属客户或产品
+	LicenseChangeRenew     = "renew"     // 续期
+	LicenseChangePlan      = "plan"      // 授权方案变更同步
+	LicenseChangeTransfer  = "transfer"  // 转移给其他客户
+	LicenseChangeRevert    = "revert"    // 回滚到历史版本
+	LicenseChangeConvert   = "convert"   // 试用授权转为正式授权
+	LicenseChangePolicy    = "policy"    // 变更激活限制
+)
+
+// LicenseSnapshot 授权可编辑字段的快照，用于计算变更和回滚
+// 设备占用、用量计数等由客户端使用产生的字段不在快照中
+type LicenseSnapshot struct {
+	Type            LicenseType     `json:"type"`
+	Status          LicenseStatus   `json:"status"`
+	MaxDevices      int             `json:"max_devices"`
+	SeatMode        LicenseSeatMode `json:"seat_mode"`
+	LeaseDuration   int             `json:"lease_duration"`
+	StartTime       time.Time       `json:"start_time"`
+	ExpireTime      time.Time       `json:"expire_time"`
+	Description     string          `json:"description"`
+	CustomerID      string          `json:"customer_id"`
+	ProductID       string          `json:"product_id"`
+	PlanID          string          `json:"plan_id"`
+	PartnerID       string          `json:"partner_id"` // 签发授权的合作伙伴，只用于记录，回滚时不修改
+	Features        []string        `json:"features"`
+	Metadata        string          `json:"metadata"`
+	UsageLimit      int64           `json:"usage_limit"`
+	GracePeriodDays *int            `json:"grace_period_days"`
+	// 激活限制，没有限制时为空策略；为null表示该版本早于快照记录激活限制，回滚时不修改
+	ActivationPolicy *ActivationPolicy `json:"activation_policy"`
+}
+
+// Value 实现driver.Valuer接口
+func (s LicenseSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (s *LicenseSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		return nil
+	}
+	return errors.New("invalid license snapshot value")
+}
+
+// LicenseFieldChange 单个字段的变更前后值
+type LicenseFieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// LicenseFieldChanges 字段变更列表
+type LicenseFieldChanges []LicenseFieldChange
+
+// Value 实现driver.Valuer接口
+func (c LicenseFieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (c *LicenseFieldChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = nil
+		return nil
+	}
+	return errors.New("invalid license field changes value")
+}
+
+// LicenseVersion 授权变更历史，每次变更追加一个版本，记录变更后的快照和字段级差异
+type LicenseVersion struct {
+	ID           string              `json:"id" gorm:"primaryKey"`
+	LicenseID    string              `json:"license_id" gorm:"type:varchar(191);uniqueIndex:idx_license_version"`
+	Version      int                 `json:"version" gorm:"uniqueIndex:idx_license_version"` // 版本号，从1开始
+	Action       string              `json:"action" gorm:"type:varchar(20)"`
+	Reason       string              `json:"reason" gorm:"type:text"`
+	Changes      LicenseFieldChanges `json:"changes" gorm:"type:text"`
+	Snapshot     LicenseSnapshot     `json:"snapshot" gorm:"type:text"`
+	RevertedTo   int                 `json:"reverted_to,omitempty"` // 回滚时的目标版本
+	OperatorID   string              `json:"operator_id" gorm:"type:varchar(191)"`
+	OperatorName string              `json:"operator_name" gorm:"type:varchar(191)"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// TableName 指定表名
+func (LicenseVersion) TableName() string {
+	return "license_versions"
+}
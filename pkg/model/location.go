@@ -6,10 +6,11 @@ import (
 
 // Location 位置信息
 type Location struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Country   string  `json:"country"`
-	City      string  `json:"city"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"` // ISO 3166-1二位代码
+	City        string  `json:"city"`
 }
 
 // DeviceLocationLog 设备位置日志
@@ -0,0 +1,29 @@
+// model/offline_activation.go
+package model
+
+import (
+	"time"
+)
+
+// OfflineActivationRequestVersion 离线激活请求文件格式版本
+const OfflineActivationRequestVersion = 1
+
+// OfflineActivationRequest 离线激活请求文件，由无网络设备上的客户端生成，管理员上传到服务端
+type OfflineActivationRequest struct {
+	Version     int          `json:"version"`
+	Code        string       `json:"code" binding:"required"`
+	Device      ClientDevice `json:"device"`
+	Fingerprint string       `json:"fingerprint" binding:"required"` // 设备硬件指纹，服务端据设备信息重新计算校验
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// OfflineActivationResponse 离线激活响应文件，包含绑定到请求设备指纹的签名授权文件
+type OfflineActivationResponse struct {
+	Version      int          `json:"version"`
+	LicenseID    string       `json:"license_id"`
+	ActivationID string       `json:"activation_id"`
+	Code         string       `json:"code"`
+	Fingerprint  string       `json:"fingerprint"`
+	LicenseFile  *LicenseFile `json:"license_file"`
+	IssuedAt     time.Time    `json:"issued_at"`
+}
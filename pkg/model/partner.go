@@ -0,0 +1,65 @@
+// model/partner.go
+package model
+
+import "time"
+
+// 合作伙伴状态
+const (
+	PartnerStatusActive   = "active"   // 正常
+	PartnerStatusDisabled = "disabled" // 已停用，不能再签发授权
+)
+
+// Partner 经销商或合作伙伴，ParentID不为空时为下级合作伙伴
+// 合作伙伴用户只能为本合作伙伴的客户签发授权，并只能看到本合作伙伴及其下级的数据
+type Partner struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"type:varchar(191);not null"`
+	ParentID     string    `json:"parent_id" gorm:"type:varchar(191);index"`
+	ContactName  string    `json:"contact_name" gorm:"type:varchar(191)"`
+	ContactEmail string    `json:"contact_email" gorm:"type:varchar(191)"`
+	Status       string    `json:"status" gorm:"type:varchar(20)"`
+	CreatedBy    string    `json:"created_by" gorm:"type:varchar(191)"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Partner) TableName() string {
+	return "partners"
+}
+
+// PartnerQuota 合作伙伴按产品和授权方案分配的授权配额
+// Quantity为分配到的授权数，Used为已签发的授权数，Delegated为再分配给下级合作伙伴的授权数
+type PartnerQuota struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	PartnerID string    `json:"partner_id" gorm:"type:varchar(191);uniqueIndex:idx_partner_quota"`
+	ProductID string    `json:"product_id" gorm:"type:varchar(191);index"`
+	PlanID    string    `json:"plan_id" gorm:"type:varchar(191);uniqueIndex:idx_partner_quota"`
+	Quantity  int       `json:"quantity"`
+	Used      int       `json:"used"`
+	Delegated int       `json:"delegated"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (PartnerQuota) TableName() string {
+	return "partner_quotas"
+}
+
+// Available 返回配额中还可签发或再分配的授权数
+func (q *PartnerQuota) Available() int {
+	return q.Quantity - q.Used - q.Delegated
+}
+
+// PartnerConsumption 合作伙伴的配额消耗情况
+type PartnerConsumption struct {
+	Partner        Partner        `json:"partner"`
+	Quotas         []PartnerQuota `json:"quotas"`
+	Quantity       int            `json:"quantity"`        // 各配额分配数合计
+	Used           int            `json:"used"`            // 各配额已签发数合计
+	Delegated      int            `json:"delegated"`       // 各配额再分配数合计
+	LicenseCount   int64          `json:"license_count"`   // 本合作伙伴签发的授权数，不含下级
+	ActiveLicenses int64          `json:"active_licenses"` // 其中已激活的授权数
+	CustomerCount  int64          `json:"customer_count"`
+}
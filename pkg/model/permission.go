@@ -11,12 +11,13 @@ const (
 	RoleTypeAdmin    RoleType = "admin"    // 管理员
 	RoleTypeOperator RoleType = "operator" // 操作员
 	RoleTypeViewer   RoleType = "viewer"   // 查看者
+	RoleTypePartner  RoleType = "partner"  // 合作伙伴，只能访问本合作伙伴的数据
 )
 
 // IsValid 检查角色类型是否有效
 func (r RoleType) IsValid() bool {
 	switch r {
-	case RoleTypeAdmin, RoleTypeOperator, RoleTypeViewer:
+	case RoleTypeAdmin, RoleTypeOperator, RoleTypeViewer, RoleTypePartner:
 		return true
 	default:
 		return false
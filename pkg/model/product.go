@@ -18,11 +18,16 @@ func (a StringArray) Value() (driver.Value, error) {
 
 // Scan 实现sql.Scanner接口
 func (a *StringArray) Scan(value interface{}) error {
-	b, ok := value.([]byte)
-	if !ok {
-		return errors.New("类型断言到[]byte失败")
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, &a)
+	case string:
+		return json.Unmarshal([]byte(v), &a)
+	case nil:
+		*a = nil
+		return nil
 	}
-	return json.Unmarshal(b, &a)
+	return errors.New("类型断言到[]byte失败")
 }
 
 // Product 产品模型
@@ -31,6 +36,8 @@ type Product struct {
 	Name        string      `json:"name" gorm:"not null"`
 	Description string      `json:"description"`
 	Features    StringArray `json:"features" gorm:"type:json"`
+	ClientKey   string      `json:"clientKey" gorm:"type:varchar(191);index"` // 客户端API认证密钥
+	KeyPrefix   string      `json:"keyPrefix" gorm:"type:varchar(8)"`         // 授权码前缀
 	CreatedAt   time.Time   `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time   `json:"updatedAt" gorm:"autoUpdateTime"`
 }
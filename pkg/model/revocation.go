@@ -0,0 +1,76 @@
+// model/revocation.go
+package model
+
+import (
+	"time"
+)
+
+// RevocationType 吊销对象类型
+type RevocationType string
+
+const (
+	RevocationTypeLicense RevocationType = "license" // 授权码
+	RevocationTypeDevice  RevocationType = "device"  // 设备指纹
+)
+
+// RevocationAction 吊销列表变更动作
+type RevocationAction string
+
+const (
+	RevocationActionAdd    RevocationAction = "add"    // 加入吊销列表
+	RevocationActionRemove RevocationAction = "remove" // 从吊销列表移除
+)
+
+// RevocationEntry 吊销列表变更记录，Version全局递增，客户端据此增量同步
+type RevocationEntry struct {
+	ID        string           `json:"id" gorm:"primaryKey"`
+	Version   int64            `json:"version" gorm:"uniqueIndex"`
+	Type      RevocationType   `json:"type" gorm:"type:varchar(20);index:idx_revocation_value"`
+	Value     string           `json:"value" gorm:"type:varchar(191);index:idx_revocation_value"` // 授权码或设备指纹
+	Action    RevocationAction `json:"action" gorm:"type:varchar(20)"`
+	Reason    string           `json:"reason" gorm:"type:varchar(255)"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RevocationEntry) TableName() string {
+	return "revocation_entries"
+}
+
+// RevocationCounterID 吊销列表版本计数行的ID，全局只有一行
+const RevocationCounterID = 1
+
+// RevocationCounter 吊销列表的版本计数，追加变更时在事务中锁定该行分配版本号
+type RevocationCounter struct {
+	ID      int   `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Version int64 `json:"version"` // 已分配的最大版本号
+}
+
+// TableName 指定表名
+func (RevocationCounter) TableName() string {
+	return "revocation_counters"
+}
+
+// RevocationListEntry 吊销列表中的一项
+type RevocationListEntry struct {
+	Type   RevocationType   `json:"type"`
+	Value  string           `json:"value"`
+	Action RevocationAction `json:"action"`
+}
+
+// RevocationListType 吊销列表的签名内容类型，避免与授权文件等其他签名内容混用
+const RevocationListType = "revocation_list"
+
+// RevocationList 被签名的吊销列表内容
+// 全量列表包含当前所有被吊销的对象；增量列表包含BaseVersion之后按顺序发生的变更
+type RevocationList struct {
+	Type        string                `json:"typ"`
+	Version     int64                 `json:"version"`
+	BaseVersion int64                 `json:"base_version"`
+	Full        bool                  `json:"full"`
+	IssuedAt    time.Time             `json:"issued_at"`
+	Entries     []RevocationListEntry `json:"entries"`
+}
+
+// SignedRevocationList 签名的吊销列表，与离线授权文件使用相同的签名封装
+type SignedRevocationList = LicenseFile
@@ -0,0 +1,84 @@
+// model/signing_key.go
+package model
+
+import "time"
+
+// 签名密钥状态，由启用、停用和作废时间计算得出
+const (
+	SigningKeyStatusPending = "pending" // 未到启用时间，客户端可提前获取
+	SigningKeyStatusActive  = "active"  // 用于签发新的授权文件
+	SigningKeyStatusRetired = "retired" // 不再签发，已签发的授权文件仍可校验
+	SigningKeyStatusRevoked = "revoked" // 已作废，不再发布，其签发的授权文件失效
+)
+
+// SigningKey 授权签名密钥
+// ID即密钥ID(kid)，与签名文件中的kid一致；ProductID为空表示默认密钥，产品没有专属密钥时使用默认密钥
+// 根密钥来自签名密钥文件，客户端内置其公钥，其余密钥由根密钥背书，私钥使用根密钥加密保存
+type SigningKey struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	Algorithm   string     `json:"algorithm" gorm:"type:varchar(20)"`
+	ProductID   string     `json:"product_id" gorm:"type:varchar(191);index"`
+	PublicKey   string     `json:"public_key" gorm:"type:varchar(100)"` // base64编码的公钥
+	PrivateKey  string     `json:"-" gorm:"type:text"`                  // 使用根密钥加密的私钥
+	Root        bool       `json:"root"`
+	Endorsement string     `json:"endorsement,omitempty" gorm:"type:varchar(100)"` // 根密钥对该密钥的签名
+	Description string     `json:"description" gorm:"type:varchar(255)"`
+	ActivatesAt time.Time  `json:"activates_at" gorm:"index"`
+	RetiresAt   *time.Time `json:"retires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy   string     `json:"created_by" gorm:"type:varchar(191)"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Status string `json:"status" gorm:"-"`
+}
+
+// TableName 指定表名
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+// StatusAt 返回密钥在指定时间的状态
+func (k *SigningKey) StatusAt(t time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return SigningKeyStatusRevoked
+	case t.Before(k.ActivatesAt):
+		return SigningKeyStatusPending
+	case k.RetiresAt != nil && !t.Before(*k.RetiresAt):
+		return SigningKeyStatusRetired
+	default:
+		return SigningKeyStatusActive
+	}
+}
+
+// SigningKeyJWK JWK格式的签名公钥
+type SigningKeyJWK struct {
+	KeyID       string     `json:"kid"`
+	KeyType     string     `json:"kty"` // 固定为OKP
+	Curve       string     `json:"crv"` // 固定为Ed25519
+	X           string     `json:"x"`   // base64url编码的公钥
+	Use         string     `json:"use"` // 固定为sig
+	Algorithm   string     `json:"alg"` // 固定为EdDSA
+	ProductID   string     `json:"product_id,omitempty"`
+	Status      string     `json:"status"`
+	Root        bool       `json:"root,omitempty"`
+	Endorsement string     `json:"endorsement,omitempty"` // 根密钥背书签名，客户端据此信任非根密钥
+	ActivatesAt time.Time  `json:"activates_at"`
+	RetiresAt   *time.Time `json:"retires_at,omitempty"`
+}
+
+// SigningKeySetType 签名公钥集合的签名内容类型，避免与授权文件等其他签名内容混用
+const SigningKeySetType = "signing_key_set"
+
+// SigningKeySet JWKS格式的签名公钥集合
+// 下发给客户端时由根密钥签名，并列出已作废的密钥ID，客户端不信任本地缓存中未签名的密钥状态
+type SigningKeySet struct {
+	Type     string          `json:"typ,omitempty"`
+	Keys     []SigningKeyJWK `json:"keys"`
+	Revoked  []string        `json:"revoked,omitempty"`   // 已作废的密钥ID
+	IssuedAt *time.Time      `json:"issued_at,omitempty"` // 签名时间
+}
+
+// SignedSigningKeySet 根密钥签名的签名公钥集合，与离线授权文件使用相同的签名封装
+type SignedSigningKeySet = LicenseFile
@@ -31,6 +31,22 @@ const (
 	ConfigGroupBackup    = "backup"    // 备份配置
 	ConfigGroupIntegration = "integration" // 集成配置
 	ConfigGroupNotification = "notification" // 通知配置
+	ConfigGroupLicense      = "license"      // 授权配置
+)
+
+// 授权相关配置项
+const (
+	ConfigLicenseTransferLimit      = "license.transferLimit"      // 统计周期内允许的转移次数
+	ConfigLicenseTransferPeriodDays = "license.transferPeriodDays" // 转移次数统计周期(天)
+	ConfigLicenseGracePeriodDays    = "license.gracePeriodDays"    // 到期后的宽限期(天)，可按类型覆盖，如license.gracePeriodDays.trial
+	ConfigLicenseKeyGroups          = "license.keyGroups"          // 授权码分组数
+	ConfigLicenseKeyGroupSize       = "license.keyGroupSize"       // 授权码每组字符数
+	ConfigFingerprintMinScore       = "license.fingerprintMinScore" // 设备指纹容错匹配的最低分，0表示只接受完全一致
+	ConfigFingerprintWeight         = "license.fingerprintWeight"   // 指纹组件权重，按组件配置，如license.fingerprintWeight.disk_id
+	ConfigFingerprintRebindDays     = "license.fingerprintRebindDays" // 同一激活两次容错换绑硬件的最小间隔(天)
+	ConfigLicenseTrialDays          = "license.trialDays"           // 自助试用授权的有效期(天)，0表示关闭自助试用
+	ConfigLicenseTrialWindowDays    = "license.trialWindowDays"     // 同一设备、网络或邮箱再次申请试用的间隔(天)
+	ConfigClockTamperTolerance      = "license.clockTamperTolerance" // 判定时钟回拨前允许的回退秒数，用于容忍正常的时钟校准
 )
 
 // DefaultSystemConfigs 默认系统配置
@@ -105,4 +121,81 @@ var DefaultSystemConfigs = []SystemConfig{
 		Group:       ConfigGroupNotification,
 		IsSystem:    true,
 	},
+	{
+		Name:        ConfigLicenseTransferLimit,
+		Value:       "3",
+		Description: "统计周期内每个授权允许的转移次数，0表示不限制",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseTransferPeriodDays,
+		Value:       "365",
+		Description: "授权转移次数统计周期(天)",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseGracePeriodDays,
+		Value:       "7",
+		Description: "授权到期后的默认宽限期(天)，0表示无宽限期",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseGracePeriodDays + "." + string(LicenseTypeTrial),
+		Value:       "0",
+		Description: "试用授权到期后的宽限期(天)",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseKeyGroups,
+		Value:       "4",
+		Description: "授权码分组数",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseKeyGroupSize,
+		Value:       "5",
+		Description: "授权码每组字符数，最后一个字符为校验字符",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigFingerprintMinScore,
+		Value:       "2",
+		Description: "设备指纹容错匹配的最低分，各组件权重默认为1，即硬盘、BIOS、主板三者匹配两个即视为同一台设备",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigFingerprintRebindDays,
+		Value:       "30",
+		Description: "同一激活容错匹配换绑硬件后，该天数内不再容错匹配，更换硬件的设备按新设备激活",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseTrialDays,
+		Value:       "14",
+		Description: "客户端自助申请的试用授权有效期(天)，0表示关闭自助试用",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigLicenseTrialWindowDays,
+		Value:       "365",
+		Description: "同一设备、IP网段或邮箱在该天数内只能申请一次试用",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
+	{
+		Name:        ConfigClockTamperTolerance,
+		Value:       "300",
+		Description: "设备上报的本地时间回退超过该秒数时记录时钟回拨异常",
+		Group:       ConfigGroupLicense,
+		IsSystem:    true,
+	},
 }
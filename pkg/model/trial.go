@@ -0,0 +1,34 @@
+// model/trial.go
+package model
+
+import "time"
+
+// 试用记录状态
+const (
+	TrialStatusActive    = "active"    // 试用中或已到期
+	TrialStatusConverted = "converted" // 已转为正式授权
+)
+
+// LicenseTrial 客户端自助申请的试用记录，用于识别同一设备、网络或邮箱重复申请试用
+type LicenseTrial struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	ProductID   string     `json:"product_id" gorm:"type:varchar(191);index"`
+	LicenseID   string     `json:"license_id" gorm:"type:varchar(191);index"`
+	DeviceID    string     `json:"device_id" gorm:"type:varchar(191)"`
+	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(64);index"`
+	DiskID      string     `json:"disk_id" gorm:"type:varchar(191)"`
+	BIOS        string     `json:"bios" gorm:"column:bios;type:varchar(191)"`
+	Motherboard string     `json:"motherboard" gorm:"type:varchar(191)"`
+	IPAddress   string     `json:"ip_address" gorm:"type:varchar(50)"`
+	IPRange     string     `json:"ip_range" gorm:"type:varchar(50);index"` // IPv4按/24、IPv6按/64归并的网段
+	Email       string     `json:"email" gorm:"type:varchar(191);index"`
+	Status      string     `json:"status" gorm:"type:varchar(20)"`
+	ConvertedAt *time.Time `json:"converted_at,omitempty"`
+	ConvertedBy string     `json:"converted_by,omitempty" gorm:"type:varchar(191)"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (LicenseTrial) TableName() string {
+	return "license_trials"
+}
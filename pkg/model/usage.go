@@ -27,6 +27,64 @@ type UsageReport struct {
 	Records      []UsageRecord `json:"records"`
 }
 
+// MeteredUsage 按量计费授权的用量上报记录，同一授权下的幂等键只计量一次
+type MeteredUsage struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	LicenseID      string    `json:"license_id" gorm:"type:varchar(191);uniqueIndex:idx_usage_idempotency"`
+	DeviceID       string    `json:"device_id" gorm:"type:varchar(191);index"`
+	IdempotencyKey string    `json:"idempotency_key" gorm:"type:varchar(191);uniqueIndex:idx_usage_idempotency"`
+	Quantity       int64     `json:"quantity"`
+	RecordedAt     time.Time `json:"recorded_at" gorm:"index"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (MeteredUsage) TableName() string {
+	return "metered_usages"
+}
+
+// UsageBucket 按天汇总的用量，每个授权在每台设备上每天一条
+type UsageBucket struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	LicenseID   string    `json:"license_id" gorm:"type:varchar(191);uniqueIndex:idx_usage_bucket"`
+	DeviceID    string    `json:"device_id" gorm:"type:varchar(191);uniqueIndex:idx_usage_bucket"`
+	PeriodStart time.Time `json:"period_start" gorm:"uniqueIndex:idx_usage_bucket"`
+	Quantity    int64     `json:"quantity"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UsageBucket) TableName() string {
+	return "usage_buckets"
+}
+
+// UsagePeriodStat 单个统计周期的用量
+type UsagePeriodStat struct {
+	Period   string `json:"period"` // 日粒度为2006-01-02，月粒度为2006-01
+	Quantity int64  `json:"quantity"`
+}
+
+// DeviceUsageStat 单台设备的用量
+type DeviceUsageStat struct {
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	Quantity   int64  `json:"quantity"`
+}
+
+// LicenseUsageSummary 授权用量汇总
+type LicenseUsageSummary struct {
+	LicenseID   string            `json:"license_id"`
+	UsageLimit  int64             `json:"usage_limit"`
+	UsageCount  int64             `json:"usage_count"`
+	Quantity    int64             `json:"quantity"` // 查询区间内的用量
+	Periods     []UsagePeriodStat `json:"periods"`
+	Devices     []DeviceUsageStat `json:"devices"`
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     time.Time         `json:"end_time"`
+	Granularity string            `json:"granularity"`
+}
+
 // LogExportFormat 日志导出格式
 type LogExportFormat string
 
@@ -23,6 +23,7 @@ type User struct {
 	Password   string     `json:"-" gorm:"not null;type:varchar(191)"` // 密码不返回给前端
 	Salt       []byte     `json:"-"`                                   // 密码盐值
 	RoleID     string     `json:"role_id" gorm:"type:varchar(191)"`    // 关联角色ID
+	PartnerID  string     `json:"partner_id" gorm:"type:varchar(191)"` // 所属合作伙伴，为空表示内部用户
 	Status     UserStatus `json:"status" gorm:"type:varchar(20)"`      // true: 启用, false: 禁用
 	LastLogin  time.Time  `json:"last_login"`
 	CreateTime time.Time  `json:"create_time"`
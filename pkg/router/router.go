@@ -28,7 +28,7 @@ func SetupRouter() *gin.Engine {
 	// 处理前端路由
 	r.NoRoute(func(c *gin.Context) {
 		// 对于API路径，返回404
-		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") || strings.HasPrefix(c.Request.URL.Path, "/client/") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "API not found"})
 			return
 		}
@@ -39,6 +39,9 @@ func SetupRouter() *gin.Engine {
 	// 健康检查
 	r.GET("/health", handler.HealthCheck)
 
+	// 公开的签名公钥集合
+	r.GET("/.well-known/jwks.json", handler.GetSigningKeySet)
+
 	// 公开路由组
 	public := r.Group("/auth")
 	{
@@ -78,6 +81,7 @@ func SetupRouter() *gin.Engine {
 	// 需要认证的API路由组
 	api := r.Group("/api")
 	api.Use(authMiddleware)
+	api.Use(middleware.PartnerScope()) // 合作伙伴用户只能访问/api/partner下的接口
 	{
 		// 用户管理
 		api.GET("/users", handler.ListUsers)
@@ -142,6 +146,7 @@ func SetupRouter() *gin.Engine {
 		api.GET("/customers", handler.GetCustomers)
 		api.POST("/customers", handler.CreateCustomer)
 		api.GET("/customers/:id", handler.GetCustomerByID)
+		api.GET("/customers/:id/summary", handler.GetCustomerSummary) // 客户授权与设备汇总
 		api.PUT("/customers/:id", handler.UpdateCustomer)
 		api.DELETE("/customers/:id", handler.DeleteCustomer)
 
@@ -151,19 +156,90 @@ func SetupRouter() *gin.Engine {
 		api.GET("/products/:id", handler.GetProductByID)
 		api.PUT("/products/:id", handler.UpdateProduct)
 		api.DELETE("/products/:id", handler.DeleteProduct)
+		api.POST("/products/:id/client-key", handler.RegenerateProductClientKey) // 重新生成客户端密钥
+		api.GET("/products/:id/plans", handler.GetProductLicensePlans)  // 获取产品的授权方案
+		api.POST("/products/:id/plans", handler.CreateLicensePlan)      // 创建授权方案
+		api.GET("/plans/:id", handler.GetLicensePlan)                   // 获取授权方案详情
+		api.PUT("/plans/:id", handler.UpdateLicensePlan)                // 更新授权方案
+		api.DELETE("/plans/:id", handler.DeleteLicensePlan)             // 删除授权方案
+
+		// 授权组和标签
+		api.GET("/license-groups", handler.ListLicenseGroups)
+		api.POST("/license-groups", handler.CreateLicenseGroup)
+		api.POST("/license-groups/assign", handler.AssignLicenseToGroup)     // 将单个授权分配到组
+		api.GET("/license-groups/:id", handler.GetLicenseGroup)
+		api.PUT("/license-groups/:id", handler.UpdateLicenseGroup)
+		api.DELETE("/license-groups/:id", handler.DeleteLicenseGroup)
+		api.GET("/license-groups/:id/stats", handler.GetLicenseGroupStats)    // 授权组统计
+		api.POST("/license-groups/:id/licenses", handler.AssignLicensesToGroup) // 批量移入授权组
+		api.GET("/license-tags", handler.ListLicenseTags)
+		api.POST("/license-tags", handler.CreateLicenseTag)
+		api.POST("/license-tags/assign", handler.AddTagsToLicense) // 设置单个授权的标签
+		api.POST("/license-tags/bulk", handler.BulkTagLicenses)    // 批量添加或移除标签
+		api.PUT("/license-tags/:id", handler.UpdateLicenseTag)
+		api.DELETE("/license-tags/:id", handler.DeleteLicenseTag)
 
 		// 授权管理
 		api.GET("/licenses/stats", handler.GetLicenseStats)
 		api.GET("/licenses/generate-key", handler.GenerateLicenseKey)
+		api.GET("/licenses/public-key", handler.GetLicensePublicKey) // 获取离线授权校验公钥
+		api.GET("/signing-keys", handler.ListSigningKeys)                 // 获取签名密钥
+		api.POST("/signing-keys/rotate", handler.RotateSigningKey)        // 轮换签名密钥
+		api.POST("/signing-keys/:id/retire", handler.RetireSigningKey)    // 停用签名密钥
+		api.POST("/signing-keys/:id/revoke", handler.RevokeSigningKey)    // 作废签名密钥
+		api.GET("/licenses/revocations", handler.GetRevocationEntries) // 获取吊销列表变更记录
 		api.POST("/licenses/export", handler.ExportLicenses)
+		api.POST("/licenses/import", handler.ImportLicenses) // 导入授权，dry_run=true时只返回校验报告，legacy_codes=true时接受其他系统的授权码
 		api.POST("/licenses/reset-filters", handler.ResetLicenseFilters)
 		api.POST("/licenses/batch-generate", handler.BatchGenerateLicense)
+		api.POST("/licenses/batch-renew", handler.BatchRenewLicenses) // 按授权组或标签批量续期
+		api.POST("/licenses/from-plan", handler.CreateLicenseFromPlan) // 按授权方案创建授权
+		api.GET("/licenses/search", handler.SearchLicenses) // 按过滤表达式搜索授权，游标分页
 		api.GET("/licenses", handler.ListLicenses)
 		api.POST("/licenses", handler.CreateLicense)
 		api.GET("/licenses/:id", handler.GetLicense)
 		api.PUT("/licenses/:id", handler.UpdateLicense)
 		api.DELETE("/licenses/:id", handler.DeleteLicense)
 		api.GET("/licenses/:id/activations", handler.GetLicenseActivations) // 获取许可证激活记录
+		api.POST("/licenses/:id/offline-activations", handler.CreateOfflineActivation) // 上传离线激活请求文件
+		api.GET("/licenses/:id/leases", handler.GetLicenseLeases)           // 获取浮动授权当前租约
+		api.POST("/licenses/:id/transfer", handler.TransferLicense)         // 转移授权
+		api.GET("/licenses/:id/transfers", handler.GetLicenseTransfers)     // 获取授权转移记录
+		api.GET("/licenses/:id/usage", handler.GetLicenseUsage)             // 获取按量计费用量
+		api.GET("/licenses/:id/entitlements", handler.GetLicenseEntitlements) // 获取授权权益
+		api.GET("/licenses/:id/status-history", handler.GetLicenseStatusHistory) // 获取授权状态变更记录
+		api.POST("/licenses/:id/renew", handler.RenewLicense)               // 续期授权
+		api.GET("/licenses/:id/terms", handler.GetLicenseTerms)             // 获取授权有效期历史
+		api.POST("/licenses/:id/convert", handler.ConvertTrialLicense) // 试用授权转正式授权
+		api.GET("/license-trials", handler.ListLicenseTrials)         // 获取自助试用记录
+		api.GET("/licenses/:id/versions", handler.GetLicenseVersions)                      // 获取授权变更历史
+		api.POST("/licenses/:id/versions/:version/revert", handler.RevertLicenseVersion) // 回滚到指定版本
+		api.GET("/licenses/:id/file", handler.DownloadLicenseFile)          // 下载离线授权文件
+		api.PUT("/licenses/:id/activation-policy", handler.SetLicenseActivationPolicy) // 设置激活限制
+
+		// 合作伙伴管理路由
+		api.GET("/partners", handler.ListPartners)
+		api.POST("/partners", handler.CreatePartner)
+		api.GET("/partners/consumption", handler.GetPartnerConsumption) // 按合作伙伴查看配额消耗
+		api.GET("/partners/:id", handler.GetPartner)
+		api.PUT("/partners/:id", handler.UpdatePartner)
+		api.POST("/partners/:id/quotas", handler.AllocatePartnerQuota) // 调整合作伙伴配额
+		api.POST("/partners/:id/users", handler.AddPartnerUser)        // 添加合作伙伴用户
+
+		// 合作伙伴用户路由，只能访问本合作伙伴及其下级的数据
+		partner := api.Group("/partner")
+		{
+			partner.GET("/quotas", handler.PartnerGetQuotas)                       // 查看自己的配额
+			partner.GET("/consumption", handler.PartnerGetConsumption)             // 查看自己及下级的配额消耗
+			partner.GET("/sub-partners", handler.PartnerListSubPartners)           // 查看下级合作伙伴
+			partner.POST("/sub-partners", handler.PartnerCreateSubPartner)         // 创建下级合作伙伴
+			partner.POST("/sub-partners/:id/quotas", handler.PartnerDelegateQuota) // 向下级分配配额
+			partner.GET("/customers", handler.PartnerListCustomers)                // 查看客户
+			partner.POST("/customers", handler.PartnerCreateCustomer)              // 创建客户
+			partner.GET("/licenses", handler.PartnerListLicenses)                  // 查看签发的授权
+			partner.POST("/licenses", handler.PartnerCreateLicenses)               // 为客户签发授权
+			partner.GET("/licenses/:id", handler.PartnerGetLicense)                // 查看授权详情
+		}
 
 		// 设备管理路由
 		devices := api.Group("/devices")
@@ -220,6 +296,23 @@ func SetupRouter() *gin.Engine {
 		}
 	}
 
+	// 客户端授权API (使用产品客户端密钥认证)
+	client := r.Group("/client/v1")
+	client.Use(middleware.ClientKeyAuth())
+	{
+		client.POST("/activate", handler.ClientActivate)     // 激活授权
+		client.POST("/trial", handler.ClientStartTrial)      // 申请试用授权
+		client.POST("/verify", handler.ClientVerify)         // 校验授权
+		client.POST("/heartbeat", handler.ClientHeartbeat)   // 心跳
+		client.POST("/deactivate", handler.ClientDeactivate) // 停用授权
+		client.POST("/checkout", handler.ClientCheckout)     // 签出浮动授权租约
+		client.POST("/release", handler.ClientRelease)       // 归还浮动授权租约
+		client.POST("/usage", handler.ClientReportUsage)     // 上报按量计费用量
+		client.POST("/entitlements/check", handler.ClientCheckEntitlement) // 查询授权权益
+		client.GET("/revocations", handler.ClientGetRevocations) // 获取签名的吊销列表
+		client.GET("/keys", handler.ClientGetSigningKeys)        // 获取签名公钥集合
+	}
+
 	// 系统初始化相关API (不需要认证)
 	systemInit := r.Group("/api/system")
 	{
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"LVerity/pkg/service"
+	"log"
+	"time"
+)
+
+// licenseExpiryInterval 授权到期状态检查间隔
+const licenseExpiryInterval = 10 * time.Minute
+
+// StartLicenseExpiry 启动授权到期状态任务，定期将到期授权转入宽限期或过期
+func StartLicenseExpiry() {
+	go func() {
+		processLicenseExpiry()
+
+		ticker := time.NewTicker(licenseExpiryInterval)
+		for range ticker.C {
+			processLicenseExpiry()
+		}
+	}()
+}
+
+// processLicenseExpiry 推进授权到期状态
+func processLicenseExpiry() {
+	graced, expired, err := service.ProcessLicenseExpiry()
+	if err != nil {
+		log.Printf("Error processing license expiry: %v", err)
+		return
+	}
+	if graced > 0 || expired > 0 {
+		log.Printf("License expiry: %d entered grace period, %d expired", graced, expired)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// activationDeniedAlert 违反激活限制时创建的告警标题
+const activationDeniedAlert = "activation_denied"
+
+var (
+	locationResolver   = GetLocationFromIP
+	locationResolverMu sync.RWMutex
+)
+
+// SetLocationResolver 替换按IP查询地理位置的实现，如接入本地GeoIP库；传入nil时恢复默认实现
+func SetLocationResolver(resolver func(ip string) (*model.Location, error)) {
+	locationResolverMu.Lock()
+	defer locationResolverMu.Unlock()
+	if resolver == nil {
+		resolver = GetLocationFromIP
+	}
+	locationResolver = resolver
+}
+
+// resolveLocation 按IP查询地理位置
+func resolveLocation(ip string) (*model.Location, error) {
+	locationResolverMu.RLock()
+	resolver := locationResolver
+	locationResolverMu.RUnlock()
+	return resolver(ip)
+}
+
+// ValidateActivationPolicy 校验激活限制策略中的网段、时段和时区格式
+func ValidateActivationPolicy(policy *model.ActivationPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, cidr := range policy.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			return fmt.Errorf("invalid cidr %q: %v", cidr, err)
+		}
+	}
+	for _, hours := range policy.AllowedHours {
+		if _, _, err := parseHourRange(hours); err != nil {
+			return err
+		}
+	}
+	if policy.Timezone != "" {
+		if _, err := time.LoadLocation(policy.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %v", policy.Timezone, err)
+		}
+	}
+	return nil
+}
+
+// parseHourRange 解析HH:MM-HH:MM格式的时段，返回起止时间距零点的分钟数
+func parseHourRange(hours string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(hours), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid hour range %q, expected HH:MM-HH:MM", hours)
+	}
+	var minutes [2]int
+	for i, part := range parts {
+		clock := strings.Split(strings.TrimSpace(part), ":")
+		if len(clock) != 2 {
+			return 0, 0, fmt.Errorf("invalid hour range %q, expected HH:MM-HH:MM", hours)
+		}
+		hour, err := strconv.Atoi(clock[0])
+		if err != nil || hour < 0 || hour > 24 {
+			return 0, 0, fmt.Errorf("invalid hour in %q", hours)
+		}
+		minute, err := strconv.Atoi(clock[1])
+		if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+			return 0, 0, fmt.Errorf("invalid minute in %q", hours)
+		}
+		minutes[i] = hour*60 + minute
+	}
+	return minutes[0], minutes[1], nil
+}
+
+// effectiveActivationPolicy 获取授权生效的激活限制，授权未设置时使用其授权方案的策略
+func effectiveActivationPolicy(license *model.License) (*model.ActivationPolicy, error) {
+	if !license.ActivationPolicy.IsEmpty() {
+		return license.ActivationPolicy, nil
+	}
+	if license.PlanID == "" {
+		return nil, nil
+	}
+	plan, err := GetLicensePlan(license.PlanID)
+	if err != nil {
+		if errors.Is(err, ErrLicensePlanNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if plan.ActivationPolicy.IsEmpty() {
+		return nil, nil
+	}
+	return plan.ActivationPolicy, nil
+}
+
+// evaluateActivationPolicy 按IP网段、时段和国家依次校验，返回拒绝原因，允许时返回空字符串
+// 设置了国家限制但无法确定所在国家时拒绝，出口管制场景下宁可误拦也不放行
+func evaluateActivationPolicy(policy *model.ActivationPolicy, ipAddress string, now time.Time) (string, *model.Location) {
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+
+	if len(policy.AllowedCIDRs) > 0 {
+		if ip == nil {
+			return "client ip address could not be determined", nil
+		}
+		allowed := false
+		for _, cidr := range policy.AllowedCIDRs {
+			if _, network, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil && network.Contains(ip) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("ip address %s is not in an allowed network", ip), nil
+		}
+	}
+
+	if len(policy.AllowedHours) > 0 {
+		loc := time.UTC
+		if policy.Timezone != "" {
+			if l, err := time.LoadLocation(policy.Timezone); err == nil {
+				loc = l
+			}
+		}
+		local := now.In(loc)
+		minute := local.Hour()*60 + local.Minute()
+		allowed := false
+		for _, hours := range policy.AllowedHours {
+			start, end, err := parseHourRange(hours)
+			if err != nil {
+				continue
+			}
+			if (start <= end && minute >= start && minute < end) ||
+				(start > end && (minute >= start || minute < end)) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("activation is not allowed at %s (%s)", local.Format("15:04"), loc), nil
+		}
+	}
+
+	if len(policy.AllowedCountries) == 0 && len(policy.DeniedCountries) == 0 {
+		return "", nil
+	}
+	if ip == nil {
+		return "client ip address could not be determined", nil
+	}
+	location, err := resolveLocation(ip.String())
+	if err != nil || location == nil || (location.Country == "" && location.CountryCode == "") {
+		return "client location could not be determined", location
+	}
+	matches := func(countries []string) bool {
+		for _, country := range countries {
+			country = strings.TrimSpace(country)
+			if strings.EqualFold(country, location.Country) || strings.EqualFold(country, location.CountryCode) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(policy.DeniedCountries) || (len(policy.AllowedCountries) > 0 && !matches(policy.AllowedCountries)) {
+		return fmt.Sprintf("activation is not allowed in %s", location.Country), location
+	}
+	return "", location
+}
+
+// checkActivationPolicy 在激活、签出和心跳时校验授权的激活限制
+// 违反时为设备创建告警，并返回带拒绝原因的ACTIVATION_DENIED错误
+func checkActivationPolicy(license *model.License, device *model.Device, ipAddress string, action string) error {
+	policy, err := effectiveActivationPolicy(license)
+	if err != nil || policy == nil {
+		return err
+	}
+	reason, location := evaluateActivationPolicy(policy, ipAddress, time.Now())
+	if reason == "" {
+		return nil
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"license_id": license.ID,
+		"action":     action,
+		"ip_address": ipAddress,
+		"location":   location,
+		"reason":     reason,
+	})
+	if _, err := CreateAlert(device.ID, activationDeniedAlert, model.AlertLevelWarning,
+		fmt.Sprintf("%s of license %s denied: %s", action, license.Code, reason), string(metadata)); err != nil {
+		log.Printf("Failed to create activation alert for device %s: %v", device.ID, err)
+	}
+	LogOperation("", "", "activation_denied", "license", license.ID, map[string]string{
+		"device_id":  device.ID,
+		"action":     action,
+		"ip_address": ipAddress,
+		"reason":     reason,
+	})
+	return newClientError(model.ClientCodeActivationDenied, "%s", reason)
+}
+
+// SetLicenseActivationPolicy 设置授权的激活限制，policy为空时清除，改用授权方案的策略
+func SetLicenseActivationPolicy(licenseID string, policy *model.ActivationPolicy, operatorID, operatorName string) (*model.License, error) {
+	if err := ValidateActivationPolicy(policy); err != nil {
+		return nil, err
+	}
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if !policy.IsEmpty() {
+		value = policy
+	}
+	err = trackLicenseChange(license.ID, &model.LicenseVersion{
+		Action:       model.LicenseChangePolicy,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+			Updates(map[string]interface{}{
+				"activation_policy": value,
+				"updated_at":        time.Now(),
+				"updated_by":        operatorID,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update activation policy: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "update_activation_policy", "license", license.ID, policy)
+	return GetLicenseByID(license.ID)
+}
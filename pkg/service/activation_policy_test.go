@@ -0,0 +1,121 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestLocation 将所有IP解析到指定国家，测试结束后恢复默认实现
+func setTestLocation(t *testing.T, country, countryCode string) {
+	SetLocationResolver(func(ip string) (*model.Location, error) {
+		if countryCode == "" {
+			return nil, errors.New("unknown location")
+		}
+		return &model.Location{Country: country, CountryCode: countryCode}, nil
+	})
+	t.Cleanup(func() { SetLocationResolver(nil) })
+}
+
+func TestEvaluateActivationPolicy(t *testing.T) {
+	setTestLocation(t, "Germany", "DE")
+	now := time.Date(2026, 1, 15, 23, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		policy  model.ActivationPolicy
+		ip      string
+		allowed bool
+	}{
+		{"allowed cidr", model.ActivationPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}}, "10.1.2.3", true},
+		{"outside cidr", model.ActivationPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}}, "192.168.1.1", false},
+		{"unknown ip", model.ActivationPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}}, "", false},
+		{"within hours", model.ActivationPolicy{AllowedHours: []string{"23:00-24:00"}}, "10.1.2.3", true},
+		{"across midnight", model.ActivationPolicy{AllowedHours: []string{"22:00-02:00"}}, "10.1.2.3", true},
+		{"outside hours", model.ActivationPolicy{AllowedHours: []string{"09:00-18:00"}}, "10.1.2.3", false},
+		{"hours in timezone", model.ActivationPolicy{AllowedHours: []string{"07:00-08:00"}, Timezone: "Asia/Shanghai"}, "10.1.2.3", true},
+		{"allowed country", model.ActivationPolicy{AllowedCountries: []string{"de"}}, "10.1.2.3", true},
+		{"allowed country by name", model.ActivationPolicy{AllowedCountries: []string{"Germany"}}, "10.1.2.3", true},
+		{"not allowed country", model.ActivationPolicy{AllowedCountries: []string{"FR"}}, "10.1.2.3", false},
+		{"denied over allowed", model.ActivationPolicy{AllowedCountries: []string{"DE"}, DeniedCountries: []string{"DE"}}, "10.1.2.3", false},
+	}
+	for _, tc := range cases {
+		reason, _ := evaluateActivationPolicy(&tc.policy, tc.ip, now)
+		assert.Equal(t, tc.allowed, reason == "", "%s: %s", tc.name, reason)
+	}
+
+	// 无法确定所在国家时拒绝
+	setTestLocation(t, "", "")
+	reason, _ := evaluateActivationPolicy(&model.ActivationPolicy{DeniedCountries: []string{"DE"}}, "10.1.2.3", now)
+	assert.Equal(t, "client location could not be determined", reason)
+}
+
+func TestValidateActivationPolicy(t *testing.T) {
+	assert.NoError(t, ValidateActivationPolicy(nil))
+	assert.NoError(t, ValidateActivationPolicy(&model.ActivationPolicy{
+		AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"},
+		AllowedHours: []string{"09:00-18:00", "22:00-24:00"},
+		Timezone:     "Europe/Berlin",
+	}))
+	for _, policy := range []model.ActivationPolicy{
+		{AllowedCIDRs: []string{"bad"}},
+		{AllowedHours: []string{"09:00"}},
+		{AllowedHours: []string{"25:00-26:00"}},
+		{AllowedHours: []string{"24:30-01:00"}},
+		{Timezone: "Mars/Base"},
+	} {
+		assert.Error(t, ValidateActivationPolicy(&policy))
+	}
+}
+
+func TestClientActivationPolicy(t *testing.T) {
+	setupTest(t)
+	setTestLocation(t, "Germany", "DE")
+	product := createTestProduct(t, "p-policy")
+	plan, err := CreateLicensePlan(product.ID, &LicensePlanRequest{
+		Name:             "Export Controlled",
+		Type:             model.LicenseTypeStandard,
+		DurationDays:     30,
+		MaxDevices:       1,
+		ActivationPolicy: &model.ActivationPolicy{DeniedCountries: []string{"DE"}},
+	}, "", "")
+	require.NoError(t, err)
+	license, err := CreateLicenseFromPlan(&LicenseFromPlanRequest{PlanID: plan.ID}, "", "")
+	require.NoError(t, err)
+	req := &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("001")}
+
+	// 授权方案禁止的国家不能激活，返回拒绝原因并创建告警
+	_, err = ClientActivate(product.ID, req, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeActivationDenied, clientErrorCode(err))
+	assert.Contains(t, err.Error(), "Germany")
+	var alerts int64
+	require.NoError(t, database.GetDB().Model(&model.Alert{}).Where("title = ?", activationDeniedAlert).Count(&alerts).Error)
+	assert.Equal(t, int64(1), alerts)
+
+	// 授权上的策略优先于授权方案
+	_, err = SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{AllowedCIDRs: []string{"127.0.0.0/8"}}, "", "")
+	require.NoError(t, err)
+	_, err = ClientActivate(product.ID, req, "127.0.0.1")
+	require.NoError(t, err)
+
+	// 心跳时同样校验
+	_, err = SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}}, "", "")
+	require.NoError(t, err)
+	_, err = ClientHeartbeat(product.ID, req, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeActivationDenied, clientErrorCode(err))
+	_, err = ClientHeartbeat(product.ID, req, "10.1.2.3")
+	require.NoError(t, err)
+
+	// 清除授权上的策略后恢复使用授权方案的策略
+	_, err = SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{}, "", "")
+	require.NoError(t, err)
+	_, err = ClientHeartbeat(product.ID, req, "10.1.2.3")
+	assert.Equal(t, model.ClientCodeActivationDenied, clientErrorCode(err))
+
+	_, err = SetLicenseActivationPolicy(license.ID, &model.ActivationPolicy{AllowedCIDRs: []string{"bad"}}, "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultHeartbeatInterval 设备未设置心跳频率时的默认心跳间隔(秒)
+const defaultHeartbeatInterval = 60
+
+// ClientError 客户端API业务错误，携带面向客户端的结果码
+type ClientError struct {
+	Code    model.ClientCode
+	Message string
+}
+
+func (e *ClientError) Error() string {
+	return e.Message
+}
+
+// newClientError 创建客户端API业务错误
+func newClientError(code model.ClientCode, format string, args ...interface{}) *ClientError {
+	return &ClientError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// resolveClientDevice 根据客户端上报的硬件信息查找设备，不存在时自动注册
+// 指纹不完全一致时按容错策略在已激活该授权的设备中查找，更换部分硬件的设备仍识别为原设备
+func resolveClientDevice(info *model.ClientDevice, license *model.License) (*model.Device, error) {
+	device, err := GetDeviceByHardwareInfo(info.DiskID, info.BIOS, info.Motherboard)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if license != nil {
+			if device, err = matchLicenseDevice(license, info); err != nil {
+				return nil, err
+			}
+		}
+		if device == nil {
+			if device, err = RegisterDevice(info.DiskID, info.BIOS, info.Motherboard, info.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if IsDeviceBlocked(device) {
+		return nil, newClientError(model.ClientCodeDeviceBlocked, "device is blocked")
+	}
+	return device, nil
+}
+
+// findClientLicense 根据客户端提交的授权码查找客户端密钥对应产品的授权
+// 数据库中存在的授权码总是有效，包括不带校验字符的早期授权码；不存在时输错的授权码按格式错误拒绝
+// 属于其他产品的授权按不存在处理，未关联产品的旧授权不限制产品
+func findClientLicense(productID, code string) (*model.License, error) {
+	license, err := FindLicenseByInputCode(code)
+	if err != nil {
+		if IsLicenseCodeFormatError(err) {
+			return nil, newClientError(model.ClientCodeInvalidLicenseKey, "%v", err)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newClientError(model.ClientCodeLicenseNotFound, "license not found")
+		}
+		return nil, err
+	}
+	if license.ProductID != "" && license.ProductID != productID {
+		return nil, newClientError(model.ClientCodeLicenseNotFound, "license not found")
+	}
+	return license, nil
+}
+
+// getClientLicense 根据授权码获取授权并检查其是否可用
+func getClientLicense(productID, code string) (*model.License, error) {
+	license, err := findClientLicense(productID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	switch license.Status {
+	case model.LicenseStatusDisabled, model.LicenseStatusRevoked, model.LicenseStatusTransferred:
+		return nil, newClientError(model.ClientCodeLicenseDisabled, "license is %s", license.Status)
+	}
+	// 宽限期内的授权仍可使用，由调用方返回宽限期结果
+	if _, expired := CheckLicenseExpiry(license, time.Now()); expired {
+		return nil, newClientError(model.ClientCodeLicenseExpired, "license has expired")
+	}
+
+	return license, nil
+}
+
+// resolveClientRequest 解析客户端请求中的设备和授权
+func resolveClientRequest(productID string, req *model.ClientLicenseRequest) (*model.License, *model.Device, error) {
+	license, err := getClientLicense(productID, req.Code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	device, err := resolveClientDevice(&req.Device, license)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkClientClock(device, req.ClientTime); err != nil {
+		return nil, nil, err
+	}
+	return license, device, nil
+}
+
+// buildClientLicenseData 构造返回给客户端的授权信息
+// 按设备授权返回该激活记录的授权文件，浮动授权返回随租约到期的授权文件
+func buildClientLicenseData(license *model.License, device *model.Device) (*model.ClientLicenseData, error) {
+	fingerprint := utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard)
+
+	heartbeatInterval := device.HeartbeatRate
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = defaultHeartbeatInterval
+	}
+
+	data := &model.ClientLicenseData{
+		LicenseID:   license.ID,
+		Code:        license.Code,
+		Type:        license.Type,
+		Status:      license.Status,
+		Features:    license.Features,
+		MaxDevices:  license.MaxDevices,
+		UsedDevices: license.UsedDevices,
+		StartTime:   license.StartTime,
+		ExpireTime:  license.ExpireTime,
+		DeviceID:    device.ID,
+		SeatMode:    license.SeatMode,
+	}
+	data.InGrace, _ = CheckLicenseExpiry(license, time.Now())
+	if data.InGrace {
+		data.GraceExpireTime = LicenseGraceExpireTime(license)
+	}
+
+	if IsFloatingLicense(license) {
+		lease, err := GetActiveLease(license.ID, device.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, newClientError(model.ClientCodeLeaseNotFound, "no active lease for this device")
+			}
+			return nil, err
+		}
+		if data.LicenseFile, err = SignLeaseFile(license, fingerprint, lease.ExpiresAt); err != nil {
+			return nil, err
+		}
+		data.LeaseExpiresAt = &lease.ExpiresAt
+
+		// 心跳间隔不超过租约时长的三分之一，保证租约能及时续约
+		if maxInterval := int(leaseDuration(license).Seconds()) / 3; maxInterval > 0 && heartbeatInterval > maxInterval {
+			heartbeatInterval = maxInterval
+		}
+	} else {
+		activation, err := GetActiveActivation(license.ID, device.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, newClientError(model.ClientCodeLicenseNotActivated, "license is not activated on this device")
+			}
+			return nil, err
+		}
+		if data.LicenseFile, err = GetActivationLicenseFile(license, activation, fingerprint); err != nil {
+			return nil, err
+		}
+	}
+
+	data.HeartbeatInterval = heartbeatInterval
+	signedTime, err := SignServerTime(license.ProductID, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	data.SignedServerTime = signedTime
+	return data, nil
+}
+
+// ClientActivate 客户端激活授权，占用一个设备名额；已在该设备上激活时直接返回
+func ClientActivate(productID string, req *model.ClientLicenseRequest, ipAddress string) (*model.ClientLicenseData, error) {
+	license, device, err := resolveClientRequest(productID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActivationPolicy(license, device, ipAddress, "activate"); err != nil {
+		return nil, err
+	}
+	if IsFloatingLicense(license) {
+		return nil, newClientError(model.ClientCodeInvalidRequest, "floating license must be checked out instead of activated")
+	}
+	// 宽限期内只允许已激活的设备继续使用
+	if inGrace, _ := CheckLicenseExpiry(license, time.Now()); inGrace {
+		if _, err := GetActiveActivation(license.ID, device.ID); err != nil {
+			return nil, newClientError(model.ClientCodeLicenseExpired, "license is in its grace period and cannot be activated on new devices")
+		}
+		return buildClientLicenseData(license, device)
+	}
+
+	if _, err := ActivateLicense(license.Code, device.ID, ipAddress); err != nil {
+		if errors.Is(err, ErrDeviceLimitReached) {
+			return nil, newClientError(model.ClientCodeDeviceLimitReached, "license has reached its device limit of %d", licenseSeats(license))
+		}
+		return nil, err
+	}
+	if license, err = GetLicenseByCode(license.Code); err != nil {
+		return nil, err
+	}
+
+	return buildClientLicenseData(license, device)
+}
+
+// ClientCheckout 客户端签出浮动授权租约
+func ClientCheckout(productID string, req *model.ClientLicenseRequest, ipAddress string) (*model.ClientLicenseData, error) {
+	license, device, err := resolveClientRequest(productID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActivationPolicy(license, device, ipAddress, "checkout"); err != nil {
+		return nil, err
+	}
+	if !IsFloatingLicense(license) {
+		return nil, newClientError(model.ClientCodeInvalidRequest, "license is not a floating license")
+	}
+	if inGrace, _ := CheckLicenseExpiry(license, time.Now()); inGrace {
+		if _, err := GetActiveLease(license.ID, device.ID); err != nil {
+			return nil, newClientError(model.ClientCodeLicenseExpired, "license is in its grace period and cannot be checked out on new devices")
+		}
+		return ClientHeartbeat(productID, req, ipAddress)
+	}
+
+	if _, err := CheckoutLease(license.Code, device.ID, ipAddress); err != nil {
+		if errors.Is(err, ErrDeviceLimitReached) {
+			return nil, newClientError(model.ClientCodeDeviceLimitReached, "all %d floating seats are in use", licenseSeats(license))
+		}
+		return nil, err
+	}
+	if license, err = GetLicenseByCode(license.Code); err != nil {
+		return nil, err
+	}
+
+	return buildClientLicenseData(license, device)
+}
+
+// ClientVerify 客户端校验授权是否在该设备上有效
+func ClientVerify(productID string, req *model.ClientLicenseRequest) (*model.ClientLicenseData, error) {
+	license, device, err := resolveClientRequest(productID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildClientLicenseData(license, device)
+}
+
+// ClientHeartbeat 客户端心跳，校验授权及其激活限制并刷新设备心跳时间，浮动授权同时续约租约
+func ClientHeartbeat(productID string, req *model.ClientLicenseRequest, ipAddress string) (*model.ClientLicenseData, error) {
+	license, device, err := resolveClientRequest(productID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActivationPolicy(license, device, ipAddress, "heartbeat"); err != nil {
+		return nil, err
+	}
+
+	if IsFloatingLicense(license) {
+		if _, err := RenewLease(license.Code, device.ID); err != nil {
+			if errors.Is(err, ErrLeaseNotFound) {
+				return nil, newClientError(model.ClientCodeLeaseNotFound, "no active lease for this device")
+			}
+			return nil, err
+		}
+	}
+
+	data, err := buildClientLicenseData(license, device)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := UpdateDeviceHeartbeat(data.DeviceID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ClientDeactivate 客户端停用授权，释放该设备占用的名额
+func ClientDeactivate(productID string, req *model.ClientLicenseRequest) error {
+	license, err := findClientLicense(productID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	device, err := resolveClientDevice(&req.Device, license)
+	if err != nil {
+		return err
+	}
+	if IsFloatingLicense(license) {
+		return newClientError(model.ClientCodeInvalidRequest, "floating license must be released instead of deactivated")
+	}
+
+	if err := DeactivateLicense(license.Code, device.ID); err != nil {
+		if errors.Is(err, ErrLicenseNotActivated) {
+			return newClientError(model.ClientCodeLicenseNotActivated, "license is not activated on this device")
+		}
+		return err
+	}
+	return nil
+}
+
+// ClientRelease 客户端归还浮动授权租约
+func ClientRelease(productID string, req *model.ClientLicenseRequest) error {
+	license, err := findClientLicense(productID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	device, err := resolveClientDevice(&req.Device, license)
+	if err != nil {
+		return err
+	}
+
+	if err := ReleaseLease(license.Code, device.ID); err != nil {
+		if errors.Is(err, ErrLeaseNotFound) {
+			return newClientError(model.ClientCodeLeaseNotFound, "no active lease for this device")
+		}
+		return err
+	}
+	return nil
+}
+
+// ClientReportUsage 客户端上报按量计费用量，设备需已激活授权或持有租约
+func ClientReportUsage(productID string, req *model.ClientUsageRequest) (*model.ClientUsageData, error) {
+	license, device, err := resolveClientRequest(productID, &model.ClientLicenseRequest{Code: req.Code, Device: req.Device})
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureClientSeat(license, device); err != nil {
+		return nil, err
+	}
+
+	usage, duplicate, err := ReportUsage(license.ID, device.ID, req.IdempotencyKey, req.Quantity)
+	if err != nil {
+		if errors.Is(err, ErrUsageLimitReached) {
+			return nil, newClientError(model.ClientCodeUsageLimitReached, "license usage limit of %d reached", license.UsageLimit)
+		}
+		return nil, err
+	}
+
+	if license, err = GetLicenseByID(license.ID); err != nil {
+		return nil, err
+	}
+
+	remaining := int64(-1)
+	if license.UsageLimit > 0 {
+		remaining = license.UsageLimit - license.UsageCount
+	}
+	return &model.ClientUsageData{
+		LicenseID:  license.ID,
+		UsageID:    usage.ID,
+		Quantity:   usage.Quantity,
+		UsageCount: license.UsageCount,
+		UsageLimit: license.UsageLimit,
+		Remaining:  remaining,
+		Duplicate:  duplicate,
+	}, nil
+}
+
+// ClientCheckEntitlement 客户端查询授权权益，指定功能时返回该功能是否开启及其数量限制
+func ClientCheckEntitlement(productID string, req *model.ClientEntitlementRequest) (*model.ClientEntitlementData, error) {
+	license, device, err := resolveClientRequest(productID, &model.ClientLicenseRequest{Code: req.Code, Device: req.Device})
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureClientSeat(license, device); err != nil {
+		return nil, err
+	}
+
+	entitlements, err := GetLicenseEntitlements(license)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.ClientEntitlementData{
+		LicenseID:    license.ID,
+		Entitlements: entitlements,
+	}
+	if req.Feature != "" {
+		data.Entitlement = FindEntitlement(entitlements, req.Feature)
+	}
+	return data, nil
+}
+
+// ensureClientSeat 检查设备是否已激活授权或持有浮动授权租约
+func ensureClientSeat(license *model.License, device *model.Device) error {
+	if IsFloatingLicense(license) {
+		if _, err := GetActiveLease(license.ID, device.ID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newClientError(model.ClientCodeLeaseNotFound, "no active lease for this device")
+			}
+			return err
+		}
+		return nil
+	}
+
+	if _, err := GetActiveActivation(license.ID, device.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newClientError(model.ClientCodeLicenseNotActivated, "license is not activated on this device")
+		}
+		return err
+	}
+	return nil
+}
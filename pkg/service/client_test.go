@@ -0,0 +1,43 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientKeyProductScope(t *testing.T) {
+	setupTest(t)
+	productA := createTestProduct(t, "p-a")
+	productB := createTestProduct(t, "p-b")
+
+	license, err := GenerateProductLicense(productB.ID, model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	req := &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("001")}
+
+	// 其他产品的客户端密钥不能使用该授权
+	_, err = ClientActivate(productA.ID, req, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+	_, err = ClientCheckout(productA.ID, req, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+	_, err = ClientVerify(productA.ID, req)
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+	err = ClientDeactivate(productA.ID, req)
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+	_, err = ClientCheckEntitlement(productA.ID, &model.ClientEntitlementRequest{Code: license.Code, Device: req.Device})
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.UsedDevices)
+
+	// 所属产品的客户端密钥可以正常激活
+	data, err := ClientActivate(productB.ID, req, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, data.LicenseID)
+	_, err = ClientHeartbeat(productA.ID, req, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeLicenseNotFound, clientErrorCode(err))
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultClockTamperTolerance 未配置时判定时钟回拨前允许的回退秒数
+const defaultClockTamperTolerance = 300
+
+// SignServerTime 使用产品当前的签名密钥签名服务端时间，绑定设备指纹，客户端据此维护不早于服务端时间的可信时间
+func SignServerTime(productID string, fingerprint string) (*model.LicenseFile, error) {
+	_, privateKey, err := selectSigningKey(productID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.SignServerTime(&model.ServerTimeClaims{
+		Type:        model.ServerTimeClaimsType,
+		Time:        time.Now(),
+		Fingerprint: fingerprint,
+	}, privateKey)
+}
+
+// checkClientClock 比较设备上报的本地时间与此前上报过的最晚时间
+// 回退超过容忍范围时记录clock_tamper异常行为；设备记录的最晚时间只前进不后退
+func checkClientClock(device *model.Device, clientTime *time.Time) error {
+	if clientTime == nil || clientTime.IsZero() {
+		return nil
+	}
+	reported := clientTime.UTC()
+
+	tolerance := time.Duration(GetSystemConfigInt(model.ConfigClockTamperTolerance, defaultClockTamperTolerance)) * time.Second
+	if device.LastClientTime != nil && reported.Before(device.LastClientTime.Add(-tolerance)) {
+		if err := RecordAbnormalBehavior(device.ID, model.AbnormalBehaviorClockTamper,
+			"device clock is earlier than previously reported", "high",
+			map[string]interface{}{
+				"reported_time": reported,
+				"last_time":     device.LastClientTime,
+				"server_time":   time.Now().UTC(),
+				"rollback":      device.LastClientTime.Sub(reported).String(),
+			}); err != nil {
+			log.Printf("Failed to record clock tamper for device %s: %v", device.ID, err)
+		}
+		return nil
+	}
+
+	if err := database.GetDB().Model(&model.Device{}).
+		Where("id = ? AND (last_client_time IS NULL OR last_client_time < ?)", device.ID, reported).
+		Update("last_client_time", reported).Error; err != nil {
+		return fmt.Errorf("failed to update device client time: %v", err)
+	}
+	return nil
+}
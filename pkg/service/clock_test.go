@@ -0,0 +1,62 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockTamperDetection(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-clock")
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	req := &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("001")}
+	fingerprint := utils.GenerateFingerprint(req.Device.DiskID, req.Device.BIOS, req.Device.Motherboard)
+	_, err = ClientActivate(product.ID, req, "")
+	require.NoError(t, err)
+
+	// 每次校验都返回绑定设备的签名服务端时间
+	data, err := ClientVerify(product.ID, req)
+	require.NoError(t, err)
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	serverTime, err := utils.VerifyServerTime(data.SignedServerTime, publicKey, fingerprint)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), serverTime.Time, time.Minute)
+	_, err = utils.VerifyServerTime(data.SignedServerTime, publicKey, "other")
+	assert.ErrorIs(t, err, utils.ErrFingerprintMismatch)
+	_, err = utils.VerifyServerTime(data.LicenseFile, publicKey, fingerprint)
+	assert.Error(t, err)
+
+	// 容忍范围内的回退不记录
+	now := time.Now()
+	req.ClientTime = &now
+	_, err = ClientVerify(product.ID, req)
+	require.NoError(t, err)
+	skewed := now.Add(-time.Minute)
+	req.ClientTime = &skewed
+	_, err = ClientVerify(product.ID, req)
+	require.NoError(t, err)
+	behaviors, err := GetDeviceAbnormalBehaviors(data.DeviceID)
+	require.NoError(t, err)
+	assert.Empty(t, behaviors)
+
+	// 设备上报的时间早于此前上报的时间时记录clock_tamper，最晚时间不后退
+	rolledBack := now.Add(-2 * time.Hour)
+	req.ClientTime = &rolledBack
+	_, err = ClientVerify(product.ID, req)
+	require.NoError(t, err)
+	behaviors, err = GetDeviceAbnormalBehaviors(data.DeviceID)
+	require.NoError(t, err)
+	require.Len(t, behaviors, 1)
+	assert.Equal(t, model.AbnormalBehaviorClockTamper, behaviors[0].Type)
+	device, err := GetDevice(data.DeviceID)
+	require.NoError(t, err)
+	require.NotNil(t, device.LastClientTime)
+	assert.True(t, device.LastClientTime.Equal(now.UTC()))
+}
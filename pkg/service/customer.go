@@ -4,9 +4,16 @@ package service
 import (
 	"LVerity/pkg/database"
 	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
 	"fmt"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+var ErrCustomerHasLicenses = errors.New("customer still owns licenses")
+
 // CustomerService 客户服务接口
 type CustomerService interface {
 	GetCustomers() ([]model.Customer, error)
@@ -14,6 +21,8 @@ type CustomerService interface {
 	CreateCustomer(customer *model.Customer) error
 	UpdateCustomer(customer *model.Customer) error
 	DeleteCustomer(id string) error
+	GetCustomerSummary(id string) (*model.CustomerSummary, error)
+	ReassignLicenses(fromID string, toID string, operatorID, operatorName string) (int64, error)
 }
 
 // customerService 客户服务实现
@@ -58,10 +67,147 @@ func (s *customerService) UpdateCustomer(customer *model.Customer) error {
 	return nil
 }
 
-// DeleteCustomer 删除客户
+// DeleteCustomer 删除客户，客户名下仍有授权时拒绝删除，需先转移或删除其授权
 func (s *customerService) DeleteCustomer(id string) error {
+	var count int64
+	if err := database.DB.Model(&model.License{}).
+		Where("customer_id = ? AND deleted = ?", id, false).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("统计客户授权失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d", ErrCustomerHasLicenses, count)
+	}
+
 	if err := database.DB.Delete(&model.Customer{}, "id = ?", id).Error; err != nil {
 		return fmt.Errorf("删除客户失败: %w", err)
 	}
 	return nil
 }
+
+// ReassignLicenses 将客户名下未删除的授权改归另一客户，返回改归的授权数
+// 每个授权在流转链中记录一次改归，不重新签发授权
+func (s *customerService) ReassignLicenses(fromID string, toID string, operatorID, operatorName string) (int64, error) {
+	if fromID == toID {
+		return 0, errors.New("目标客户不能与原客户相同")
+	}
+	if _, err := s.GetCustomerByID(toID); err != nil {
+		return 0, err
+	}
+
+	var licenses []model.License
+	if err := database.DB.Where("customer_id = ? AND deleted = ?", fromID, false).Find(&licenses).Error; err != nil {
+		return 0, fmt.Errorf("获取客户授权失败: %w", err)
+	}
+	if len(licenses) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	transfers := make([]model.LicenseTransfer, len(licenses))
+	ids := make([]string, len(licenses))
+	for i := range licenses {
+		originID, err := getTransferOrigin(licenses[i].ID)
+		if err != nil {
+			return 0, err
+		}
+		ids[i] = licenses[i].ID
+		transfers[i] = model.LicenseTransfer{
+			ID:              utils.GenerateUUID(),
+			OriginLicenseID: originID,
+			LicenseID:       licenses[i].ID,
+			Type:            model.LicenseTransferReassign,
+			FromCustomerID:  fromID,
+			ToCustomerID:    toID,
+			Reason:          "客户删除，授权改归其他客户",
+			OperatorID:      operatorID,
+			OperatorName:    operatorName,
+			CreatedAt:       now,
+		}
+	}
+
+	var moved int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.License{}).
+			Where("id IN ? AND customer_id = ? AND deleted = ?", ids, fromID, false).
+			Updates(map[string]interface{}{"customer_id": toID, "updated_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("转移客户授权失败: %w", result.Error)
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return errors.New("客户授权已变更，请重试")
+		}
+		moved = result.RowsAffected
+		if err := tx.Create(&transfers).Error; err != nil {
+			return fmt.Errorf("记录授权转移失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	LogOperation(operatorID, operatorName, "reassign_licenses", "customer", fromID, map[string]interface{}{
+		"to_customer_id": toID,
+		"license_ids":    ids,
+	})
+	return moved, nil
+}
+
+// GetCustomerSummary 汇总客户名下的授权和已激活设备
+func (s *customerService) GetCustomerSummary(id string) (*model.CustomerSummary, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var licenses []model.License
+	if err := database.DB.Where("customer_id = ? AND deleted = ?", id, false).Find(&licenses).Error; err != nil {
+		return nil, fmt.Errorf("获取客户授权失败: %w", err)
+	}
+
+	summary := &model.CustomerSummary{
+		Customer:      customer,
+		LicenseCount:  int64(len(licenses)),
+		StatusCounts:  make(map[model.LicenseStatus]int64),
+		ProductCounts: make(map[string]int64),
+		Devices:       []model.CustomerDevice{},
+	}
+
+	now := time.Now()
+	licenseIDs := make([]string, 0, len(licenses))
+	for _, license := range licenses {
+		licenseIDs = append(licenseIDs, license.ID)
+		summary.StatusCounts[license.Status]++
+		summary.ProductCounts[license.ProductID]++
+
+		switch license.Status {
+		case model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive, model.LicenseStatusGrace:
+			summary.MaxDevices += int64(licenseSeats(&license))
+			summary.UsedDevices += int64(license.UsedDevices)
+			if license.ExpireTime.After(now) && license.ExpireTime.Before(now.AddDate(0, 0, 30)) {
+				summary.ExpiringSoon++
+			}
+		}
+	}
+	if len(licenseIDs) == 0 {
+		return summary, nil
+	}
+
+	if err := database.DB.Table("license_activations AS a").
+		Select("a.device_id, d.name AS device_name, d.status AS device_status, a.license_id, l.code AS license_code, a.status AS activation_status, a.activated_at, d.last_seen").
+		Joins("JOIN licenses l ON l.id = a.license_id").
+		Joins("LEFT JOIN devices d ON d.id = a.device_id").
+		Where("a.license_id IN ? AND a.status IN ?", licenseIDs, seatActivationStatuses).
+		Order("a.activated_at DESC").
+		Scan(&summary.Devices).Error; err != nil {
+		return nil, fmt.Errorf("获取客户设备失败: %w", err)
+	}
+
+	devices := make(map[string]bool)
+	for _, device := range summary.Devices {
+		devices[device.DeviceID] = true
+	}
+	summary.DeviceCount = len(devices)
+	return summary, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCustomerLicense(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-customer")
+	customer := createTestCustomer(t, "c-owner")
+
+	license, err := GenerateLicenseWithOptions(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{CustomerID: customer.ID})
+	require.NoError(t, err)
+
+	// 所属客户随授权记录一起写入
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, stored.CustomerID)
+	assert.Equal(t, product.ID, stored.ProductID)
+	assert.Empty(t, stored.GroupID)
+}
+
+func TestBackfillLicenseCustomers(t *testing.T) {
+	setupTest(t)
+	customer := createTestCustomer(t, "c-legacy")
+	legacy, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), customer.ID, nil, 0)
+	require.NoError(t, err)
+
+	// 早期版本在group_id中保存客户ID，迁移后改存customer_id
+	db := database.GetDB()
+	require.NoError(t, db.Exec("DELETE FROM migrations WHERE id = ?", "data_002_backfill_license_customers").Error)
+	require.NoError(t, database.Migrate(db))
+	stored, err := GetLicenseByID(legacy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, stored.CustomerID)
+	assert.Empty(t, stored.GroupID)
+
+	// 迁移只执行一次，之后group_id按授权组处理
+	grouped, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), customer.ID, nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+	stored, err = GetLicenseByID(grouped.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.CustomerID)
+	assert.Equal(t, customer.ID, stored.GroupID)
+}
+
+func TestResolveLegacyLicenseCustomer(t *testing.T) {
+	setupTest(t)
+	customer := createTestCustomer(t, "c-form")
+	group, err := CreateLicenseGroup("group", "", "")
+	require.NoError(t, err)
+
+	customerID, groupID, err := ResolveLegacyLicenseCustomer("", customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, customerID)
+	assert.Empty(t, groupID)
+
+	customerID, groupID, err = ResolveLegacyLicenseCustomer("", group.ID)
+	require.NoError(t, err)
+	assert.Empty(t, customerID)
+	assert.Equal(t, group.ID, groupID)
+
+	customerID, groupID, err = ResolveLegacyLicenseCustomer(customer.ID, group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, customerID)
+	assert.Equal(t, group.ID, groupID)
+}
+
+func TestReassignLicenses(t *testing.T) {
+	setupTest(t)
+	from := createTestCustomer(t, "c-from")
+	to := createTestCustomer(t, "c-to")
+	options := LicenseOptions{CustomerID: from.ID}
+	active, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0, options)
+	require.NoError(t, err)
+	deleted, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0, options)
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", deleted.ID).Update("deleted", true).Error)
+
+	moved, err := NewCustomerService().ReassignLicenses(from.ID, to.ID, "u-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), moved)
+
+	// 已删除的授权保留原客户
+	stored, err := GetLicenseByID(active.ID)
+	require.NoError(t, err)
+	assert.Equal(t, to.ID, stored.CustomerID)
+	stored, err = GetLicenseByID(deleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, from.ID, stored.CustomerID)
+
+	// 改归记录在流转链中
+	transfers, err := GetLicenseTransfers(active.ID)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, model.LicenseTransferReassign, transfers[0].Type)
+	assert.Equal(t, from.ID, transfers[0].FromCustomerID)
+	assert.Equal(t, to.ID, transfers[0].ToCustomerID)
+	assert.Equal(t, "admin", transfers[0].OperatorName)
+	transfers, err = GetLicenseTransfers(deleted.ID)
+	require.NoError(t, err)
+	assert.Empty(t, transfers)
+
+	var logs int64
+	require.NoError(t, database.GetDB().Model(&model.OperationLog{}).
+		Where("action = ? AND resource_id = ?", "reassign_licenses", from.ID).Count(&logs).Error)
+	assert.Equal(t, int64(1), logs)
+}
+
+func TestCustomerSummaryAndDelete(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-customer")
+	customers := NewCustomerService()
+	createTestCustomer(t, "customer-1")
+	createTestCustomer(t, "customer-2")
+
+	license, err := GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 0, 10), "", nil, 0)
+	require.NoError(t, err)
+	assert.Error(t, UpdateLicenseRelations(license.ID, "missing", product.ID))
+	require.NoError(t, UpdateLicenseRelations(license.ID, "customer-1", product.ID))
+	device, _ := registerTestDevice(t, "001")
+	_, err = ActivateLicense(license.Code, device.ID, "")
+	require.NoError(t, err)
+
+	summary, err := customers.GetCustomerSummary("customer-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.LicenseCount)
+	assert.Equal(t, int64(1), summary.ProductCounts[product.ID])
+	assert.Equal(t, int64(2), summary.MaxDevices)
+	assert.Equal(t, int64(1), summary.UsedDevices)
+	assert.Equal(t, int64(1), summary.ExpiringSoon)
+	assert.Equal(t, 1, summary.DeviceCount)
+	require.Len(t, summary.Devices, 1)
+	assert.Equal(t, license.Code, summary.Devices[0].LicenseCode)
+
+	licenses, total, err := ListLicenses("1", "10", "", "", "customer-1", product.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, licenses, 1)
+
+	// 名下仍有授权的客户不能删除，转移授权后可以删除
+	assert.ErrorIs(t, customers.DeleteCustomer("customer-1"), ErrCustomerHasLicenses)
+	moved, err := customers.ReassignLicenses("customer-1", "customer-2", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), moved)
+	require.NoError(t, customers.DeleteCustomer("customer-1"))
+}
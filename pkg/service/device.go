@@ -94,7 +94,7 @@ func BlockDevice(deviceID string) error {
 		return err
 	}
 
-	return nil
+	return RevokeDeviceFingerprint(deviceID, "device blocked")
 }
 
 // UnblockDevice 解除设备禁用
@@ -113,7 +113,7 @@ func UnblockDevice(deviceID string) error {
 		return err
 	}
 
-	return nil
+	return RestoreDeviceFingerprint(deviceID, "device unblocked")
 }
 
 // GetDevicesByStatus 获取指定状态的设备列表
@@ -499,7 +499,7 @@ func BlockDeviceWithReason(deviceID string, reason string) error {
 	if result.RowsAffected == 0 {
 		return errors.New("device not found")
 	}
-	return nil
+	return RevokeDeviceFingerprint(deviceID, reason)
 }
 
 // ActivateDevice 激活设备
@@ -566,6 +566,15 @@ func UnbindLicense(deviceID string) error {
 	if err != nil {
 		return err
 	}
+
+	// 停用设备上的激活，释放占用的名额
+	if device.LicenseID != "" {
+		if license, err := GetLicenseByID(device.LicenseID); err == nil {
+			if err := DeactivateLicense(license.Code, deviceID); err != nil && !errors.Is(err, ErrLicenseNotActivated) {
+				return err
+			}
+		}
+	}
 	
 	// 清除授权绑定信息
 	device.LicenseID = ""
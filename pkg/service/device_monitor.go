@@ -3,6 +3,7 @@ package service
 import (
 	"LVerity/pkg/database"
 	"LVerity/pkg/model"
+	"log"
 	"sync"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 const (
 	deviceOfflineThreshold = 30 * time.Minute
 	deviceCleanupInterval  = 1 * time.Hour
+	leaseReclaimInterval   = 1 * time.Minute
 )
 
 var (
@@ -57,11 +59,15 @@ func (dm *DeviceMonitor) Start() {
 	go func() {
 		ticker := time.NewTicker(deviceCleanupInterval)
 		defer ticker.Stop()
+		leaseTicker := time.NewTicker(leaseReclaimInterval)
+		defer leaseTicker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
 				dm.CleanupOfflineDevices()
+			case <-leaseTicker.C:
+				dm.ReclaimExpiredLeases()
 			case <-dm.stopChan:
 				return
 			}
@@ -149,6 +155,18 @@ func (dm *DeviceMonitor) CleanupOfflineDevices() {
 	}
 }
 
+// ReclaimExpiredLeases 回收超时未续约的浮动授权租约
+func (dm *DeviceMonitor) ReclaimExpiredLeases() {
+	count, err := ReclaimExpiredLeases()
+	if err != nil {
+		log.Printf("Failed to reclaim expired leases: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Reclaimed %d expired license leases", count)
+	}
+}
+
 // NewDeviceSession 创建新的设备会话
 func NewDeviceSession(deviceID string) *DeviceSession {
 	return &DeviceSession{
@@ -11,17 +11,9 @@ import (
 
 // MatchBlacklistRule 检查设备是否匹配黑名单规则
 func MatchBlacklistRule(device *model.Device, rule *model.BlacklistRule) (bool, error) {
-	var value string
-
 	// 根据规则类型获取对应的设备信息
-	switch rule.Type {
-	case "disk_id":
-		value = device.DiskID
-	case "bios":
-		value = device.BIOS
-	case "motherboard":
-		value = device.Motherboard
-	default:
+	value, ok := deviceComponentValue(device, rule.Type)
+	if !ok {
 		return false, fmt.Errorf("unknown rule type: %s", rule.Type)
 	}
 
@@ -57,6 +49,40 @@ func CompareDevices(device1, device2 *model.Device) []string {
 	return differences
 }
 
+// deviceComponentValue 获取设备指纹组件的取值
+func deviceComponentValue(device *model.Device, component string) (string, bool) {
+	switch component {
+	case model.FingerprintComponentDiskID:
+		return device.DiskID, true
+	case model.FingerprintComponentBIOS:
+		return device.BIOS, true
+	case model.FingerprintComponentMotherboard:
+		return device.Motherboard, true
+	}
+	return "", false
+}
+
+// MatchDeviceFingerprint 按容错策略逐个比较两个设备的指纹组件
+// 任一方为空的组件视为不匹配；权重为0的组件不计分但仍记录是否匹配
+func MatchDeviceFingerprint(stored, reported *model.Device, policy *model.FingerprintPolicy) *model.FingerprintMatch {
+	match := &model.FingerprintMatch{
+		Matched:   []string{},
+		Unmatched: []string{},
+		MinScore:  policy.MinScore,
+	}
+	for _, component := range model.FingerprintComponents {
+		storedValue, _ := deviceComponentValue(stored, component)
+		reportedValue, _ := deviceComponentValue(reported, component)
+		if storedValue != "" && storedValue == reportedValue {
+			match.Matched = append(match.Matched, component)
+			match.Score += policy.Weights[component]
+		} else {
+			match.Unmatched = append(match.Unmatched, component)
+		}
+	}
+	return match
+}
+
 // ValidateDeviceInfo 验证设备信息是否完整
 func ValidateDeviceInfo(device *model.Device) error {
 	if device.DiskID == "" {
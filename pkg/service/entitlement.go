@@ -0,0 +1,89 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GetLicenseEntitlements 获取授权的全部权益，合并同一客户名下同一产品的有效功能模块授权
+func GetLicenseEntitlements(license *model.License) ([]model.Entitlement, error) {
+	entitlements := licenseEntitlements(license)
+
+	if license.Type != model.LicenseTypeModule {
+		modules, err := getCustomerModuleLicenses(license.CustomerID, license.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range modules {
+			if modules[i].ID == license.ID {
+				continue
+			}
+			for key, addon := range licenseEntitlements(&modules[i]) {
+				if base, ok := entitlements[key]; ok {
+					entitlements[key] = utils.MergeEntitlement(base, addon)
+				} else {
+					entitlements[key] = addon
+				}
+			}
+		}
+	}
+
+	return utils.SortedEntitlements(entitlements), nil
+}
+
+// licenseEntitlements 解析单个授权的权益并记录来源
+func licenseEntitlements(license *model.License) map[string]model.Entitlement {
+	entitlements := utils.ParseEntitlements(license.Features)
+	for key, entitlement := range entitlements {
+		entitlement.Sources = []string{license.ID}
+		entitlements[key] = entitlement
+	}
+	return entitlements
+}
+
+// getCustomerModuleLicenses 获取客户名下指定产品当前有效的功能模块授权，其他产品的模块不能叠加
+func getCustomerModuleLicenses(customerID string, productID string) ([]model.License, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var licenses []model.License
+	if err := database.GetDB().
+		Where("customer_id = ? AND product_id = ? AND type = ? AND deleted = ?", customerID, productID, model.LicenseTypeModule, false).
+		Where("status IN ?", []model.LicenseStatus{model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive, model.LicenseStatusGrace}).
+		Where("start_time <= ?", now).
+		Order("created_at").
+		Find(&licenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get module licenses: %v", err)
+	}
+
+	// 宽限期内的模块授权仍然有效
+	valid := licenses[:0]
+	for i := range licenses {
+		if _, expired := CheckLicenseExpiry(&licenses[i], now); expired {
+			continue
+		}
+		if licenses[i].FeaturesStr != "" {
+			if err := json.Unmarshal([]byte(licenses[i].FeaturesStr), &licenses[i].Features); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal features: %v", err)
+			}
+		}
+		valid = append(valid, licenses[i])
+	}
+	return valid, nil
+}
+
+// FindEntitlement 在权益列表中查找功能，未授权时返回关闭状态的权益
+func FindEntitlement(entitlements []model.Entitlement, feature string) *model.Entitlement {
+	for i := range entitlements {
+		if entitlements[i].Key == feature {
+			return &entitlements[i]
+		}
+	}
+	return &model.Entitlement{Key: feature, Enabled: false, Sources: []string{}}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createModuleLicense 为客户创建功能模块授权
+func createModuleLicense(t *testing.T, customerID string, startTime, expireTime time.Time, features []string) *model.License {
+	license, err := GenerateLicenseWithOptions("", model.LicenseTypeModule, 0, startTime, expireTime, "", features, 0,
+		LicenseOptions{CustomerID: customerID})
+	require.NoError(t, err)
+	return license
+}
+
+func TestLicenseEntitlements(t *testing.T) {
+	setupTest(t)
+	createTestCustomer(t, "customer-1")
+	createTestCustomer(t, "customer-2")
+	now := time.Now()
+
+	base, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 1, now, now.AddDate(0, 1, 0), "", []string{"export_pdf", "max_users=50", "api=false"}, 0,
+		LicenseOptions{CustomerID: "customer-1"})
+	require.NoError(t, err)
+	module := createModuleLicense(t, "customer-1", now.Add(-time.Minute), now.AddDate(0, 1, 0), []string{"max_users=25", "api=true"})
+
+	// 未生效、已过期、其他客户和其他产品的模块授权不参与合并
+	createModuleLicense(t, "customer-1", now.AddDate(0, 0, 1), now.AddDate(0, 1, 0), []string{"max_users=100"})
+	createModuleLicense(t, "customer-1", now.AddDate(0, -2, 0), now.AddDate(0, -1, 0), []string{"max_users=100"})
+	createModuleLicense(t, "customer-2", now.Add(-time.Minute), now.AddDate(0, 1, 0), []string{"max_users=100", "reports"})
+	other := createTestProduct(t, "p-other")
+	_, err = GenerateLicenseWithOptions(other.ID, model.LicenseTypeModule, 0, now.Add(-time.Minute), now.AddDate(0, 1, 0), "", []string{"max_users=100", "reports"}, 0,
+		LicenseOptions{CustomerID: "customer-1"})
+	require.NoError(t, err)
+
+	stored, err := GetLicenseByID(base.ID)
+	require.NoError(t, err)
+	entitlements, err := GetLicenseEntitlements(stored)
+	require.NoError(t, err)
+
+	// 功能模块授权的数量限制累加到基础授权，开关以模块授权为准
+	users := FindEntitlement(entitlements, "max_users")
+	assert.True(t, users.Enabled)
+	require.NotNil(t, users.Limit)
+	assert.Equal(t, int64(75), *users.Limit)
+	assert.Equal(t, []string{base.ID, module.ID}, users.Sources)
+	assert.True(t, FindEntitlement(entitlements, "api").Enabled)
+	assert.True(t, FindEntitlement(entitlements, "export_pdf").Enabled)
+	assert.False(t, FindEntitlement(entitlements, "reports").Enabled)
+
+	// 模块授权本身只包含自己的权益
+	stored, err = GetLicenseByID(module.ID)
+	require.NoError(t, err)
+	entitlements, err = GetLicenseEntitlements(stored)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), *FindEntitlement(entitlements, "max_users").Limit)
+	assert.False(t, FindEntitlement(entitlements, "export_pdf").Enabled)
+}
+
+func TestModuleEntitlementsByCustomer(t *testing.T) {
+	setupTest(t)
+	createTestCustomer(t, "customer-1")
+	now := time.Now()
+
+	// 模块授权按客户关联，同一授权组但没有客户的授权不合并
+	base, err := GenerateLicense(model.LicenseTypeStandard, 1, now, now.AddDate(0, 1, 0), "customer-1", []string{"max_users=50"}, 0)
+	require.NoError(t, err)
+	module, err := GenerateLicense(model.LicenseTypeModule, 0, now.Add(-time.Minute), now.AddDate(0, 1, 0), "customer-1", []string{"max_users=25"}, 0)
+	require.NoError(t, err)
+	stored, err := GetLicenseByID(base.ID)
+	require.NoError(t, err)
+	entitlements, err := GetLicenseEntitlements(stored)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), *FindEntitlement(entitlements, "max_users").Limit)
+
+	require.NoError(t, UpdateLicenseRelations(base.ID, "customer-1", ""))
+	require.NoError(t, UpdateLicenseRelations(module.ID, "customer-1", ""))
+	stored, err = GetLicenseByID(base.ID)
+	require.NoError(t, err)
+	entitlements, err = GetLicenseEntitlements(stored)
+	require.NoError(t, err)
+	assert.Equal(t, int64(75), *FindEntitlement(entitlements, "max_users").Limit)
+
+	// 删除的模块授权不再合并
+	require.NoError(t, DeleteLicense(module.ID))
+	entitlements, err = GetLicenseEntitlements(stored)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), *FindEntitlement(entitlements, "max_users").Limit)
+}
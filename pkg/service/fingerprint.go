@@ -0,0 +1,127 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultFingerprintMinScore 未配置时的容错匹配最低分，各组件默认权重为1，即三选二
+const defaultFingerprintMinScore = 2
+
+// defaultFingerprintRebindDays 未配置时同一激活两次容错换绑的最小间隔(天)
+const defaultFingerprintRebindDays = 30
+
+// errFingerprintRebindLimited 激活在换绑间隔内已被并发请求换绑，事务回滚后按未匹配处理
+var errFingerprintRebindLimited = errors.New("fingerprint rebind is rate limited")
+
+// GetFingerprintPolicy 获取设备指纹容错匹配策略
+func GetFingerprintPolicy() *model.FingerprintPolicy {
+	policy := &model.FingerprintPolicy{
+		Weights:  make(map[string]int, len(model.FingerprintComponents)),
+		MinScore: GetSystemConfigInt(model.ConfigFingerprintMinScore, defaultFingerprintMinScore),
+	}
+	for _, component := range model.FingerprintComponents {
+		policy.Weights[component] = GetSystemConfigInt(model.ConfigFingerprintWeight+"."+component, 1)
+	}
+	return policy
+}
+
+// matchLicenseDevice 在已激活该授权的设备中查找与上报硬件容错匹配的设备
+// 找到时将设备的指纹组件更新为上报的值，并把匹配结果记录到激活记录上，更换单个硬件不会占用新的名额
+// 客户端标记为型号级信息的组件不参与匹配；同一激活在换绑间隔内只能容错换绑一次，避免同型号设备轮流顶替
+// 没有达到策略要求的设备时返回nil
+func matchLicenseDevice(license *model.License, info *model.ClientDevice) (*model.Device, error) {
+	policy := GetFingerprintPolicy()
+	if policy.MinScore <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	rebindAfter := now.AddDate(0, 0, -GetSystemConfigInt(model.ConfigFingerprintRebindDays, defaultFingerprintRebindDays))
+	db := database.GetDB()
+	var devices []model.Device
+	if err := db.Where("id IN (?)", db.Model(&model.LicenseActivation{}).
+		Select("device_id").
+		Where("license_id = ? AND status IN ?", license.ID, seatActivationStatuses).
+		Where("matched_at IS NULL OR matched_at <= ?", rebindAfter)).
+		Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license devices: %v", err)
+	}
+
+	reported := &model.Device{Name: info.Name, DiskID: info.DiskID, BIOS: info.BIOS, Motherboard: info.Motherboard}
+	candidate := *reported
+	for _, component := range info.Fallback {
+		switch component {
+		case model.FingerprintComponentDiskID:
+			candidate.DiskID = ""
+		case model.FingerprintComponentBIOS:
+			candidate.BIOS = ""
+		case model.FingerprintComponentMotherboard:
+			candidate.Motherboard = ""
+		}
+	}
+	var best *model.Device
+	var bestMatch *model.FingerprintMatch
+	for i := range devices {
+		match := MatchDeviceFingerprint(&devices[i], &candidate, policy)
+		if match.IsMatch() && (bestMatch == nil || match.Score > bestMatch.Score) {
+			best, bestMatch = &devices[i], match
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	reported.Status = best.Status
+	differences := CompareDevices(best, reported)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 指纹变化后原授权文件失效，清空后按新指纹重新签发；并发换绑同一激活时只有一个请求生效
+		result := tx.Model(&model.LicenseActivation{}).
+			Where("license_id = ? AND device_id = ?", license.ID, best.ID).
+			Where("matched_at IS NULL OR matched_at <= ?", rebindAfter).
+			Updates(map[string]interface{}{
+				"matched_components":   model.StringArray(bestMatch.Matched),
+				"unmatched_components": model.StringArray(bestMatch.Unmatched),
+				"match_score":          bestMatch.Score,
+				"matched_at":           now,
+				"signed_file":          "",
+				"updated_at":           now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to record fingerprint match: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errFingerprintRebindLimited
+		}
+		if err := tx.Model(&model.Device{}).Where("id = ?", best.ID).
+			Updates(map[string]interface{}{
+				"disk_id":     info.DiskID,
+				"bios":        info.BIOS,
+				"motherboard": info.Motherboard,
+				"updated_at":  now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update device fingerprint: %v", err)
+		}
+		return nil
+	})
+	if errors.Is(err, errFingerprintRebindLimited) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation("", "", "fingerprint_match", "device", best.ID, map[string]interface{}{
+		"license_id":  license.ID,
+		"match":       bestMatch,
+		"differences": differences,
+	})
+
+	best.DiskID, best.BIOS, best.Motherboard = info.DiskID, info.BIOS, info.Motherboard
+	return best, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDeviceFingerprint(t *testing.T) {
+	setupTest(t)
+	stored := &model.Device{DiskID: "d", BIOS: "b", Motherboard: "m"}
+
+	policy := GetFingerprintPolicy()
+	assert.Equal(t, defaultFingerprintMinScore, policy.MinScore)
+	match := MatchDeviceFingerprint(stored, &model.Device{DiskID: "x", BIOS: "b", Motherboard: "m"}, policy)
+	assert.True(t, match.IsMatch())
+	assert.Equal(t, []string{model.FingerprintComponentDiskID}, match.Unmatched)
+	match = MatchDeviceFingerprint(stored, &model.Device{DiskID: "d", BIOS: "x", Motherboard: "y"}, policy)
+	assert.False(t, match.IsMatch())
+
+	// 提高组件权重后单个组件即可匹配
+	policy.Weights[model.FingerprintComponentDiskID] = 2
+	match = MatchDeviceFingerprint(stored, &model.Device{DiskID: "d", BIOS: "x", Motherboard: "y"}, policy)
+	assert.True(t, match.IsMatch())
+	assert.Equal(t, 2, match.Score)
+
+	// 空值不算匹配
+	match = MatchDeviceFingerprint(&model.Device{BIOS: "b"}, &model.Device{BIOS: "b"}, GetFingerprintPolicy())
+	assert.Equal(t, 1, match.Score)
+}
+
+func TestClientActivateMatchesReplacedHardware(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-fingerprint")
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	data, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("001")}, "")
+	require.NoError(t, err)
+
+	// 更换硬盘后三个组件匹配两个，仍识别为原设备，不占用新的名额
+	replaced := testClientDevice("001")
+	replaced.DiskID = "disk-replaced"
+	matched, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: replaced}, "")
+	require.NoError(t, err)
+	assert.Equal(t, data.DeviceID, matched.DeviceID)
+
+	activations, err := GetLicenseActivationsByID(license.ID)
+	require.NoError(t, err)
+	require.Len(t, activations, 1)
+	assert.Equal(t, model.StringArray{model.FingerprintComponentBIOS, model.FingerprintComponentMotherboard}, activations[0].MatchedComponents)
+	assert.Equal(t, model.StringArray{model.FingerprintComponentDiskID}, activations[0].UnmatchedComponents)
+	assert.Equal(t, 2, activations[0].MatchScore)
+	device, err := GetDeviceByHardwareInfo("disk-replaced", "bios-001", "board-001")
+	require.NoError(t, err)
+	assert.Equal(t, data.DeviceID, device.ID)
+
+	// 只有一个组件相同的设备视为新设备
+	other := testClientDevice("002")
+	other.BIOS = "bios-001"
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: other}, "")
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, clientErrorCode(err))
+
+	// 最低分为0时关闭容错匹配
+	_, err = CreateSystemConfig(model.ConfigFingerprintMinScore, "0", "", model.ConfigGroupLicense, true)
+	require.NoError(t, err)
+	replaced.DiskID = "disk-replaced-again"
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: replaced}, "")
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, clientErrorCode(err))
+}
+
+func TestClientActivateRebindLimits(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-fingerprint")
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	data, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("001")}, "")
+	require.NoError(t, err)
+
+	// 同型号设备的BIOS和主板都是型号级信息，不能顶替已激活的设备
+	sameModel := testClientDevice("002")
+	sameModel.BIOS, sameModel.Motherboard = "bios-001", "board-001"
+	sameModel.Fallback = []string{model.FingerprintComponentBIOS, model.FingerprintComponentMotherboard}
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: sameModel}, "")
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, clientErrorCode(err))
+
+	// 换绑间隔内同一激活只能容错换绑一次
+	replaced := testClientDevice("001")
+	replaced.DiskID = "disk-replaced"
+	matched, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: replaced}, "")
+	require.NoError(t, err)
+	assert.Equal(t, data.DeviceID, matched.DeviceID)
+	replaced.DiskID = "disk-replaced-again"
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: replaced}, "")
+	assert.Equal(t, model.ClientCodeDeviceLimitReached, clientErrorCode(err))
+
+	// 超过换绑间隔后可以再次换绑
+	require.NoError(t, database.GetDB().Model(&model.LicenseActivation{}).Where("license_id = ?", license.ID).
+		Update("matched_at", time.Now().AddDate(0, 0, -31)).Error)
+	replaced.DiskID = "disk-replaced-later"
+	matched, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: replaced}, "")
+	require.NoError(t, err)
+	assert.Equal(t, data.DeviceID, matched.DeviceID)
+}
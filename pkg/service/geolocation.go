@@ -18,12 +18,13 @@ const (
 
 // GeoIPResponse IP地理位置信息响应
 type GeoIPResponse struct {
-	Status  string  `json:"status"`
-	Country string  `json:"country"`
-	City    string  `json:"city"`
-	Lat     float64 `json:"lat"`
-	Lon     float64 `json:"lon"`
-	Message string  `json:"message"`
+	Status      string  `json:"status"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"countryCode"`
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Message     string  `json:"message"`
 }
 
 // GetLocationFromIP 从IP获取地理位置信息
@@ -51,10 +52,11 @@ func GetLocationFromIP(ip string) (*model.Location, error) {
 	}
 
 	return &model.Location{
-		Latitude:  geoIP.Lat,
-		Longitude: geoIP.Lon,
-		Country:   geoIP.Country,
-		City:      geoIP.City,
+		Latitude:    geoIP.Lat,
+		Longitude:   geoIP.Lon,
+		Country:     geoIP.Country,
+		CountryCode: geoIP.CountryCode,
+		City:        geoIP.City,
 	}, nil
 }
 
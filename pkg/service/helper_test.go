@@ -0,0 +1,68 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTest 使用临时SQLite数据库和签名密钥初始化测试环境
+func setupTest(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+	database.SetDB(db)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	require.NoError(t, InitLicenseSigner(filepath.Join(dir, "signing.key")))
+}
+
+// createTestProduct 创建测试产品，返回带客户端密钥的产品
+func createTestProduct(t *testing.T, id string) *model.Product {
+	product := &model.Product{ID: id, Name: "Product " + id}
+	require.NoError(t, NewProductService().CreateProduct(product))
+	return product
+}
+
+// testClientDevice 返回按序号区分的客户端设备信息
+func testClientDevice(serial string) model.ClientDevice {
+	return model.ClientDevice{
+		Name:        "device-" + serial,
+		DiskID:      "disk-" + serial,
+		BIOS:        "bios-" + serial,
+		Motherboard: "board-" + serial,
+	}
+}
+
+// clientErrorCode 取出客户端API错误的结果码
+func clientErrorCode(err error) model.ClientCode {
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		return clientErr.Code
+	}
+	return ""
+}
+
+// registerTestDevice 按序号注册测试设备，返回设备及其指纹
+func registerTestDevice(t *testing.T, serial string) (*model.Device, string) {
+	info := testClientDevice(serial)
+	device, err := RegisterDevice(info.DiskID, info.BIOS, info.Motherboard, info.Name)
+	require.NoError(t, err)
+	return device, utils.GenerateFingerprint(info.DiskID, info.BIOS, info.Motherboard)
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultLeaseDuration 浮动授权未设置租约时长时的默认值
+const defaultLeaseDuration = 15 * time.Minute
+
+var (
+	ErrLicenseNotFloating = errors.New("license is not a floating license")
+	ErrLicenseFloating    = errors.New("floating license must be checked out with a lease")
+	ErrLeaseNotFound      = errors.New("no active lease for this device")
+)
+
+// IsFloatingLicense 判断授权是否为浮动授权
+func IsFloatingLicense(license *model.License) bool {
+	return license.SeatMode == model.LicenseSeatModeFloating
+}
+
+// leaseDuration 授权的租约时长
+func leaseDuration(license *model.License) time.Duration {
+	if license.LeaseDuration <= 0 {
+		return defaultLeaseDuration
+	}
+	return time.Duration(license.LeaseDuration) * time.Second
+}
+
+// ValidateSeatMode 校验席位模式和租约时长
+func ValidateSeatMode(seatMode model.LicenseSeatMode, leaseDurationSeconds int) error {
+	switch seatMode {
+	case model.LicenseSeatModeNamed, model.LicenseSeatModeFloating:
+	default:
+		return fmt.Errorf("invalid seat mode: %s", seatMode)
+	}
+	if leaseDurationSeconds < 0 {
+		return errors.New("lease duration cannot be negative")
+	}
+	return nil
+}
+
+// UpdateLicenseSeatMode 设置授权的席位模式，已有设备占用名额时不允许切换
+func UpdateLicenseSeatMode(licenseID string, seatMode model.LicenseSeatMode, leaseDurationSeconds int) error {
+	if err := ValidateSeatMode(seatMode, leaseDurationSeconds); err != nil {
+		return err
+	}
+
+	return trackLicenseChange(licenseID, &model.LicenseVersion{Action: model.LicenseChangeSeatMode}, func(tx *gorm.DB) error {
+		result := tx.Model(&model.License{}).
+			Where("id = ? AND (used_devices = 0 OR seat_mode = ?)", licenseID, seatMode).
+			Updates(map[string]interface{}{
+				"seat_mode":      seatMode,
+				"lease_duration": leaseDurationSeconds,
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update seat mode: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("license not found or seats are in use")
+		}
+		return nil
+	})
+}
+
+// CheckoutLease 为设备签出浮动授权租约，设备已持有租约时直接续约
+func CheckoutLease(code string, deviceID string, ipAddress string) (*model.LicenseLease, error) {
+	license, err := GetLicenseByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %v", err)
+	}
+	if !IsFloatingLicense(license) {
+		return nil, ErrLicenseNotFloating
+	}
+
+	// 检查授权状态
+	switch license.Status {
+	case model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive:
+	default:
+		return nil, fmt.Errorf("license is %s", license.Status)
+	}
+	if time.Now().After(license.ExpireTime) {
+		return nil, fmt.Errorf("license has expired")
+	}
+
+	// 先回收该授权已过期的租约，避免名额被长期占用
+	if err := reclaimLicenseLeases(license.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var lease model.LicenseLease
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// 锁定授权行，同一授权的签出按顺序执行；同一设备并发签出时后到的请求续约先签出的租约，不重复占用名额
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", license.ID).First(&model.License{}).Error; err != nil {
+			return fmt.Errorf("failed to lock license: %v", err)
+		}
+
+		err := tx.Where("license_id = ? AND device_id = ? AND status = ?", license.ID, deviceID, model.LeaseStatusActive).
+			First(&lease).Error
+		if err == nil {
+			lease.RenewedAt = now
+			lease.ExpiresAt = now.Add(leaseDuration(license))
+			lease.UpdatedAt = now
+			if err := tx.Model(&lease).Updates(map[string]interface{}{
+				"renewed_at": lease.RenewedAt,
+				"expires_at": lease.ExpiresAt,
+				"updated_at": lease.UpdatedAt,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to renew license lease: %v", err)
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get license lease: %v", err)
+		}
+
+		// 原子占用一个并发名额
+		result := tx.Model(&model.License{}).
+			Where("id = ? AND used_devices < ?", license.ID, licenseSeats(license)).
+			Updates(map[string]interface{}{
+				"used_devices": gorm.Expr("used_devices + 1"),
+				"status":       model.LicenseStatusUsed,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update license: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeviceLimitReached
+		}
+
+		lease = model.LicenseLease{
+			ID:           utils.GenerateUUID(),
+			LicenseID:    license.ID,
+			DeviceID:     deviceID,
+			Status:       model.LeaseStatusActive,
+			IPAddress:    ipAddress,
+			CheckedOutAt: now,
+			RenewedAt:    now,
+			ExpiresAt:    now.Add(leaseDuration(license)),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := tx.Create(&lease).Error; err != nil {
+			return fmt.Errorf("failed to create license lease: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// RenewLease 续约设备持有的浮动授权租约
+func RenewLease(code string, deviceID string) (*model.LicenseLease, error) {
+	license, err := GetLicenseByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %v", err)
+	}
+
+	now := time.Now()
+	var lease model.LicenseLease
+	if err := database.GetDB().
+		Where("license_id = ? AND device_id = ? AND status = ? AND expires_at > ?",
+			license.ID, deviceID, model.LeaseStatusActive, now).
+		First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeaseNotFound
+		}
+		return nil, fmt.Errorf("failed to get license lease: %v", err)
+	}
+
+	lease.RenewedAt = now
+	lease.ExpiresAt = now.Add(leaseDuration(license))
+	lease.UpdatedAt = now
+	if err := database.GetDB().Model(&lease).Updates(map[string]interface{}{
+		"renewed_at": lease.RenewedAt,
+		"expires_at": lease.ExpiresAt,
+		"updated_at": lease.UpdatedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to renew license lease: %v", err)
+	}
+	return &lease, nil
+}
+
+// ReleaseLease 归还设备持有的浮动授权租约
+func ReleaseLease(code string, deviceID string) error {
+	license, err := GetLicenseByCode(code)
+	if err != nil {
+		return fmt.Errorf("failed to get license: %v", err)
+	}
+
+	var lease model.LicenseLease
+	if err := database.GetDB().
+		Where("license_id = ? AND device_id = ? AND status = ?", license.ID, deviceID, model.LeaseStatusActive).
+		First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLeaseNotFound
+		}
+		return fmt.Errorf("failed to get license lease: %v", err)
+	}
+	return endLease(&lease, model.LeaseStatusReleased)
+}
+
+// endLease 结束租约并释放其占用的名额，租约已被结束时不重复释放
+func endLease(lease *model.LicenseLease, status string) error {
+	now := time.Now()
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.LicenseLease{}).
+			Where("id = ? AND status = ?", lease.ID, model.LeaseStatusActive).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"released_at": now,
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to end license lease: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&model.License{}).
+			Where("id = ? AND used_devices > 0", lease.LicenseID).
+			Updates(map[string]interface{}{
+				"used_devices": gorm.Expr("used_devices - 1"),
+				"updated_at":   now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update license: %v", err)
+		}
+		return tx.Model(&model.License{}).
+			Where("id = ? AND used_devices = 0 AND status = ?", lease.LicenseID, model.LicenseStatusUsed).
+			Update("status", model.LicenseStatusUnused).Error
+	})
+}
+
+// reclaimLicenseLeases 回收指定授权已过期的租约
+func reclaimLicenseLeases(licenseID string) error {
+	var leases []model.LicenseLease
+	if err := database.GetDB().
+		Where("license_id = ? AND status = ? AND expires_at <= ?", licenseID, model.LeaseStatusActive, time.Now()).
+		Find(&leases).Error; err != nil {
+		return fmt.Errorf("failed to get expired leases: %v", err)
+	}
+	for i := range leases {
+		if err := endLease(&leases[i], model.LeaseStatusExpired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReclaimExpiredLeases 回收所有超时未续约的浮动授权租约，返回回收数量
+func ReclaimExpiredLeases() (int, error) {
+	var leases []model.LicenseLease
+	if err := database.GetDB().
+		Where("status = ? AND expires_at <= ?", model.LeaseStatusActive, time.Now()).
+		Find(&leases).Error; err != nil {
+		return 0, fmt.Errorf("failed to get expired leases: %v", err)
+	}
+
+	reclaimed := 0
+	for i := range leases {
+		if err := endLease(&leases[i], model.LeaseStatusExpired); err != nil {
+			log.Printf("Failed to reclaim lease %s: %v", leases[i].ID, err)
+			continue
+		}
+		reclaimed++
+	}
+	return reclaimed, nil
+}
+
+// GetActiveLease 获取设备持有的有效租约
+func GetActiveLease(licenseID string, deviceID string) (*model.LicenseLease, error) {
+	var lease model.LicenseLease
+	if err := database.GetDB().
+		Where("license_id = ? AND device_id = ? AND status = ? AND expires_at > ?",
+			licenseID, deviceID, model.LeaseStatusActive, time.Now()).
+		First(&lease).Error; err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// GetLicenseLeases 获取授权当前的租约持有者
+func GetLicenseLeases(licenseID string) ([]model.LicenseLease, error) {
+	if _, err := GetLicenseByID(licenseID); err != nil {
+		return nil, err
+	}
+
+	leases := []model.LicenseLease{}
+	if err := database.GetDB().Table("license_leases").
+		Select("license_leases.*, devices.name as device_name").
+		Joins("LEFT JOIN devices ON license_leases.device_id = devices.id").
+		Where("license_leases.license_id = ? AND license_leases.status = ? AND license_leases.expires_at > ?",
+			licenseID, model.LeaseStatusActive, time.Now()).
+		Order("license_leases.checked_out_at").
+		Scan(&leases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license leases: %v", err)
+	}
+	return leases, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFloatingLicense(t *testing.T) {
+	setupTest(t)
+
+	// 无效的席位模式在创建前拒绝，不留下授权记录
+	_, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{SeatMode: "shared"})
+	assert.Error(t, err)
+	_, err = GenerateLicenseWithOptions("", model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{SeatMode: model.LicenseSeatModeFloating, LeaseDuration: -1})
+	assert.Error(t, err)
+	var count int64
+	require.NoError(t, database.GetDB().Model(&model.License{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	license, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{SeatMode: model.LicenseSeatModeFloating, LeaseDuration: 600})
+	require.NoError(t, err)
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseSeatModeFloating, stored.SeatMode)
+	assert.Equal(t, 600, stored.LeaseDuration)
+	assert.True(t, IsFloatingLicense(stored))
+}
+
+func TestFloatingLeaseReclaim(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, UpdateLicenseSeatMode(license.ID, model.LicenseSeatModeFloating, 300))
+	first, _ := registerTestDevice(t, "001")
+	second, _ := registerTestDevice(t, "002")
+
+	// 浮动授权不能按设备激活
+	_, err = ActivateLicense(license.Code, first.ID, "")
+	assert.ErrorIs(t, err, ErrLicenseFloating)
+
+	lease, err := CheckoutLease(license.Code, first.ID, "")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(300*time.Second), lease.ExpiresAt, time.Minute)
+	_, err = CheckoutLease(license.Code, second.ID, "")
+	assert.ErrorIs(t, err, ErrDeviceLimitReached)
+
+	// 归还后释放名额，重复归还返回无租约
+	require.NoError(t, ReleaseLease(license.Code, first.ID))
+	assert.ErrorIs(t, ReleaseLease(license.Code, first.ID), ErrLeaseNotFound)
+	_, err = CheckoutLease(license.Code, second.ID, "")
+	require.NoError(t, err)
+
+	// 超时未续约的租约被回收，不能再续约
+	require.NoError(t, database.GetDB().Model(&model.LicenseLease{}).
+		Where("license_id = ? AND status = ?", license.ID, model.LeaseStatusActive).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	reclaimed, err := ReclaimExpiredLeases()
+	require.NoError(t, err)
+	assert.Equal(t, 1, reclaimed)
+	reclaimed, err = ReclaimExpiredLeases()
+	require.NoError(t, err)
+	assert.Equal(t, 0, reclaimed)
+	_, err = RenewLease(license.Code, second.ID)
+	assert.ErrorIs(t, err, ErrLeaseNotFound)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.UsedDevices)
+	leases, err := GetLicenseLeases(license.ID)
+	require.NoError(t, err)
+	assert.Empty(t, leases)
+}
+
+func TestCheckoutLeaseSameDevice(t *testing.T) {
+	setupTest(t)
+	sqlDB, err := database.GetDB().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	license, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{SeatMode: model.LicenseSeatModeFloating, LeaseDuration: 300})
+	require.NoError(t, err)
+	device, _ := registerTestDevice(t, "001")
+
+	// 同一设备并发签出只占用一个名额并返回同一租约，SQLite串行执行写事务，无法复现并发交错
+	var wg sync.WaitGroup
+	leases := make(chan *model.LicenseLease, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := CheckoutLease(license.Code, device.ID, "")
+			assert.NoError(t, err)
+			leases <- lease
+		}()
+	}
+	wg.Wait()
+	close(leases)
+	ids := make(map[string]bool)
+	for lease := range leases {
+		if lease != nil {
+			ids[lease.ID] = true
+		}
+	}
+	assert.Len(t, ids, 1)
+
+	// 租约超时后重新签出时先回收原租约，仍只占用一个名额
+	require.NoError(t, database.GetDB().Model(&model.LicenseLease{}).Where("license_id = ?", license.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	lease, err := CheckoutLease(license.Code, device.ID, "")
+	require.NoError(t, err)
+	assert.True(t, lease.ExpiresAt.After(time.Now()))
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedDevices)
+}
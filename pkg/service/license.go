@@ -11,10 +11,65 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrDeviceLimitReached  = errors.New("license device limit reached")
+	ErrLicenseNotActivated = errors.New("license is not activated on this device")
+
+	// ErrLicenseExpireTimeEdit 编辑授权时不能直接修改到期时间
+	ErrLicenseExpireTimeEdit = errors.New("到期时间不能直接修改，请通过续期修改")
+
+	// errActivationExists 设备已激活该授权，激活事务回滚后返回已有的激活记录
+	errActivationExists = errors.New("license is already activated on this device")
+)
+
 // GenerateLicense 生成授权码
 func GenerateLicense(licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, features []string, usageLimit int64) (*model.License, error) {
+	return GenerateProductLicense("", licenseType, maxDevices, startTime, expireTime, groupID, features, usageLimit)
+}
+
+// LicenseOptions 创建授权时随授权记录一起写入的属性
+type LicenseOptions struct {
+	SeatMode      model.LicenseSeatMode // 席位模式，为空时按设备激活
+	LeaseDuration int                   // 浮动授权租约时长(秒)
+	CustomerID    string                // 授权所属客户，调用方需先通过ValidateLicenseRelations校验
+	PlanID        string                // 创建授权所用的授权方案
+	PartnerID     string                // 签发授权的合作伙伴
+	GracePeriod   *int                  // 到期宽限期天数，为空时使用系统配置
+	Description   string
+	CreatedBy     string
+}
+
+// GenerateProductLicense 为产品生成授权码，授权码使用产品配置的前缀
+func GenerateProductLicense(productID string, licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, features []string, usageLimit int64) (*model.License, error) {
+	return GenerateLicenseWithOptions(productID, licenseType, maxDevices, startTime, expireTime, groupID, features, usageLimit, LicenseOptions{})
+}
+
+// GenerateLicenseWithOptions 为产品生成授权码，options先于授权记录校验并随授权记录一次写入
+func GenerateLicenseWithOptions(productID string, licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, features []string, usageLimit int64, options LicenseOptions) (*model.License, error) {
 	// 生成授权码
-	code := utils.GenerateUUID()
+	code, err := GenerateLicenseCode(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	license, err := newLicenseWithOptions(code, productID, licenseType, maxDevices, startTime, expireTime, groupID, features, usageLimit, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Create(license).Error; err != nil {
+		return nil, fmt.Errorf("failed to create license: %v", err)
+	}
+
+	return license, nil
+}
+
+// newLicenseWithOptions 校验options并构造已签发离线授权文件的授权记录，由调用方写入数据库
+func newLicenseWithOptions(code string, productID string, licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, features []string, usageLimit int64, options LicenseOptions) (*model.License, error) {
+	if options.SeatMode != "" {
+		if err := ValidateSeatMode(options.SeatMode, options.LeaseDuration); err != nil {
+			return nil, err
+		}
+	}
 
 	// 序列化功能列表
 	featuresJSON, err := json.Marshal(features)
@@ -24,26 +79,36 @@ func GenerateLicense(licenseType model.LicenseType, maxDevices int, startTime ti
 
 	// 创建授权记录
 	license := &model.License{
-		ID:          utils.GenerateUUID(),
-		Code:        code,
-		Type:        licenseType,
-		Status:      model.LicenseStatusUnused,
-		MaxDevices:  maxDevices,
-		StartTime:   startTime,
-		ExpireTime:  expireTime,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		GroupID:     groupID,
-		Features:    features,
-		FeaturesStr: string(featuresJSON),
-		UsageLimit:  usageLimit,
-		UsageCount:  0,
-	}
-
-	if err := database.GetDB().Create(license).Error; err != nil {
-		return nil, fmt.Errorf("failed to create license: %v", err)
+		ID:            utils.GenerateUUID(),
+		Code:          code,
+		Type:          licenseType,
+		Status:        model.LicenseStatusUnused,
+		MaxDevices:    maxDevices,
+		StartTime:     startTime,
+		ExpireTime:    expireTime,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		GroupID:       groupID,
+		ProductID:     productID,
+		Features:      features,
+		FeaturesStr:   string(featuresJSON),
+		UsageLimit:    usageLimit,
+		UsageCount:    0,
+		SeatMode:      options.SeatMode,
+		LeaseDuration: options.LeaseDuration,
+		CustomerID:    options.CustomerID,
+		PlanID:        options.PlanID,
+		PartnerID:     options.PartnerID,
+		Description:   options.Description,
+		CreatedBy:     options.CreatedBy,
+
+		GracePeriodDays: options.GracePeriod,
+	}
+
+	// 签发离线授权文件
+	if err := signLicenseFile(license, ""); err != nil {
+		return nil, err
 	}
-
 	return license, nil
 }
 
@@ -62,48 +127,125 @@ func VerifyLicense(code string) (bool, error) {
 	}
 
 	// 检查授权状态
-	if license.Status != model.LicenseStatusUnused && license.Status != model.LicenseStatusActive {
+	switch license.Status {
+	case model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive, model.LicenseStatusGrace:
+	default:
 		return false, fmt.Errorf("license is not valid")
 	}
 
-	// 检查过期时间
-	if time.Now().After(license.ExpireTime) {
+	// 检查过期时间，宽限期内仍视为有效
+	if _, expired := CheckLicenseExpiry(&license, time.Now()); expired {
 		return false, fmt.Errorf("license has expired")
 	}
 
 	return true, nil
 }
 
-// ActivateLicense 激活授权码
-func ActivateLicense(code string, deviceID string) error {
-	var license model.License
-	if err := database.GetDB().Where("code = ?", code).First(&license).Error; err != nil {
-		return fmt.Errorf("failed to get license: %v", err)
-	}
+// ActivateLicense 在设备上激活授权码
+// 每台设备占用一个名额，已激活的设备重复激活直接返回原激活记录；
+// 名额通过条件更新原子占用，并发激活不会超过MaxDevices
+func ActivateLicense(code string, deviceID string, ipAddress string) (*model.LicenseActivation, error) {
+	return activateLicense(code, deviceID, ipAddress, model.ActivationStatusActive)
+}
 
-	// 处理 Features 字段
-	if license.FeaturesStr != "" {
-		if err := json.Unmarshal([]byte(license.FeaturesStr), &license.Features); err != nil {
-			return fmt.Errorf("failed to unmarshal features: %v", err)
-		}
+// activateLicense 以指定的激活状态在设备上激活授权
+func activateLicense(code string, deviceID string, ipAddress string, status string) (*model.LicenseActivation, error) {
+	license, err := GetLicenseByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %v", err)
 	}
 
 	// 检查授权状态
-	if license.Status != model.LicenseStatusUnused {
-		return fmt.Errorf("license is not unused")
+	switch license.Status {
+	case model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive:
+	default:
+		return nil, fmt.Errorf("license is %s", license.Status)
+	}
+	if IsFloatingLicense(license) {
+		return nil, ErrLicenseFloating
 	}
 
 	// 检查过期时间
 	if time.Now().After(license.ExpireTime) {
-		return fmt.Errorf("license has expired")
+		return nil, fmt.Errorf("license has expired")
 	}
 
-	// 更新授权状态
-	license.Status = model.LicenseStatusUsed
-	license.DeviceID = deviceID
-	license.UpdatedAt = time.Now()
+	var activation model.LicenseActivation
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return occupyLicenseSeat(tx, license, deviceID, ipAddress, status, &activation)
+	})
+	if err != nil {
+		// 设备已激活或并发激活同一设备时，以已存在的激活记录为准
+		if existing, getErr := GetActiveActivation(license.ID, deviceID); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return &activation, nil
+}
+
+// occupyLicenseSeat 在事务中为设备激活授权并占用一个设备名额，设备已激活时返回errActivationExists
+func occupyLicenseSeat(tx *gorm.DB, license *model.License, deviceID string, ipAddress string, status string, activation *model.LicenseActivation) error {
+	now := time.Now()
+
+	// 先占用该设备的激活记录，已激活或被并发请求抢先激活时幂等返回，不重复占用名额
+	err := tx.Where("license_id = ? AND device_id = ?", license.ID, deviceID).First(activation).Error
+	if err == nil {
+		result := tx.Model(&model.LicenseActivation{}).
+			Where("id = ? AND status NOT IN ?", activation.ID, seatActivationStatuses).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"activated_at":   now,
+				"deactivated_at": nil,
+				"ip_address":     ipAddress,
+				"signed_file":    "",
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update license activation: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errActivationExists
+		}
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		*activation = model.LicenseActivation{
+			ID:          utils.GenerateUUID(),
+			LicenseID:   license.ID,
+			DeviceID:    deviceID,
+			ActivatedAt: now,
+			Status:      status,
+			IPAddress:   ipAddress,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		// 并发激活同一设备时唯一索引冲突，由外层以已存在的激活记录为准
+		if err := tx.Create(activation).Error; err != nil {
+			return fmt.Errorf("failed to create license activation: %v", err)
+		}
+	} else {
+		return fmt.Errorf("failed to get license activation: %v", err)
+	}
+
+	// 原子占用一个设备名额
+	result := tx.Model(&model.License{}).
+		Where("id = ? AND used_devices < ?", license.ID, licenseSeats(license)).
+		Updates(map[string]interface{}{
+			"used_devices": gorm.Expr("used_devices + 1"),
+			"status":       model.LicenseStatusUsed,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update license: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeviceLimitReached
+	}
 
-	if err := database.GetDB().Save(&license).Error; err != nil {
+	// 第一台激活的设备作为授权的主设备
+	if err := tx.Model(&model.License{}).
+		Where("id = ? AND (device_id = '' OR device_id IS NULL)", license.ID).
+		Update("device_id", deviceID).Error; err != nil {
 		return fmt.Errorf("failed to update license: %v", err)
 	}
 
@@ -112,26 +254,121 @@ func ActivateLicense(code string, deviceID string) error {
 		ID:        utils.GenerateUUID(),
 		LicenseID: license.ID,
 		DeviceID:  deviceID,
-		StartTime: time.Now(),
+		StartTime: now,
 		Status:    "active",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-
-	if err := database.GetDB().Create(usage).Error; err != nil {
+	if err := tx.Create(usage).Error; err != nil {
 		return fmt.Errorf("failed to create license usage: %v", err)
 	}
 
-	return nil
+	return tx.Model(&model.Device{}).
+		Where("id = ?", deviceID).
+		Updates(map[string]interface{}{"license_id": license.ID, "updated_at": now}).Error
+}
+
+// DeactivateLicense 停用授权在设备上的激活并释放设备名额
+func DeactivateLicense(code string, deviceID string) error {
+	license, err := GetLicenseByCode(code)
+	if err != nil {
+		return fmt.Errorf("failed to get license: %v", err)
+	}
+
+	now := time.Now()
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.LicenseActivation{}).
+			Where("license_id = ? AND device_id = ? AND status IN ?", license.ID, deviceID, seatActivationStatuses).
+			Updates(map[string]interface{}{
+				"status":         model.ActivationStatusInactive,
+				"deactivated_at": now,
+				"signed_file":    "",
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to deactivate license: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrLicenseNotActivated
+		}
+
+		if err := tx.Model(&model.License{}).
+			Where("id = ? AND used_devices > 0", license.ID).
+			Updates(map[string]interface{}{
+				"used_devices": gorm.Expr("used_devices - 1"),
+				"updated_at":   now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update license: %v", err)
+		}
+
+		// 主设备停用时改由其他仍激活的设备作为主设备，没有则恢复为未使用
+		if license.DeviceID == deviceID {
+			var next model.LicenseActivation
+			nextDeviceID := ""
+			if err := tx.Where("license_id = ? AND status IN ?", license.ID, seatActivationStatuses).
+				Order("activated_at").First(&next).Error; err == nil {
+				nextDeviceID = next.DeviceID
+			}
+			if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+				Update("device_id", nextDeviceID).Error; err != nil {
+				return fmt.Errorf("failed to update license: %v", err)
+			}
+		}
+		if err := tx.Model(&model.License{}).
+			Where("id = ? AND used_devices = 0 AND status = ?", license.ID, model.LicenseStatusUsed).
+			Update("status", model.LicenseStatusUnused).Error; err != nil {
+			return fmt.Errorf("failed to update license: %v", err)
+		}
+
+		if err := tx.Model(&model.Device{}).
+			Where("id = ? AND license_id = ?", deviceID, license.ID).
+			Updates(map[string]interface{}{"license_id": "", "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to unbind device: %v", err)
+		}
+
+		return tx.Model(&model.LicenseUsage{}).
+			Where("license_id = ? AND device_id = ? AND status = ?", license.ID, deviceID, "active").
+			Updates(map[string]interface{}{
+				"status":     "inactive",
+				"end_time":   now,
+				"updated_at": now,
+			}).Error
+	})
+}
+
+// seatActivationStatuses 占用设备名额的激活状态
+var seatActivationStatuses = []string{model.ActivationStatusActive, model.ActivationStatusOffline}
+
+// GetActiveActivation 获取授权在设备上的有效激活记录(含离线激活)
+func GetActiveActivation(licenseID string, deviceID string) (*model.LicenseActivation, error) {
+	var activation model.LicenseActivation
+	if err := database.GetDB().
+		Where("license_id = ? AND device_id = ? AND status IN ?", licenseID, deviceID, seatActivationStatuses).
+		First(&activation).Error; err != nil {
+		return nil, err
+	}
+	return &activation, nil
+}
+
+// licenseSeats 授权可激活的设备数，未设置时按单设备授权处理
+func licenseSeats(license *model.License) int {
+	if license.MaxDevices <= 0 {
+		return 1
+	}
+	return license.MaxDevices
 }
 
-// BatchCreateLicense 批量生成授权码
-func BatchCreateLicense(count int, licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, features []string, usageLimit int64) ([]*model.License, error) {
+// BatchCreateLicense 批量生成授权码，授权码在批次内和数据库中均不重复
+func BatchCreateLicense(productID string, count int, licenseType model.LicenseType, maxDevices int, startTime time.Time, expireTime time.Time, groupID string, customerID string, features []string, usageLimit int64) ([]*model.License, error) {
 	var licenses []*model.License
 
+	codes, err := GenerateLicenseCodes(productID, count)
+	if err != nil {
+		return nil, err
+	}
+
 	// 批量生成授权码
-	for i := 0; i < count; i++ {
-		code := utils.GenerateUUID()
+	for _, code := range codes {
 
 		// 序列化功能列表
 		featuresJSON, err := json.Marshal(features)
@@ -150,11 +387,18 @@ func BatchCreateLicense(count int, licenseType model.LicenseType, maxDevices int
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 			GroupID:     groupID,
+			CustomerID:  customerID,
+			ProductID:   productID,
 			Features:    features,
 			FeaturesStr: string(featuresJSON),
 			UsageLimit:  usageLimit,
 			UsageCount:  0,
 		}
+
+		// 签发离线授权文件
+		if err := signLicenseFile(license, ""); err != nil {
+			return nil, err
+		}
 		licenses = append(licenses, license)
 	}
 
@@ -197,21 +441,6 @@ func QueryLicenses(status model.LicenseStatus, startTime time.Time, endTime time
 	return licenses, nil
 }
 
-// ImportLicenses 导入授权记录
-func ImportLicenses(licenses []model.License) error {
-	for i := range licenses {
-		licenses[i].ID = utils.GenerateUUID()
-		licenses[i].CreatedAt = time.Now()
-		licenses[i].UpdatedAt = time.Now()
-	}
-
-	if err := database.GetDB().Create(&licenses).Error; err != nil {
-		return fmt.Errorf("failed to import licenses: %v", err)
-	}
-
-	return nil
-}
-
 // QueryLicenseStats 查询授权统计信息
 func QueryLicenseStats(startTime time.Time, endTime time.Time) (*model.LicenseStats, error) {
 	stats := &model.LicenseStats{}
@@ -232,10 +461,15 @@ func QueryLicenseStats(startTime time.Time, endTime time.Time) (*model.LicenseSt
 	}
 
 	// 统计已过期授权数
-	if err := database.GetDB().Model(&model.License{}).Where("expire_time < ?", time.Now()).Count(&stats.ExpiredCount).Error; err != nil {
+	if err := database.GetDB().Model(&model.License{}).Where("status = ?", model.LicenseStatusExpired).Count(&stats.ExpiredCount).Error; err != nil {
 		return nil, fmt.Errorf("failed to count expired licenses: %v", err)
 	}
 
+	// 统计宽限期内授权数
+	if err := database.GetDB().Model(&model.License{}).Where("status = ?", model.LicenseStatusGrace).Count(&stats.GraceCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count grace licenses: %v", err)
+	}
+
 	// 统计各类型授权数量
 	var typeStats []struct {
 		Type  model.LicenseType `json:"type"`
@@ -300,14 +534,21 @@ func DisableLicense(code string) error {
 		return fmt.Errorf("failed to get license: %v", err)
 	}
 
-	license.Status = model.LicenseStatusDisabled
-	license.UpdatedAt = time.Now()
-
-	if err := database.GetDB().Save(&license).Error; err != nil {
-		return fmt.Errorf("failed to disable license: %v", err)
-	}
-
-	return nil
+	return trackLicenseChange(license.ID, &model.LicenseVersion{
+		Action: model.LicenseChangeDisable,
+		Reason: "license disabled",
+	}, func(tx *gorm.DB) error {
+		if err := tx.Model(&license).Updates(map[string]interface{}{
+			"status":     model.LicenseStatusDisabled,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to disable license: %v", err)
+		}
+		if err := addRevocation(tx, model.RevocationTypeLicense, license.Code, model.RevocationActionAdd, "license disabled"); err != nil {
+			return fmt.Errorf("failed to revoke license: %v", err)
+		}
+		return nil
+	})
 }
 
 // GetLicenseInfo 获取授权码信息
@@ -337,6 +578,12 @@ func BatchDisableLicense(codes []string) error {
 	}()
 
 	for _, code := range codes {
+		var license model.License
+		if err := tx.Where("code = ?", code).First(&license).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to get license %s: %v", code, err)
+		}
+		before := licenseSnapshotOf(&license)
 		if err := tx.Model(&model.License{}).
 			Where("code = ?", code).
 			Updates(map[string]interface{}{
@@ -346,6 +593,17 @@ func BatchDisableLicense(codes []string) error {
 			tx.Rollback()
 			return fmt.Errorf("failed to disable license %s: %v", code, err)
 		}
+		if _, err := recordLicenseVersion(tx, license.ID, before, &model.LicenseVersion{
+			Action: model.LicenseChangeDisable,
+			Reason: "license disabled",
+		}); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := addRevocation(tx, model.RevocationTypeLicense, code, model.RevocationActionAdd, "license disabled"); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revoke license %s: %v", code, err)
+		}
 	}
 
 	return tx.Commit().Error
@@ -450,17 +708,11 @@ func UpdateLicenseMetadata(id string, metadata string) error {
 		return errors.New("license id cannot be empty")
 	}
 	
-	result := database.GetDB().Model(&model.License{}).
-		Where("id = ?", id).
-		Update("metadata", metadata)
-
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("license not found")
-	}
-	return nil
+	return trackLicenseChange(id, &model.LicenseVersion{Action: model.LicenseChangeMetadata}, func(tx *gorm.DB) error {
+		return tx.Model(&model.License{}).
+			Where("id = ?", id).
+			Update("metadata", metadata).Error
+	})
 }
 
 // UpdateLicenseFeatures 更新授权码功能列表
@@ -470,13 +722,15 @@ func UpdateLicenseFeatures(licenseID string, features []string) error {
 		return err
 	}
 
-	return database.GetDB().Model(&model.License{}).
-		Where("id = ?", licenseID).
-		Update("features", string(featuresJSON)).Error
+	return trackLicenseChange(licenseID, &model.LicenseVersion{Action: model.LicenseChangeFeatures}, func(tx *gorm.DB) error {
+		return tx.Model(&model.License{}).
+			Where("id = ?", licenseID).
+			Update("features", string(featuresJSON)).Error
+	})
 }
 
 // ListLicenses 获取授权码列表
-func ListLicenses(page string, pageSize string, status string, groupID string) ([]model.License, int64, error) {
+func ListLicenses(page string, pageSize string, status string, groupID string, customerID string, productID string, tagID string) ([]model.License, int64, error) {
 	var licenses []model.License
 	var total int64
 
@@ -490,6 +744,15 @@ func ListLicenses(page string, pageSize string, status string, groupID string) (
 	if groupID != "" {
 		query = query.Where("group_id = ?", groupID)
 	}
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if tagID != "" {
+		query = query.Where("id IN (?)", database.GetDB().Table("license_tag_mapping").Select("license_id").Where("tag_id = ?", tagID))
+	}
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -497,7 +760,7 @@ func ListLicenses(page string, pageSize string, status string, groupID string) (
 	}
 
 	// 获取分页数据
-	if err := query.Offset(offset).Limit(limit).Find(&licenses).Error; err != nil {
+	if err := query.Preload("Tags").Offset(offset).Limit(limit).Find(&licenses).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -548,15 +811,8 @@ func DeleteLicense(id string) error {
 
 // GenerateLicenseKey 生成随机授权密钥
 func GenerateLicenseKey() (string, error) {
-	// 使用utils包中的随机字符串生成器生成授权密钥
-	// 生成格式为: XXXX-XXXX-XXXX-XXXX 的密钥
-	part1 := utils.RandomString(4)
-	part2 := utils.RandomString(4)
-	part3 := utils.RandomString(4)
-	part4 := utils.RandomString(4)
-	
-	key := fmt.Sprintf("%s-%s-%s-%s", part1, part2, part3, part4)
-	return key, nil
+	// 生成带校验字符的授权码，格式为: XXXXX-XXXXX-XXXXX-XXXXX
+	return GenerateLicenseCode("")
 }
 
 // GetLicenseByID 根据ID获取授权码
@@ -611,6 +867,10 @@ func GetLicenseActivationsByID(licenseID string) ([]model.LicenseActivation, err
 		IPAddress   string    `json:"ip_address"`
 		Location    string    `json:"location"`
 		CreatedAt   time.Time `json:"created_at"`
+		MatchedComponents   model.StringArray `json:"matched_components"`
+		UnmatchedComponents model.StringArray `json:"unmatched_components"`
+		MatchScore          int               `json:"match_score"`
+		MatchedAt           *time.Time        `json:"matched_at"`
 	}
 	
 	// 查询激活历史记录
@@ -636,6 +896,10 @@ func GetLicenseActivationsByID(licenseID string) ([]model.LicenseActivation, err
 			IPAddress:   record.IPAddress,
 			Location:    record.Location,
 			CreatedAt:   record.CreatedAt,
+			MatchedComponents:   record.MatchedComponents,
+			UnmatchedComponents: record.UnmatchedComponents,
+			MatchScore:          record.MatchScore,
+			MatchedAt:           record.MatchedAt,
 		}
 		activations = append(activations, activation)
 	}
@@ -648,8 +912,14 @@ func GetLicenseActivationsByID(licenseID string) ([]model.LicenseActivation, err
 	return activations, nil
 }
 
-// UpdateLicenseComprehensive 全面更新许可证信息
-func UpdateLicenseComprehensive(license model.License) (*model.License, error) {
+// licenseEditableColumns 管理端编辑授权时更新的列，未列出的列不会被编辑覆盖
+var licenseEditableColumns = []string{
+	"code", "status", "group_id", "customer_id", "product_id", "type", "max_devices", "features",
+	"description", "usage_limit", "seat_mode", "lease_duration", "metadata", "start_time", "updated_at",
+}
+
+// UpdateLicenseComprehensive 全面更新许可证信息，变更记录到授权历史
+func UpdateLicenseComprehensive(license model.License, reason string, operatorID, operatorName string) (*model.License, error) {
 	if license.ID == "" {
 		return nil, errors.New("许可证ID不能为空")
 	}
@@ -663,41 +933,163 @@ func UpdateLicenseComprehensive(license model.License) (*model.License, error) {
 		return nil, fmt.Errorf("查询许可证失败: %v", err)
 	}
 
+	// group_id表示授权组，所属客户保存在customer_id；早期前端仍在group_id中传客户ID
+	customerID, groupID, err := ResolveLegacyLicenseCustomer(license.CustomerID, license.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	license.CustomerID, license.GroupID = customerID, groupID
+	if err := ValidateLicenseRelations(license.CustomerID, license.ProductID); err != nil {
+		return nil, err
+	}
+	if license.LeaseDuration < 0 {
+		return nil, errors.New("租约时长不能为负数")
+	}
+	// 到期时间通过续期修改，续期会记录有效期、恢复宽限期状态并重新签发离线授权文件
+	if !license.ExpireTime.IsZero() && !license.ExpireTime.Truncate(time.Second).Equal(existingLicense.ExpireTime.Truncate(time.Second)) {
+		return nil, ErrLicenseExpireTimeEdit
+	}
+	// 修改授权码时校验格式，未修改的早期授权码保持原样
+	if license.Code != existingLicense.Code {
+		if err := utils.CheckLicenseCode(license.Code); err != nil {
+			return nil, fmt.Errorf("授权码格式无效: %v", err)
+		}
+	}
+
 	// 序列化功能列表
 	featuresJSON, err := json.Marshal(license.Features)
 	if err != nil {
 		return nil, fmt.Errorf("序列化功能列表失败: %v", err)
 	}
 
-	// 更新字段，保留不应由用户修改的字段
-	updatedLicense := model.License{
-		ID:          existingLicense.ID,
-		Code:        license.Code,
-		Status:      license.Status,
-		GroupID:     license.GroupID,  // 对应前端的customerId
-		Type:        license.Type,
-		MaxDevices:  license.MaxDevices,
-		Features:    license.Features,
-		FeaturesStr: string(featuresJSON),
-		Description: license.Description, // 对应前端的notes
-		// 移除了前端中不存在的Version字段
-		UsageLimit:  license.UsageLimit, // 对应前端的maxActivations
-		CreatedAt:   existingLicense.CreatedAt, // 保留创建时间
-		UpdatedAt:   time.Now(),                // 更新时间为当前时间
+	// 从现有授权复制，只覆盖管理端可编辑的字段，设备占用、签发状态、方案、合作伙伴和激活限制等由各自的流程维护
+	updatedLicense := existingLicense
+	updatedLicense.Code = license.Code
+	updatedLicense.Status = license.Status
+	updatedLicense.GroupID = license.GroupID
+	updatedLicense.CustomerID = license.CustomerID
+	updatedLicense.ProductID = license.ProductID
+	updatedLicense.Type = license.Type
+	updatedLicense.MaxDevices = license.MaxDevices
+	updatedLicense.Features = license.Features
+	updatedLicense.FeaturesStr = string(featuresJSON)
+	updatedLicense.Description = license.Description // 对应前端的notes
+	updatedLicense.UsageLimit = license.UsageLimit   // 对应前端的maxActivations
+	updatedLicense.LeaseDuration = license.LeaseDuration
+	updatedLicense.UpdatedAt = time.Now()
+	if license.Metadata != "" {
+		updatedLicense.Metadata = license.Metadata
+	}
+
+	// 已有设备占用名额时不允许切换席位模式
+	if license.SeatMode != "" && license.SeatMode != existingLicense.SeatMode {
+		if existingLicense.UsedDevices > 0 {
+			return nil, errors.New("席位已被占用，无法切换席位模式")
+		}
+		switch license.SeatMode {
+		case model.LicenseSeatModeNamed, model.LicenseSeatModeFloating:
+			updatedLicense.SeatMode = license.SeatMode
+		default:
+			return nil, fmt.Errorf("无效的席位模式: %s", license.SeatMode)
+		}
 	}
 
 	// 处理日期
 	if !license.StartTime.IsZero() {
 		updatedLicense.StartTime = license.StartTime
 	}
-	if !license.ExpireTime.IsZero() {
-		updatedLicense.ExpireTime = license.ExpireTime
-	}
 
 	// 保存更新后的许可证
-	if err := database.GetDB().Save(&updatedLicense).Error; err != nil {
-		return nil, fmt.Errorf("保存许可证失败: %v", err)
+	err = trackLicenseChange(existingLicense.ID, &model.LicenseVersion{
+		Action:       model.LicenseChangeUpdate,
+		Reason:       reason,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}, func(tx *gorm.DB) error {
+		// 名额数不能少于已占用的名额，条件更新避免与并发激活交错
+		result := tx.Model(&updatedLicense).Where("used_devices <= ?", licenseSeats(&updatedLicense)).
+			Select(licenseEditableColumns).Updates(&updatedLicense)
+		if result.Error != nil {
+			return fmt.Errorf("保存许可证失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("最大设备数不能小于已占用的名额")
+		}
+
+		// 禁用状态或授权码的变化需要同步到吊销列表，离线客户端才能得知
+		wasBlocked := isLicenseBlockedStatus(existingLicense.Status)
+		blocked := isLicenseBlockedStatus(updatedLicense.Status)
+		codeChanged := updatedLicense.Code != existingLicense.Code
+		if wasBlocked && (!blocked || codeChanged) {
+			if err := addRevocation(tx, model.RevocationTypeLicense, existingLicense.Code, model.RevocationActionRemove, reason); err != nil {
+				return err
+			}
+		}
+		if blocked && (!wasBlocked || codeChanged) {
+			if err := addRevocation(tx, model.RevocationTypeLicense, updatedLicense.Code, model.RevocationActionAdd, reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &updatedLicense, nil
 }
+
+// ValidateLicenseRelations 校验授权关联的客户和产品是否存在，为空表示不关联
+func ValidateLicenseRelations(customerID string, productID string) error {
+	if customerID != "" {
+		if _, err := NewCustomerService().GetCustomerByID(customerID); err != nil {
+			return fmt.Errorf("customer %s not found", customerID)
+		}
+	}
+	if productID != "" {
+		if _, err := NewProductService().GetProductByID(productID); err != nil {
+			return fmt.Errorf("product %s not found", productID)
+		}
+	}
+	return nil
+}
+
+// ResolveLegacyLicenseCustomer 兼容早期在group_id中传客户ID的调用方
+// customer_id为空且group_id不是授权组而是客户ID时，返回该客户ID并清空group_id
+func ResolveLegacyLicenseCustomer(customerID string, groupID string) (string, string, error) {
+	if customerID != "" || groupID == "" {
+		return customerID, groupID, nil
+	}
+	var count int64
+	if err := database.GetDB().Model(&model.LicenseGroup{}).Where("id = ?", groupID).Count(&count).Error; err != nil {
+		return "", "", fmt.Errorf("failed to check license group: %v", err)
+	}
+	if count > 0 {
+		return customerID, groupID, nil
+	}
+	if err := database.GetDB().Model(&model.Customer{}).Where("id = ?", groupID).Count(&count).Error; err != nil {
+		return "", "", fmt.Errorf("failed to check customer: %v", err)
+	}
+	if count > 0 {
+		return groupID, "", nil
+	}
+	return customerID, groupID, nil
+}
+
+// UpdateLicenseRelations 更新授权所属的客户和产品
+func UpdateLicenseRelations(licenseID string, customerID string, productID string) error {
+	if err := ValidateLicenseRelations(customerID, productID); err != nil {
+		return err
+	}
+	return trackLicenseChange(licenseID, &model.LicenseVersion{Action: model.LicenseChangeRelations}, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.License{}).Where("id = ?", licenseID).
+			Updates(map[string]interface{}{
+				"customer_id": customerID,
+				"product_id":  productID,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update license relations: %v", err)
+		}
+		return nil
+	})
+}
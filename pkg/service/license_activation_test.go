@@ -0,0 +1,144 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseFileRequiresActivation(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device, fingerprint := registerTestDevice(t, "001")
+	_, otherFingerprint := registerTestDevice(t, "002")
+
+	// 未激活的设备不能获取授权文件
+	_, err = GetLicenseFile(license.ID, fingerprint)
+	assert.ErrorIs(t, err, ErrLicenseNotActivated)
+
+	_, err = ActivateLicense(license.Code, device.ID, "127.0.0.1")
+	require.NoError(t, err)
+	file, err := GetLicenseFile(license.ID, fingerprint)
+	require.NoError(t, err)
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	claims, err := utils.VerifyLicenseFile(file, publicKey, fingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, claims.Fingerprint)
+
+	// 设备名额已满，其他设备仍不能获取授权文件
+	_, err = GetLicenseFile(license.ID, otherFingerprint)
+	assert.ErrorIs(t, err, ErrLicenseNotActivated)
+}
+
+func TestConcurrentReactivation(t *testing.T) {
+	setupTest(t)
+	sqlDB, err := database.GetDB().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	license, err := GenerateLicense(model.LicenseTypeStandard, 3, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device, _ := registerTestDevice(t, "001")
+	_, err = ActivateLicense(license.Code, device.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, DeactivateLicense(license.Code, device.ID))
+
+	// 同一设备重复激活只占用一个名额，SQLite串行执行写事务，无法复现并发交错
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ActivateLicense(license.Code, device.ID, "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedDevices)
+}
+
+func TestBackfillLicenseActivations(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device, _ := registerTestDevice(t, "001")
+
+	// 早期版本激活时只记录授权的device_id
+	db := database.GetDB()
+	require.NoError(t, db.Model(&model.License{}).Where("id = ?", license.ID).
+		Updates(map[string]interface{}{"device_id": device.ID, "status": model.LicenseStatusUsed}).Error)
+	require.NoError(t, db.Exec("DELETE FROM migrations WHERE id = ?", "data_001_backfill_license_activations").Error)
+	require.NoError(t, database.Migrate(db))
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedDevices)
+	_, err = GetActiveActivation(license.ID, device.ID)
+	require.NoError(t, err)
+
+	// 迁移只执行一次，补建的激活记录可以正常停用
+	require.NoError(t, database.Migrate(db))
+	require.NoError(t, DeactivateLicense(license.Code, device.ID))
+	stored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.UsedDevices)
+}
+
+func TestActivationDeviceLimit(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	first, _ := registerTestDevice(t, "001")
+	second, _ := registerTestDevice(t, "002")
+	third, _ := registerTestDevice(t, "003")
+
+	_, err = ActivateLicense(license.Code, first.ID, "")
+	require.NoError(t, err)
+	_, err = ActivateLicense(license.Code, second.ID, "")
+	require.NoError(t, err)
+
+	// 重复激活同一设备不占用新名额
+	_, err = ActivateLicense(license.Code, first.ID, "")
+	require.NoError(t, err)
+	_, err = ActivateLicense(license.Code, third.ID, "")
+	assert.ErrorIs(t, err, ErrDeviceLimitReached)
+
+	// 停用后释放名额，主设备改为其他仍激活的设备
+	require.NoError(t, DeactivateLicense(license.Code, first.ID))
+	assert.ErrorIs(t, DeactivateLicense(license.Code, first.ID), ErrLicenseNotActivated)
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedDevices)
+	assert.Equal(t, second.ID, stored.DeviceID)
+
+	_, err = ActivateLicense(license.Code, third.ID, "")
+	require.NoError(t, err)
+	stored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.UsedDevices)
+	assert.Equal(t, model.LicenseStatusUsed, stored.Status)
+
+	// 全部停用后恢复为未使用
+	require.NoError(t, DeactivateLicense(license.Code, second.ID))
+	require.NoError(t, DeactivateLicense(license.Code, third.ID))
+	stored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.UsedDevices)
+	assert.Equal(t, model.LicenseStatusUnused, stored.Status)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultGracePeriodDays 未配置宽限期时的默认天数
+const defaultGracePeriodDays = 7
+
+// LicenseGracePeriod 获取授权类型的宽限期，优先使用按类型的配置
+func LicenseGracePeriod(licenseType model.LicenseType) time.Duration {
+	days := GetSystemConfigInt(model.ConfigLicenseGracePeriodDays, defaultGracePeriodDays)
+	days = GetSystemConfigInt(model.ConfigLicenseGracePeriodDays+"."+string(licenseType), days)
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// LicenseGraceExpireTime 获取授权宽限期结束时间，没有宽限期时返回nil
+// 授权自身设置了宽限期(来自授权方案)时优先于系统配置
+func LicenseGraceExpireTime(license *model.License) *time.Time {
+	grace := LicenseGracePeriod(license.Type)
+	if license.GracePeriodDays != nil {
+		grace = time.Duration(*license.GracePeriodDays) * 24 * time.Hour
+	}
+	if grace <= 0 {
+		return nil
+	}
+	graceExpireTime := license.ExpireTime.Add(grace)
+	return &graceExpireTime
+}
+
+// CheckLicenseExpiry 根据当前时间判断授权是否处于宽限期或已过期，不依赖定时任务是否已更新状态
+func CheckLicenseExpiry(license *model.License, now time.Time) (inGrace bool, expired bool) {
+	if license.Status == model.LicenseStatusExpired {
+		return false, true
+	}
+	if !now.After(license.ExpireTime) {
+		return false, false
+	}
+	if graceExpireTime := LicenseGraceExpireTime(license); graceExpireTime != nil && !now.After(*graceExpireTime) {
+		return true, false
+	}
+	return false, true
+}
+
+// ProcessLicenseExpiry 推进授权的到期状态：有效 → 宽限期 → 已过期，返回本次进入宽限期和过期的数量
+func ProcessLicenseExpiry() (int, int, error) {
+	now := time.Now()
+
+	var licenses []model.License
+	if err := database.GetDB().
+		Where("status IN ? AND expire_time < ? AND deleted = ?", []model.LicenseStatus{
+			model.LicenseStatusUnused,
+			model.LicenseStatusUsed,
+			model.LicenseStatusActive,
+			model.LicenseStatusGrace,
+		}, now, false).
+		Find(&licenses).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get expiring licenses: %v", err)
+	}
+
+	graced, expired := 0, 0
+	for i := range licenses {
+		license := &licenses[i]
+		inGrace, isExpired := CheckLicenseExpiry(license, now)
+
+		var target model.LicenseStatus
+		var reason string
+		switch {
+		case isExpired:
+			target = model.LicenseStatusExpired
+			reason = "license expired"
+			if license.Status == model.LicenseStatusGrace {
+				reason = "grace period ended"
+			}
+		case inGrace && license.Status != model.LicenseStatusGrace:
+			target = model.LicenseStatusGrace
+			reason = "license expired, grace period started"
+		default:
+			continue
+		}
+
+		changed, err := TransitionLicenseStatus(license.ID, license.Status, target, reason)
+		if err != nil {
+			log.Printf("Failed to transition license %s to %s: %v", license.ID, target, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if target == model.LicenseStatusGrace {
+			graced++
+		} else {
+			expired++
+		}
+	}
+	return graced, expired, nil
+}
+
+// TransitionLicenseStatus 在授权仍处于from状态时将其变更为to状态并记录变更，返回是否发生了变更
+func TransitionLicenseStatus(licenseID string, from, to model.LicenseStatus, reason string) (bool, error) {
+	changed := false
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		before, err := loadLicenseSnapshot(tx, licenseID)
+		if err != nil {
+			return err
+		}
+		changed, err = transitionLicenseStatus(tx, licenseID, from, to, reason)
+		if err != nil || !changed {
+			return err
+		}
+		_, err = recordLicenseVersion(tx, licenseID, before, &model.LicenseVersion{
+			Action: model.LicenseChangeStatus,
+			Reason: reason,
+		})
+		return err
+	})
+	return changed, err
+}
+
+// transitionLicenseStatus 在事务中执行状态变更，状态已被并发修改时不做任何更新
+func transitionLicenseStatus(tx *gorm.DB, licenseID string, from, to model.LicenseStatus, reason string) (bool, error) {
+	now := time.Now()
+	result := tx.Model(&model.License{}).
+		Where("id = ? AND status = ?", licenseID, from).
+		Updates(map[string]interface{}{
+			"status":     to,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update license status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := tx.Create(&model.LicenseStatusTransition{
+		ID:         utils.GenerateUUID(),
+		LicenseID:  licenseID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  now,
+	}).Error; err != nil {
+		return false, fmt.Errorf("failed to record status transition: %v", err)
+	}
+	return true, nil
+}
+
+// GetLicenseStatusTransitions 获取授权的状态变更记录
+func GetLicenseStatusTransitions(licenseID string) ([]model.LicenseStatusTransition, error) {
+	if _, err := GetLicenseByID(licenseID); err != nil {
+		return nil, err
+	}
+
+	transitions := []model.LicenseStatusTransition{}
+	if err := database.GetDB().
+		Where("license_id = ?", licenseID).
+		Order("created_at").
+		Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get status transitions: %v", err)
+	}
+	return transitions, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestExpireTime 直接修改授权到期时间，模拟授权已到期
+func setTestExpireTime(t *testing.T, licenseID string, expireTime time.Time) {
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", licenseID).
+		Update("expire_time", expireTime).Error)
+}
+
+func TestProcessLicenseExpiry(t *testing.T) {
+	setupTest(t)
+	_, err := CreateSystemConfig(model.ConfigLicenseGracePeriodDays+"."+string(model.LicenseTypeTrial), "0", "", model.ConfigGroupLicense, true)
+	require.NoError(t, err)
+
+	standard, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	trial, err := GenerateLicense(model.LicenseTypeTrial, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	valid, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 到期后进入默认宽限期，宽限期为0的类型直接过期
+	setTestExpireTime(t, standard.ID, time.Now().AddDate(0, 0, -1))
+	setTestExpireTime(t, trial.ID, time.Now().AddDate(0, 0, -1))
+	graced, expired, err := ProcessLicenseExpiry()
+	require.NoError(t, err)
+	assert.Equal(t, 1, graced)
+	assert.Equal(t, 1, expired)
+
+	stored, err := GetLicenseByID(standard.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusGrace, stored.Status)
+	inGrace, isExpired := CheckLicenseExpiry(stored, time.Now())
+	assert.True(t, inGrace)
+	assert.False(t, isExpired)
+	stored, err = GetLicenseByID(trial.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusExpired, stored.Status)
+	stored, err = GetLicenseByID(valid.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusUnused, stored.Status)
+
+	// 重复执行不重复变更状态
+	graced, expired, err = ProcessLicenseExpiry()
+	require.NoError(t, err)
+	assert.Equal(t, 0, graced)
+	assert.Equal(t, 0, expired)
+
+	// 宽限期结束后过期
+	setTestExpireTime(t, standard.ID, time.Now().AddDate(0, 0, -30))
+	_, expired, err = ProcessLicenseExpiry()
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	transitions, err := GetLicenseStatusTransitions(standard.ID)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, model.LicenseStatusUnused, transitions[0].FromStatus)
+	assert.Equal(t, model.LicenseStatusGrace, transitions[0].ToStatus)
+	assert.Equal(t, model.LicenseStatusExpired, transitions[1].ToStatus)
+	assert.Equal(t, "grace period ended", transitions[1].Reason)
+}
+
+func TestLicenseGraceExpireTime(t *testing.T) {
+	setupTest(t)
+	expireTime := time.Now()
+	license := &model.License{Type: model.LicenseTypeStandard, ExpireTime: expireTime}
+
+	graceExpireTime := LicenseGraceExpireTime(license)
+	require.NotNil(t, graceExpireTime)
+	assert.True(t, expireTime.AddDate(0, 0, defaultGracePeriodDays).Equal(*graceExpireTime))
+
+	// 授权自身的宽限期优先于系统配置
+	days := 0
+	license.GracePeriodDays = &days
+	assert.Nil(t, LicenseGraceExpireTime(license))
+	_, isExpired := CheckLicenseExpiry(license, expireTime.Add(time.Second))
+	assert.True(t, isExpired)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	licenseSigningKey ed25519.PrivateKey // 根签名密钥
+	licenseSignerMu   sync.RWMutex
+)
+
+// InitLicenseSigner 初始化根签名密钥并登记到密钥库
+// 根密钥的公钥内置在客户端中，轮换生成的密钥由根密钥背书并加密保存
+func InitLicenseSigner(keyFile string) error {
+	privateKey, err := utils.LoadOrCreateSigningKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	licenseSignerMu.Lock()
+	licenseSigningKey = privateKey
+	licenseSignerMu.Unlock()
+	return ensureRootSigningKey(privateKey)
+}
+
+// GetLicensePublicKey 获取根签名公钥，客户端内置该公钥并据此信任密钥库中的其他密钥
+func GetLicensePublicKey() (ed25519.PublicKey, error) {
+	root, err := getRootSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	return root.Public().(ed25519.PublicKey), nil
+}
+
+// SignLicense 为授权生成签名的离线授权文件
+func SignLicense(license *model.License, fingerprint string) (*model.LicenseFile, error) {
+	return signLicenseClaims(license.ProductID, newLicenseClaims(license, fingerprint))
+}
+
+// SignLeaseFile 为浮动授权租约生成签名的授权文件，租约到期后文件失效
+func SignLeaseFile(license *model.License, fingerprint string, leaseExpiresAt time.Time) (*model.LicenseFile, error) {
+	claims := newLicenseClaims(license, fingerprint)
+	claims.LeaseExpiresAt = &leaseExpiresAt
+	return signLicenseClaims(license.ProductID, claims)
+}
+
+// newLicenseClaims 根据授权构造待签名的授权内容
+func newLicenseClaims(license *model.License, fingerprint string) *model.LicenseClaims {
+	return &model.LicenseClaims{
+		PayloadType: model.LicenseClaimsType,
+		LicenseID:   license.ID,
+		Code:        license.Code,
+		Type:        license.Type,
+		Features:    license.Features,
+		MaxDevices:  license.MaxDevices,
+		StartTime:   license.StartTime,
+		ExpireTime:  license.ExpireTime,
+		Fingerprint: fingerprint,
+		IssuedAt:    time.Now(),
+
+		GraceExpireTime: LicenseGraceExpireTime(license),
+	}
+}
+
+// signLicenseClaims 使用产品当前的签名密钥签名授权内容
+func signLicenseClaims(productID string, claims *model.LicenseClaims) (*model.LicenseFile, error) {
+	_, privateKey, err := selectSigningKey(productID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.SignLicenseClaims(claims, privateKey)
+}
+
+// signLicenseFile 签名授权并将离线授权文件写入授权记录
+func signLicenseFile(license *model.License, fingerprint string) error {
+	file, err := SignLicense(license, fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to sign license: %v", err)
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal license file: %v", err)
+	}
+	license.SignedFile = string(data)
+	return nil
+}
+
+// licenseFileUpToDate 检查已签发的授权内容是否与当前授权一致
+func licenseFileUpToDate(claims *model.LicenseClaims, license *model.License, fingerprint string) bool {
+	if claims.Fingerprint != fingerprint ||
+		claims.Code != license.Code ||
+		claims.Type != license.Type ||
+		claims.MaxDevices != license.MaxDevices ||
+		!claims.StartTime.Equal(license.StartTime) ||
+		!claims.ExpireTime.Equal(license.ExpireTime) ||
+		len(claims.Features) != len(license.Features) {
+		return false
+	}
+	graceExpireTime := LicenseGraceExpireTime(license)
+	if (claims.GraceExpireTime == nil) != (graceExpireTime == nil) ||
+		(graceExpireTime != nil && !claims.GraceExpireTime.Equal(*graceExpireTime)) {
+		return false
+	}
+	for i := range claims.Features {
+		if claims.Features[i] != license.Features[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDeviceFingerprint 获取设备的硬件指纹
+func GetDeviceFingerprint(deviceID string) (string, error) {
+	device, err := GetDevice(deviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get device: %v", err)
+	}
+	return utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard), nil
+}
+
+// GetLicenseFile 获取授权的离线授权文件
+// fingerprint为空时使用授权主设备的指纹，只为已激活(含离线激活)的设备返回该激活记录签发的文件
+// 未激活的设备返回ErrLicenseNotActivated，避免绕过设备数限制为更多设备签发文件
+func GetLicenseFile(licenseID string, fingerprint string) (*model.LicenseFile, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fingerprint == "" && license.DeviceID != "" {
+		fingerprint, err = GetDeviceFingerprint(license.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 未绑定设备的授权文件保存在授权记录上
+	if fingerprint == "" {
+		return loadOrSignLicenseFile(license, "", false, license.SignedFile, func(data string) error {
+			return database.GetDB().Model(&model.License{}).
+				Where("id = ?", license.ID).
+				Update("signed_file", data).Error
+		})
+	}
+
+	var activations []model.LicenseActivation
+	if err := database.GetDB().
+		Where("license_id = ? AND status IN ?", license.ID, seatActivationStatuses).
+		Find(&activations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license activations: %v", err)
+	}
+	for i := range activations {
+		deviceFingerprint, err := GetDeviceFingerprint(activations[i].DeviceID)
+		if err != nil || deviceFingerprint != fingerprint {
+			continue
+		}
+		return GetActivationLicenseFile(license, &activations[i], fingerprint)
+	}
+
+	return nil, ErrLicenseNotActivated
+}
+
+// GetActivationLicenseFile 获取绑定到激活设备的离线授权文件，授权内容变化时重新签发
+// 离线激活签发的文件带有签名的离线标记，客户端据此免除离线宽限期
+func GetActivationLicenseFile(license *model.License, activation *model.LicenseActivation, fingerprint string) (*model.LicenseFile, error) {
+	offline := activation.Status == model.ActivationStatusOffline
+	return loadOrSignLicenseFile(license, fingerprint, offline, activation.SignedFile, func(data string) error {
+		return database.GetDB().Model(&model.LicenseActivation{}).
+			Where("id = ?", activation.ID).
+			Update("signed_file", data).Error
+	})
+}
+
+// loadOrSignLicenseFile 校验已保存的授权文件，失效时重新签发并通过save保存
+// 密钥轮换后已保存的文件仍使用原密钥校验，只有签名密钥被作废时才重新签发
+func loadOrSignLicenseFile(license *model.License, fingerprint string, offline bool, stored string, save func(data string) error) (*model.LicenseFile, error) {
+	if stored != "" {
+		var file model.LicenseFile
+		if err := json.Unmarshal([]byte(stored), &file); err == nil {
+			publicKey, err := getVerificationKey(file.KeyID)
+			if err != nil && !errors.Is(err, ErrSigningKeyNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				claims, err := utils.VerifyLicenseFile(&file, publicKey, "")
+				if err == nil && claims.Offline == offline && licenseFileUpToDate(claims, license, fingerprint) {
+					return &file, nil
+				}
+			}
+		}
+	}
+
+	// 重新签发并保存
+	claims := newLicenseClaims(license, fingerprint)
+	claims.Offline = offline
+	file, err := signLicenseClaims(license.ProductID, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign license: %v", err)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal license file: %v", err)
+	}
+	if err := save(string(data)); err != nil {
+		return nil, fmt.Errorf("failed to save license file: %v", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedLicenseFileRoundTrip(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", []string{"export", "max_users=10"}, 0)
+	require.NoError(t, err)
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+
+	// 生成授权时签发的文件可用根公钥离线校验
+	file, err := GetLicenseFile(license.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseFileAlgorithm, file.Algorithm)
+	assert.Equal(t, utils.PublicKeyID(publicKey), file.KeyID)
+	claims, err := utils.VerifyLicenseFile(file, publicKey, "")
+	require.NoError(t, err)
+	assert.Equal(t, license.Code, claims.Code)
+	assert.Equal(t, license.Features, claims.Features)
+	assert.Equal(t, license.ExpireTime.Unix(), claims.ExpireTime.Unix())
+	assert.Empty(t, claims.Fingerprint)
+
+	// 文件经JSON往返后签名仍然有效
+	data, err := json.Marshal(file)
+	require.NoError(t, err)
+	var decoded model.LicenseFile
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	_, err = utils.VerifyLicenseFile(&decoded, publicKey, "")
+	require.NoError(t, err)
+
+	// 修改授权内容后签名校验失败
+	claims.ExpireTime = claims.ExpireTime.AddDate(10, 0, 0)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	tampered := *file
+	tampered.Payload = base64.StdEncoding.EncodeToString(payload)
+	_, err = utils.VerifyLicenseFile(&tampered, publicKey, "")
+	assert.ErrorIs(t, err, utils.ErrInvalidSignature)
+
+	// 绑定设备的文件不能在其他设备上使用
+	bound, err := SignLicense(license, "fingerprint-1")
+	require.NoError(t, err)
+	_, err = utils.VerifyLicenseFile(bound, publicKey, "fingerprint-1")
+	require.NoError(t, err)
+	_, err = utils.VerifyLicenseFile(bound, publicKey, "fingerprint-2")
+	assert.ErrorIs(t, err, utils.ErrFingerprintMismatch)
+}
+
+func TestLicenseSignerKeyPersistence(t *testing.T) {
+	setupTest(t)
+	keyFile := filepath.Join(t.TempDir(), "signing.key")
+	require.NoError(t, InitLicenseSigner(keyFile))
+	first, err := GetLicensePublicKey()
+	require.NoError(t, err)
+
+	// 重新加载同一密钥文件得到相同的根公钥，已签发的文件仍然有效
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	file, err := SignLicense(license, "")
+	require.NoError(t, err)
+	require.NoError(t, InitLicenseSigner(keyFile))
+	second, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	_, err = utils.VerifyLicenseFile(file, second, "")
+	require.NoError(t, err)
+}
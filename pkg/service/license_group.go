@@ -0,0 +1,174 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// licenseExpiringSoonDays 授权组统计中即将到期的天数
+const licenseExpiringSoonDays = 30
+
+var ErrLicenseGroupNotFound = errors.New("license group not found")
+
+// ListLicenseGroups 获取授权组列表及每组的授权数
+func ListLicenseGroups() ([]model.LicenseGroup, error) {
+	groups := []model.LicenseGroup{}
+	if err := database.GetDB().Model(&model.LicenseGroup{}).
+		Select("license_groups.*, (SELECT COUNT(*) FROM licenses WHERE licenses.group_id = license_groups.id AND licenses.deleted = ?) AS license_count", false).
+		Order("created_at DESC").
+		Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license groups: %v", err)
+	}
+	return groups, nil
+}
+
+// GetLicenseGroup 获取授权组
+func GetLicenseGroup(id string) (*model.LicenseGroup, error) {
+	var group model.LicenseGroup
+	if err := database.GetDB().Where("id = ?", id).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLicenseGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to get license group: %v", err)
+	}
+	return &group, nil
+}
+
+// UpdateLicenseGroup 更新授权组名称和描述
+func UpdateLicenseGroup(id string, name string, description string) (*model.LicenseGroup, error) {
+	group, err := GetLicenseGroup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = name
+	group.Description = description
+	group.UpdatedAt = time.Now()
+	if err := database.GetDB().Model(group).Updates(map[string]interface{}{
+		"name":        group.Name,
+		"description": group.Description,
+		"updated_at":  group.UpdatedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update license group: %v", err)
+	}
+	return group, nil
+}
+
+// DeleteLicenseGroup 删除授权组，组内授权移出该组，授权本身不受影响
+func DeleteLicenseGroup(id string) (int64, error) {
+	if _, err := GetLicenseGroup(id); err != nil {
+		return 0, err
+	}
+
+	var ungrouped int64
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.License{}).Where("group_id = ?", id).
+			Updates(map[string]interface{}{"group_id": "", "updated_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to ungroup licenses: %v", result.Error)
+		}
+		ungrouped = result.RowsAffected
+
+		if err := tx.Where("id = ?", id).Delete(&model.LicenseGroup{}).Error; err != nil {
+			return fmt.Errorf("failed to delete license group: %v", err)
+		}
+		return nil
+	})
+	return ungrouped, err
+}
+
+// AssignLicensesToGroup 批量将授权移入授权组，groupID为空时移出所在的组
+func AssignLicensesToGroup(groupID string, licenseIDs []string) (int64, error) {
+	if len(licenseIDs) == 0 {
+		return 0, errors.New("license ids cannot be empty")
+	}
+	if groupID != "" {
+		if _, err := GetLicenseGroup(groupID); err != nil {
+			return 0, err
+		}
+	}
+
+	result := database.GetDB().Model(&model.License{}).
+		Where("id IN ? AND deleted = ?", licenseIDs, false).
+		Updates(map[string]interface{}{"group_id": groupID, "updated_at": time.Now()})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to assign licenses to group: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetLicenseGroupStats 统计授权组内授权的状态、类型、设备名额和即将到期情况
+func GetLicenseGroupStats(id string) (*model.LicenseGroupStats, error) {
+	group, err := GetLicenseGroup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.LicenseGroupStats{
+		GroupID:  group.ID,
+		Name:     group.Name,
+		ByStatus: make(map[model.LicenseStatus]int64),
+		ByType:   make(map[model.LicenseType]int64),
+	}
+	groupLicenses := func() *gorm.DB {
+		return database.GetDB().Model(&model.License{}).Where("group_id = ? AND deleted = ?", id, false)
+	}
+
+	var statusCounts []struct {
+		Status model.LicenseStatus
+		Count  int64
+	}
+	if err := groupLicenses().Select("status, COUNT(*) AS count").Group("status").Scan(&statusCounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count licenses by status: %v", err)
+	}
+	for _, item := range statusCounts {
+		stats.ByStatus[item.Status] = item.Count
+		stats.Total += item.Count
+	}
+
+	var typeCounts []struct {
+		Type  model.LicenseType
+		Count int64
+	}
+	if err := groupLicenses().Select("type, COUNT(*) AS count").Group("type").Scan(&typeCounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count licenses by type: %v", err)
+	}
+	for _, item := range typeCounts {
+		stats.ByType[item.Type] = item.Count
+	}
+
+	var seats struct {
+		MaxDevices  int64
+		UsedDevices int64
+		Activated   int64
+	}
+	if err := groupLicenses().
+		Select("COALESCE(SUM(max_devices), 0) AS max_devices, COALESCE(SUM(used_devices), 0) AS used_devices, " +
+			"COALESCE(SUM(CASE WHEN used_devices > 0 THEN 1 ELSE 0 END), 0) AS activated").
+		Scan(&seats).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum license seats: %v", err)
+	}
+	stats.MaxDevices = seats.MaxDevices
+	stats.UsedDevices = seats.UsedDevices
+	if stats.Total > 0 {
+		stats.ActivatedRate = float64(seats.Activated) / float64(stats.Total)
+	}
+
+	now := time.Now()
+	if err := groupLicenses().
+		Where("status IN ? AND expire_time BETWEEN ? AND ?", []model.LicenseStatus{
+			model.LicenseStatusUnused,
+			model.LicenseStatusUsed,
+			model.LicenseStatusActive,
+		}, now, now.AddDate(0, 0, licenseExpiringSoonDays)).
+		Count(&stats.ExpiringSoon).Error; err != nil {
+		return nil, fmt.Errorf("failed to count expiring licenses: %v", err)
+	}
+
+	return stats, nil
+}
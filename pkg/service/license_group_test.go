@@ -0,0 +1,68 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseGroupsAndTags(t *testing.T) {
+	setupTest(t)
+
+	group, err := CreateLicenseGroup("Spring Campaign", "", "admin")
+	require.NoError(t, err)
+	tag, err := CreateLicenseTag("reseller", "#ff0000")
+	require.NoError(t, err)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		license, err := GenerateLicense(model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 0, 10+40*i), "", nil, 0)
+		require.NoError(t, err)
+		ids = append(ids, license.ID)
+	}
+
+	count, err := AssignLicensesToGroup(group.ID, ids[:2])
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	stats, err := GetLicenseGroupStats(group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+	assert.Equal(t, int64(2), stats.ByStatus[model.LicenseStatusUnused])
+	assert.Equal(t, int64(4), stats.MaxDevices)
+	assert.Equal(t, int64(1), stats.ExpiringSoon)
+
+	// 重复添加的标签不会产生重复关联
+	added, _, err := BulkTagLicenses(&BulkTagRequest{LicenseIDs: ids, AddTagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), added)
+	added, removed, err := BulkTagLicenses(&BulkTagRequest{LicenseIDs: ids, AddTagIDs: []string{tag.ID}, RemoveTagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+	assert.Equal(t, int64(3), added)
+
+	licenses, total, err := ListLicenses("1", "10", "", "", "", "", tag.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, licenses[0].Tags, 1)
+	assert.Equal(t, "reseller", licenses[0].Tags[0].Name)
+
+	tags, err := ListLicenseTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, int64(3), tags[0].LicenseCount)
+
+	require.NoError(t, DeleteLicenseTag(tag.ID))
+	_, total, err = ListLicenses("1", "10", "", "", "", "", tag.ID)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	ungrouped, err := DeleteLicenseGroup(group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ungrouped)
+	_, err = GetLicenseGroup(group.ID)
+	assert.ErrorIs(t, err, ErrLicenseGroupNotFound)
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLicenseVersionNotFound = errors.New("license version not found")
+	ErrLicenseNotRevertible   = errors.New("license cannot be reverted")
+)
+
+// licenseSnapshotOf 提取授权可编辑字段的快照
+func licenseSnapshotOf(license *model.License) *model.LicenseSnapshot {
+	features := []string{}
+	if license.FeaturesStr != "" {
+		if err := json.Unmarshal([]byte(license.FeaturesStr), &features); err != nil {
+			features = []string{}
+		}
+	}
+	policy := &model.ActivationPolicy{}
+	if !license.ActivationPolicy.IsEmpty() {
+		policy = license.ActivationPolicy
+	}
+	return &model.LicenseSnapshot{
+		Type:             license.Type,
+		Status:           license.Status,
+		MaxDevices:       license.MaxDevices,
+		SeatMode:         license.SeatMode,
+		LeaseDuration:    license.LeaseDuration,
+		StartTime:        license.StartTime,
+		ExpireTime:       license.ExpireTime,
+		Description:      license.Description,
+		CustomerID:       license.CustomerID,
+		ProductID:        license.ProductID,
+		PlanID:           license.PlanID,
+		PartnerID:        license.PartnerID,
+		Features:         features,
+		Metadata:         license.Metadata,
+		UsageLimit:       license.UsageLimit,
+		GracePeriodDays:  license.GracePeriodDays,
+		ActivationPolicy: policy,
+	}
+}
+
+// loadLicenseSnapshot 读取授权当前的快照，变更前调用以便记录变更前的值
+func loadLicenseSnapshot(tx *gorm.DB, licenseID string) (*model.LicenseSnapshot, error) {
+	var license model.License
+	if err := tx.Where("id = ?", licenseID).First(&license).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license: %v", err)
+	}
+	return licenseSnapshotOf(&license), nil
+}
+
+// diffLicenseSnapshots 按快照字段顺序比较两个快照，返回发生变化的字段
+func diffLicenseSnapshots(before, after *model.LicenseSnapshot) (model.LicenseFieldChanges, error) {
+	beforeFields, err := snapshotFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes model.LicenseFieldChanges
+	snapshotType := reflect.TypeOf(model.LicenseSnapshot{})
+	for i := 0; i < snapshotType.NumField(); i++ {
+		name, _, _ := strings.Cut(snapshotType.Field(i).Tag.Get("json"), ",")
+		if !bytes.Equal(beforeFields[name], afterFields[name]) {
+			changes = append(changes, model.LicenseFieldChange{
+				Field:  name,
+				Before: beforeFields[name],
+				After:  afterFields[name],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// snapshotFields 将快照转换为字段名到JSON值的映射
+func snapshotFields(snapshot *model.LicenseSnapshot) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// recordLicenseVersion 在事务中比较授权变更前后的快照并追加一个历史版本，没有字段变化时不记录
+// 授权首次记录变更时先补记变更前的状态作为版本1，以便回滚到最初的状态
+// entry携带变更类型、原因和操作人，其余字段由本函数填充
+func recordLicenseVersion(tx *gorm.DB, licenseID string, before *model.LicenseSnapshot, entry *model.LicenseVersion) (*model.LicenseVersion, error) {
+	after, err := loadLicenseSnapshot(tx, licenseID)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffLicenseSnapshots(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff license: %v", err)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var latest int
+	if err := tx.Model(&model.LicenseVersion{}).
+		Where("license_id = ?", licenseID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to get latest license version: %v", err)
+	}
+
+	now := time.Now()
+	if latest == 0 {
+		if err := tx.Create(&model.LicenseVersion{
+			ID:        utils.GenerateUUID(),
+			LicenseID: licenseID,
+			Version:   1,
+			Action:    model.LicenseChangeInitial,
+			Changes:   model.LicenseFieldChanges{},
+			Snapshot:  *before,
+			CreatedAt: now,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to create initial license version: %v", err)
+		}
+		latest = 1
+	}
+
+	entry.ID = utils.GenerateUUID()
+	entry.LicenseID = licenseID
+	entry.Version = latest + 1
+	entry.Changes = changes
+	entry.Snapshot = *after
+	entry.CreatedAt = now
+	if err := tx.Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to create license version: %v", err)
+	}
+	return entry, nil
+}
+
+// trackLicenseChange 在事务中执行授权变更并记录历史版本
+func trackLicenseChange(licenseID string, entry *model.LicenseVersion, change func(tx *gorm.DB) error) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		before, err := loadLicenseSnapshot(tx, licenseID)
+		if err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+		_, err = recordLicenseVersion(tx, licenseID, before, entry)
+		return err
+	})
+}
+
+// GetLicenseVersions 获取授权的变更历史，按版本号倒序
+func GetLicenseVersions(licenseID string) ([]model.LicenseVersion, error) {
+	if _, err := GetLicenseByID(licenseID); err != nil {
+		return nil, err
+	}
+
+	versions := []model.LicenseVersion{}
+	if err := database.GetDB().
+		Where("license_id = ?", licenseID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license versions: %v", err)
+	}
+	return versions, nil
+}
+
+// GetLicenseVersion 获取授权的指定历史版本
+func GetLicenseVersion(licenseID string, version int) (*model.LicenseVersion, error) {
+	var entry model.LicenseVersion
+	if err := database.GetDB().
+		Where("license_id = ? AND version = ?", licenseID, version).
+		First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLicenseVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to get license version: %v", err)
+	}
+	return &entry, nil
+}
+
+// RevertLicenseVersion 将授权的可编辑字段恢复为指定版本的快照，回滚本身作为新版本记录
+// 未使用/已使用状态由当前的设备占用决定；已转移的授权不能回滚；禁用状态的变化同步到吊销列表
+// 授权已处于该版本的状态时返回nil
+func RevertLicenseVersion(licenseID string, version int, reason string, operatorID, operatorName string) (*model.LicenseVersion, error) {
+	target, err := GetLicenseVersion(licenseID, version)
+	if err != nil {
+		return nil, err
+	}
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := target.Snapshot
+	if license.Status == model.LicenseStatusTransferred || snapshot.Status == model.LicenseStatusTransferred {
+		return nil, fmt.Errorf("%w: license has been transferred", ErrLicenseNotRevertible)
+	}
+	if license.UsedDevices > 0 && snapshot.SeatMode != license.SeatMode {
+		return nil, fmt.Errorf("%w: seats are in use and seat mode cannot change", ErrLicenseNotRevertible)
+	}
+
+	status := snapshot.Status
+	if status == model.LicenseStatusUnused || status == model.LicenseStatusUsed {
+		status = model.LicenseStatusUnused
+		if license.UsedDevices > 0 {
+			status = model.LicenseStatusUsed
+		}
+	}
+	featuresJSON, err := json.Marshal(snapshot.Features)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal features: %v", err)
+	}
+	if reason == "" {
+		reason = fmt.Sprintf("revert to version %d", version)
+	}
+
+	var recorded *model.LicenseVersion
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		before, err := loadLicenseSnapshot(tx, licenseID)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"type":              snapshot.Type,
+			"status":            status,
+			"max_devices":       snapshot.MaxDevices,
+			"seat_mode":         snapshot.SeatMode,
+			"lease_duration":    snapshot.LeaseDuration,
+			"start_time":        snapshot.StartTime,
+			"expire_time":       snapshot.ExpireTime,
+			"description":       snapshot.Description,
+			"customer_id":       snapshot.CustomerID,
+			"product_id":        snapshot.ProductID,
+			"plan_id":           snapshot.PlanID,
+			"features":          string(featuresJSON),
+			"metadata":          snapshot.Metadata,
+			"usage_limit":       snapshot.UsageLimit,
+			"grace_period_days": snapshot.GracePeriodDays,
+			"updated_at":        time.Now(),
+		}
+		// 早期版本的快照没有记录激活限制，回滚时保持当前的激活限制
+		if snapshot.ActivationPolicy != nil {
+			var policy interface{}
+			if !snapshot.ActivationPolicy.IsEmpty() {
+				policy = snapshot.ActivationPolicy
+			}
+			updates["activation_policy"] = policy
+		}
+		if err := tx.Model(&model.License{}).Where("id = ?", licenseID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to revert license: %v", err)
+		}
+
+		// 禁用状态的变化需要同步到吊销列表，离线客户端才能得知
+		wasBlocked := isLicenseBlockedStatus(before.Status)
+		if blocked := isLicenseBlockedStatus(status); blocked != wasBlocked {
+			action := model.RevocationActionRemove
+			if blocked {
+				action = model.RevocationActionAdd
+			}
+			if err := addRevocation(tx, model.RevocationTypeLicense, license.Code, action, reason); err != nil {
+				return err
+			}
+		}
+
+		recorded, err = recordLicenseVersion(tx, licenseID, before, &model.LicenseVersion{
+			Action:       model.LicenseChangeRevert,
+			Reason:       reason,
+			RevertedTo:   version,
+			OperatorID:   operatorID,
+			OperatorName: operatorName,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if recorded == nil {
+		return nil, nil
+	}
+
+	if err := reissueLicenseFiles(licenseID); err != nil {
+		log.Printf("Failed to reissue license files for %s: %v", licenseID, err)
+	}
+	LogOperation(operatorID, operatorName, "revert", "license", licenseID, map[string]interface{}{
+		"version": version,
+		"reason":  reason,
+	})
+	return recorded, nil
+}
+
+// isLicenseBlockedStatus 判断授权状态是否应在吊销列表中
+func isLicenseBlockedStatus(status model.LicenseStatus) bool {
+	return status == model.LicenseStatusDisabled || status == model.LicenseStatusRevoked
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseVersionHistory(t *testing.T) {
+	setupTest(t)
+
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", []string{"export"}, 0)
+	require.NoError(t, err)
+
+	// 未发生变化的更新不记录版本
+	require.NoError(t, UpdateLicenseFeatures(license.ID, []string{"export"}))
+	versions, err := GetLicenseVersions(license.ID)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	require.NoError(t, UpdateLicenseFeatures(license.ID, []string{"export", "report"}))
+	require.NoError(t, UpdateLicenseMetadata(license.ID, `{"region":"eu"}`))
+	require.NoError(t, DisableLicense(license.Code))
+
+	versions, err = GetLicenseVersions(license.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+	assert.Equal(t, model.LicenseChangeDisable, versions[0].Action)
+	assert.Equal(t, model.LicenseChangeInitial, versions[3].Action)
+	features := versions[2]
+	assert.Equal(t, model.LicenseChangeFeatures, features.Action)
+	require.Len(t, features.Changes, 1)
+	assert.Equal(t, "features", features.Changes[0].Field)
+	assert.JSONEq(t, `["export"]`, string(features.Changes[0].Before))
+	assert.JSONEq(t, `["export","report"]`, string(features.Changes[0].After))
+
+	reverted, err := RevertLicenseVersion(license.ID, 1, "", "u-1", "admin")
+	require.NoError(t, err)
+	require.NotNil(t, reverted)
+	assert.Equal(t, 5, reverted.Version)
+	assert.Equal(t, 1, reverted.RevertedTo)
+
+	restored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusUnused, restored.Status)
+	assert.Equal(t, "", restored.Metadata)
+	assert.JSONEq(t, `["export"]`, restored.FeaturesStr)
+	revocations, err := GetRevocationList(0)
+	require.NoError(t, err)
+	assert.Empty(t, revocations.Entries)
+
+	// 再次回滚到相同状态不产生新版本
+	reverted, err = RevertLicenseVersion(license.ID, 1, "", "u-1", "admin")
+	require.NoError(t, err)
+	assert.Nil(t, reverted)
+
+	_, err = RevertLicenseVersion(license.ID, 9, "", "u-1", "admin")
+	assert.ErrorIs(t, err, ErrLicenseVersionNotFound)
+}
+
+func TestActivationPolicyHistory(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 设置和清除激活限制都记录为历史版本
+	policy := &model.ActivationPolicy{DeniedCountries: []string{"DE"}}
+	_, err = SetLicenseActivationPolicy(license.ID, policy, "u-1", "admin")
+	require.NoError(t, err)
+	_, err = SetLicenseActivationPolicy(license.ID, nil, "u-1", "admin")
+	require.NoError(t, err)
+
+	versions, err := GetLicenseVersions(license.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, model.LicenseChangePolicy, versions[0].Action)
+	require.Len(t, versions[0].Changes, 1)
+	assert.Equal(t, "activation_policy", versions[0].Changes[0].Field)
+	assert.JSONEq(t, `{"denied_countries":["DE"]}`, string(versions[0].Changes[0].Before))
+	assert.JSONEq(t, `{}`, string(versions[0].Changes[0].After))
+
+	// 回滚恢复激活限制
+	_, err = RevertLicenseVersion(license.ID, 2, "", "u-1", "admin")
+	require.NoError(t, err)
+	restored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, policy, restored.ActivationPolicy)
+
+	// 快照未记录激活限制的早期版本回滚时不修改激活限制
+	legacy := versions[2].Snapshot
+	legacy.ActivationPolicy = nil
+	require.NoError(t, database.GetDB().Model(&model.LicenseVersion{}).Where("id = ?", versions[2].ID).Update("snapshot", legacy).Error)
+	_, err = RevertLicenseVersion(license.ID, 1, "", "u-1", "admin")
+	require.NoError(t, err)
+	restored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, policy, restored.ActivationPolicy)
+}
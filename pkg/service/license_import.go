@@ -0,0 +1,400 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxLicenseImportRows 单次导入的最大行数
+const maxLicenseImportRows = 10000
+
+// licenseImportStatuses 导入时允许的授权状态，激活相关状态需由客户端重新激活产生
+var licenseImportStatuses = map[model.LicenseStatus]bool{
+	model.LicenseStatusUnused:   true,
+	model.LicenseStatusDisabled: true,
+	model.LicenseStatusExpired:  true,
+}
+
+// ParseLicenseImportCSV 解析CSV导入文件，列按表头名称匹配，忽略大小写和下划线
+// 兼容导出文件的表头(如MaxDevices)，ID列被忽略；无法解析的字段记为对应行的错误
+func ParseLicenseImportCSV(r io.Reader) ([]model.LicenseImportRow, []model.LicenseImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("empty CSV file")
+		}
+		return nil, nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+		columns[strings.TrimPrefix(name, "\ufeff")] = i
+	}
+	for _, required := range []string{"type", "expiretime"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing column %s", required)
+		}
+	}
+
+	var rows []model.LicenseImportRow
+	var parseErrors []model.LicenseImportError
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV line %d: %v", line, err)
+		}
+		if len(rows)+len(parseErrors) >= maxLicenseImportRows {
+			return nil, nil, fmt.Errorf("import is limited to %d rows", maxLicenseImportRows)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := model.LicenseImportRow{
+			Row:         line,
+			Code:        field("code"),
+			Type:        field("type"),
+			Status:      field("status"),
+			StartTime:   field("starttime"),
+			ExpireTime:  field("expiretime"),
+			CustomerID:  field("customerid"),
+			ProductID:   field("productid"),
+			GroupID:     field("groupid"),
+			Description: field("description"),
+		}
+		if features := field("features"); features != "" {
+			for _, feature := range strings.Split(features, ";") {
+				if feature = strings.TrimSpace(feature); feature != "" {
+					row.Features = append(row.Features, feature)
+				}
+			}
+		}
+
+		valid := true
+		if value := field("maxdevices"); value != "" {
+			if row.MaxDevices, err = strconv.Atoi(value); err != nil {
+				parseErrors = append(parseErrors, model.LicenseImportError{Row: line, Field: "max_devices", Code: row.Code, Message: "max_devices must be an integer"})
+				valid = false
+			}
+		}
+		if value := field("usagelimit"); value != "" {
+			if row.UsageLimit, err = strconv.ParseInt(value, 10, 64); err != nil {
+				parseErrors = append(parseErrors, model.LicenseImportError{Row: line, Field: "usage_limit", Code: row.Code, Message: "usage_limit must be an integer"})
+				valid = false
+			}
+		}
+		if valid {
+			rows = append(rows, row)
+		}
+	}
+	return rows, parseErrors, nil
+}
+
+// ParseLicenseImportJSON 解析JSON导入文件，支持授权记录数组或{"licenses": [...]}
+func ParseLicenseImportJSON(data []byte) ([]model.LicenseImportRow, error) {
+	var rows []model.LicenseImportRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		var wrapped struct {
+			Licenses []model.LicenseImportRow `json:"licenses"`
+		}
+		if wrappedErr := json.Unmarshal(data, &wrapped); wrappedErr != nil {
+			return nil, fmt.Errorf("invalid JSON import file: %v", err)
+		}
+		rows = wrapped.Licenses
+	}
+	if len(rows) > maxLicenseImportRows {
+		return nil, fmt.Errorf("import is limited to %d rows", maxLicenseImportRows)
+	}
+	for i := range rows {
+		rows[i].Row = i + 1
+	}
+	return rows, nil
+}
+
+// ImportLicenses 校验并导入授权记录
+// 每行都会校验类型、日期、授权码重复和关联的客户、产品，预检时只返回逐行校验报告；
+// 正式导入时校验通过的行在同一事务中写入，无效行跳过并在报告中列出
+// parseErrors为解析阶段已发现的错误，一并计入报告；legacyCodes为true时按原样接受其他系统迁移的、不符合授权码格式的授权码
+func ImportLicenses(rows []model.LicenseImportRow, parseErrors []model.LicenseImportError, dryRun, legacyCodes bool, operatorID, operatorName string) (*model.LicenseImportResult, error) {
+	invalidRows := make(map[int]bool)
+	for _, parseError := range parseErrors {
+		invalidRows[parseError.Row] = true
+	}
+	result := &model.LicenseImportResult{
+		DryRun: dryRun,
+		Total:  len(rows) + len(invalidRows),
+		Errors: append([]model.LicenseImportError{}, parseErrors...),
+	}
+
+	licenses, rowErrors, err := validateLicenseImportRows(rows, legacyCodes)
+	if err != nil {
+		return nil, err
+	}
+	result.Errors = append(result.Errors, rowErrors...)
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	result.Valid = len(licenses)
+	result.Invalid = result.Total - result.Valid
+	if dryRun || len(licenses) == 0 {
+		return result, nil
+	}
+
+	if err := assignImportLicenseCodes(licenses); err != nil {
+		return nil, err
+	}
+	for _, license := range licenses {
+		if err := signLicenseFile(license, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(licenses, 100).Error; err != nil {
+			return fmt.Errorf("failed to import licenses: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(licenses)
+	result.Licenses = make([]model.License, 0, len(licenses))
+	for _, license := range licenses {
+		result.Licenses = append(result.Licenses, *license)
+	}
+
+	LogOperation(operatorID, operatorName, "import", "license", "", map[string]int{
+		"total":    result.Total,
+		"imported": result.Imported,
+		"invalid":  result.Invalid,
+	})
+	return result, nil
+}
+
+// validateLicenseImportRows 逐行校验导入记录，返回校验通过的授权和各行的错误
+func validateLicenseImportRows(rows []model.LicenseImportRow, legacyCodes bool) ([]*model.License, []model.LicenseImportError, error) {
+	var rowErrors []model.LicenseImportError
+
+	// 文件内重复的授权码，所有出现的行都视为无效
+	codeRows := make(map[string][]int)
+	for _, row := range rows {
+		if code := importLicenseCode(row.Code, legacyCodes); code != "" {
+			codeRows[code] = append(codeRows[code], row.Row)
+		}
+	}
+	existingCodes := make(map[string]bool)
+	if len(codeRows) > 0 {
+		codes := make([]string, 0, len(codeRows))
+		for code := range codeRows {
+			codes = append(codes, code)
+		}
+		for start := 0; start < len(codes); start += 500 {
+			end := start + 500
+			if end > len(codes) {
+				end = len(codes)
+			}
+			var existing []string
+			if err := database.GetDB().Model(&model.License{}).
+				Where("code IN ?", codes[start:end]).
+				Pluck("code", &existing).Error; err != nil {
+				return nil, nil, fmt.Errorf("failed to check license codes: %v", err)
+			}
+			for _, code := range existing {
+				existingCodes[code] = true
+			}
+		}
+	}
+
+	customers := make(map[string]bool)
+	products := make(map[string]bool)
+	now := time.Now()
+	licenses := make([]*model.License, 0, len(rows))
+	for _, row := range rows {
+		code := importLicenseCode(row.Code, legacyCodes)
+		fail := func(field, format string, args ...interface{}) {
+			rowErrors = append(rowErrors, model.LicenseImportError{Row: row.Row, Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+		}
+		failed := len(rowErrors)
+
+		if code != "" {
+			switch {
+			case len(code) > 191:
+				fail("code", "code is too long")
+			case strings.ContainsAny(code, " \t\r\n"):
+				fail("code", "code must not contain whitespace")
+			case !legacyCodes && utils.CheckLicenseCode(code) != nil:
+				fail("code", "code is not a valid license key, set legacy_codes to import codes from other systems")
+			case len(codeRows[code]) > 1:
+				fail("code", "duplicate code in file (rows %s)", joinRows(codeRows[code]))
+			case existingCodes[code]:
+				fail("code", "code already exists")
+			}
+		}
+
+		licenseType := model.LicenseType(strings.ToLower(row.Type))
+		if !licenseType.IsValid() {
+			fail("type", "invalid license type %q", row.Type)
+		}
+
+		status := model.LicenseStatusUnused
+		if row.Status != "" {
+			status = model.LicenseStatus(strings.ToLower(row.Status))
+			if !licenseImportStatuses[status] {
+				fail("status", "status %q is not supported for import", row.Status)
+			}
+		}
+
+		if row.MaxDevices < 0 {
+			fail("max_devices", "max_devices cannot be negative")
+		}
+		if row.UsageLimit < 0 {
+			fail("usage_limit", "usage_limit cannot be negative")
+		}
+
+		startTime := now
+		if row.StartTime != "" {
+			t, err := parseImportTime(row.StartTime)
+			if err != nil {
+				fail("start_time", "invalid start_time %q", row.StartTime)
+			}
+			startTime = t
+		}
+		var expireTime time.Time
+		if row.ExpireTime == "" {
+			fail("expire_time", "expire_time is required")
+		} else if t, err := parseImportTime(row.ExpireTime); err != nil {
+			fail("expire_time", "invalid expire_time %q", row.ExpireTime)
+		} else {
+			expireTime = t
+			if !expireTime.After(startTime) {
+				fail("expire_time", "expire_time must be later than start_time")
+			}
+		}
+
+		if row.CustomerID != "" {
+			exists, ok := customers[row.CustomerID]
+			if !ok {
+				_, err := NewCustomerService().GetCustomerByID(row.CustomerID)
+				exists = err == nil
+				customers[row.CustomerID] = exists
+			}
+			if !exists {
+				fail("customer_id", "customer %s not found", row.CustomerID)
+			}
+		}
+		if row.ProductID != "" {
+			exists, ok := products[row.ProductID]
+			if !ok {
+				_, err := NewProductService().GetProductByID(row.ProductID)
+				exists = err == nil
+				products[row.ProductID] = exists
+			}
+			if !exists {
+				fail("product_id", "product %s not found", row.ProductID)
+			}
+		}
+
+		if len(rowErrors) > failed {
+			continue
+		}
+
+		features := row.Features
+		if features == nil {
+			features = []string{}
+		}
+		featuresJSON, err := json.Marshal(features)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal features: %v", err)
+		}
+		licenses = append(licenses, &model.License{
+			ID:          utils.GenerateUUID(),
+			Code:        code,
+			Type:        licenseType,
+			Status:      status,
+			MaxDevices:  row.MaxDevices,
+			StartTime:   startTime,
+			ExpireTime:  expireTime,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Description: row.Description,
+			GroupID:     row.GroupID,
+			CustomerID:  row.CustomerID,
+			ProductID:   row.ProductID,
+			Features:    features,
+			FeaturesStr: string(featuresJSON),
+			UsageLimit:  row.UsageLimit,
+		})
+	}
+	return licenses, rowErrors, nil
+}
+
+// assignImportLicenseCodes 为未指定授权码的记录按所属产品的格式生成授权码
+func assignImportLicenseCodes(licenses []*model.License) error {
+	pending := make(map[string][]*model.License)
+	for _, license := range licenses {
+		if license.Code == "" {
+			pending[license.ProductID] = append(pending[license.ProductID], license)
+		}
+	}
+	for productID, items := range pending {
+		codes, err := GenerateLicenseCodes(productID, len(items))
+		if err != nil {
+			return err
+		}
+		for i, license := range items {
+			license.Code = codes[i]
+		}
+	}
+	return nil
+}
+
+// importLicenseCode 规范化导入的授权码；接受早期授权码时，不符合当前格式的授权码按原样保存
+func importLicenseCode(raw string, legacyCodes bool) string {
+	code := utils.NormalizeLicenseKey(raw)
+	if legacyCodes && utils.CheckLicenseCode(code) != nil {
+		return strings.TrimSpace(raw)
+	}
+	return code
+}
+
+// parseImportTime 解析导入文件中的时间，支持RFC3339和日期格式
+func parseImportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// joinRows 将行号列表格式化为逗号分隔的字符串
+func joinRows(rows []int) string {
+	parts := make([]string, len(rows))
+	for i, row := range rows {
+		parts[i] = strconv.Itoa(row)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseImport(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-test")
+	createTestCustomer(t, "customer-1")
+	existing, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	legacyCode := utils.GenerateUUID()
+	expire := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+
+	csvData := strings.Join([]string{
+		"code,type,max_devices,start_time,expire_time,customer_id,product_id,features",
+		legacyCode + ",standard,2,," + expire + ",customer-1,p-test,export;report",
+		",pro,1,," + expire + ",,p-test,",
+		",unknown,1,," + expire + ",,,",
+		",standard,x,," + expire + ",,,",
+		",standard,1,2024-02-01,2024-01-01,,,",
+		existing.Code + ",standard,1,," + expire + ",,,",
+		",standard,1,," + expire + ",customer-404,,",
+		legacyCode + ",standard,1,," + expire + ",,,",
+	}, "\n")
+
+	rows, parseErrors, err := ParseLicenseImportCSV(strings.NewReader(csvData))
+	require.NoError(t, err)
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, 5, parseErrors[0].Row)
+
+	// 预检只返回报告，不写入数据
+	result, err := ImportLicenses(rows, parseErrors, true, false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 8, result.Total)
+	assert.Equal(t, 1, result.Valid)
+	assert.Equal(t, 7, result.Invalid)
+	assert.Zero(t, result.Imported)
+	invalidRows := make(map[int]string)
+	for _, rowErr := range result.Errors {
+		invalidRows[rowErr.Row] = rowErr.Field
+	}
+	assert.Equal(t, map[int]string{2: "code", 4: "type", 5: "max_devices", 6: "expire_time", 7: "code", 8: "customer_id", 9: "code"}, invalidRows)
+	_, err = GetLicenseByCode(legacyCode)
+	assert.Error(t, err)
+
+	// 正式导入只写入有效行
+	result, err = ImportLicenses(rows, parseErrors, false, false, "", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Imported)
+	imported := result.Licenses[0]
+	assert.Equal(t, model.LicenseTypePro, imported.Type)
+	assert.Equal(t, product.ID, imported.ProductID)
+	assert.NoError(t, utils.ValidateLicenseKey(imported.Code))
+
+	device, _ := registerTestDevice(t, "001")
+	_, err = ActivateLicense(imported.Code, device.ID, "")
+	require.NoError(t, err)
+}
+
+func TestLicenseImportLegacyCodes(t *testing.T) {
+	setupTest(t)
+	expire := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+	csvData := strings.Join([]string{
+		"code,type,max_devices,start_time,expire_time",
+		"A0B1-C2D3-E4F5-G6H7,standard,1,," + expire,
+		"old-system-0001,standard,1,," + expire,
+		"old system 0002,standard,1,," + expire,
+	}, "\n")
+	rows, parseErrors, err := ParseLicenseImportCSV(strings.NewReader(csvData))
+	require.NoError(t, err)
+
+	// 早期格式的授权码直接接受，其他系统的授权码需要显式开启legacy_codes
+	result, err := ImportLicenses(rows, parseErrors, true, false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Valid)
+	result, err = ImportLicenses(rows, parseErrors, false, true, "", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Imported)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 4, result.Errors[0].Row)
+
+	// 按原样保存的授权码可以在客户端激活
+	license, err := FindLicenseByInputCode("old-system-0001")
+	require.NoError(t, err)
+	assert.Equal(t, "old-system-0001", license.Code)
+	_, err = ClientActivate("", &model.ClientLicenseRequest{Code: "old-system-0001", Device: testClientDevice("001")}, "")
+	require.NoError(t, err)
+}
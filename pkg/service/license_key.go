@@ -0,0 +1,112 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxLicenseCodeAttempts 授权码冲突时的最大重试次数
+const maxLicenseCodeAttempts = 5
+
+var ErrLicenseCodeCollision = errors.New("failed to generate a unique license code")
+
+// licenseKeyFormat 获取产品的授权码格式，未指定产品时不带前缀
+func licenseKeyFormat(productID string) (utils.LicenseKeyFormat, error) {
+	format := utils.LicenseKeyFormat{
+		Groups:    GetSystemConfigInt(model.ConfigLicenseKeyGroups, utils.DefaultLicenseKeyGroups),
+		GroupSize: GetSystemConfigInt(model.ConfigLicenseKeyGroupSize, utils.DefaultLicenseKeyGroupSize),
+	}
+	if productID != "" {
+		product, err := NewProductService().GetProductByID(productID)
+		if err != nil {
+			return format, err
+		}
+		format.Prefix = product.KeyPrefix
+	}
+	return format, nil
+}
+
+// GenerateLicenseCode 按产品的授权码格式生成一个数据库中不存在的授权码
+func GenerateLicenseCode(productID string) (string, error) {
+	codes, err := GenerateLicenseCodes(productID, 1)
+	if err != nil {
+		return "", err
+	}
+	return codes[0], nil
+}
+
+// GenerateLicenseCodes 批量生成互不重复且数据库中不存在的授权码，冲突的授权码重新生成
+func GenerateLicenseCodes(productID string, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	format, err := licenseKeyFormat(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, count)
+	seen := make(map[string]bool, count)
+	for attempt := 0; attempt < maxLicenseCodeAttempts && len(codes) < count; attempt++ {
+		candidates := make([]string, 0, count-len(codes))
+		for len(codes)+len(candidates) < count {
+			code, err := utils.GenerateLicenseKey(format)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate license code: %v", err)
+			}
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			candidates = append(candidates, code)
+		}
+
+		var existing []string
+		if err := database.GetDB().Model(&model.License{}).
+			Where("code IN ?", candidates).
+			Pluck("code", &existing).Error; err != nil {
+			return nil, fmt.Errorf("failed to check license codes: %v", err)
+		}
+		taken := make(map[string]bool, len(existing))
+		for _, code := range existing {
+			taken[code] = true
+		}
+		for _, code := range candidates {
+			if !taken[code] {
+				codes = append(codes, code)
+			}
+		}
+	}
+	if len(codes) < count {
+		return nil, ErrLicenseCodeCollision
+	}
+	return codes, nil
+}
+
+// FindLicenseByInputCode 按用户输入的授权码查找授权，数据库中存在的授权码总是有效
+// 先按规范化后的授权码查找，找不到时按原样查找早期以其他大小写保存的授权码；
+// 都不存在时再校验格式，输错的授权码返回格式错误，否则返回gorm.ErrRecordNotFound
+func FindLicenseByInputCode(input string) (*model.License, error) {
+	code := utils.NormalizeLicenseKey(input)
+	license, err := GetLicenseByCode(code)
+	if raw := strings.TrimSpace(input); errors.Is(err, gorm.ErrRecordNotFound) && raw != code {
+		license, err = GetLicenseByCode(raw)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if checkErr := utils.CheckLicenseCode(code); checkErr != nil {
+			return nil, checkErr
+		}
+	}
+	return license, err
+}
+
+// IsLicenseCodeFormatError 判断错误是否为授权码格式或校验字符错误
+func IsLicenseCodeFormatError(err error) bool {
+	return errors.Is(err, utils.ErrInvalidLicenseKey) || errors.Is(err, utils.ErrLicenseKeyChecksum)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCreateLicenseKeyFormat(t *testing.T) {
+	setupTest(t)
+	product := &model.Product{ID: "p-key", Name: "Key", KeyPrefix: "LV"}
+	require.NoError(t, NewProductService().CreateProduct(product))
+
+	licenses, err := BatchCreateLicense(product.ID, 20, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", "", nil, 0)
+	require.NoError(t, err)
+	seen := make(map[string]bool)
+	for _, license := range licenses {
+		assert.Regexp(t, `^LV(-[2-9A-HJ-NP-Z]{5}){4}$`, license.Code)
+		assert.NoError(t, utils.ValidateLicenseKey(license.Code))
+		assert.False(t, seen[license.Code])
+		seen[license.Code] = true
+	}
+
+	// 授权码分组按系统配置生成，未关联产品时不带前缀
+	_, err = CreateSystemConfig(model.ConfigLicenseKeyGroups, "3", "", model.ConfigGroupLicense, true)
+	require.NoError(t, err)
+	code, err := GenerateLicenseCode("")
+	require.NoError(t, err)
+	assert.Regexp(t, `^[2-9A-HJ-NP-Z]{5}(-[2-9A-HJ-NP-Z]{5}){2}$`, code)
+}
+
+func TestClientLicenseCodeLookup(t *testing.T) {
+	setupTest(t)
+	product := &model.Product{ID: "p-key", Name: "Key", KeyPrefix: "LV"}
+	require.NoError(t, NewProductService().CreateProduct(product))
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 4, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 输错的授权码在查询数据库前被拒绝，小写输入规范化后可以激活
+	mistyped := license.Code[:len(license.Code)-1] + "2"
+	if mistyped == license.Code {
+		mistyped = license.Code[:len(license.Code)-1] + "3"
+	}
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: mistyped, Device: testClientDevice("001")}, "")
+	assert.Equal(t, model.ClientCodeInvalidLicenseKey, clientErrorCode(err))
+	data, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: " " + strings.ToLower(license.Code), Device: testClientDevice("001")}, "")
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, data.LicenseID)
+
+	// 旧版UUID授权码不校验校验字符
+	legacyCode := utils.GenerateUUID()
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).Update("code", legacyCode).Error)
+	data, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: legacyCode, Device: testClientDevice("002")}, "")
+	require.NoError(t, err)
+	assert.Equal(t, license.ID, data.LicenseID)
+
+	// 早期服务端生成的不含校验字符的授权码，以及数据库中以其他格式保存的授权码仍然有效
+	for i, code := range []string{"A0B1-C2D3-E4F5-G6H7", "legacy-key-01"} {
+		require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).Update("code", code).Error)
+		data, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: code, Device: testClientDevice(fmt.Sprintf("01%d", i))}, "")
+		require.NoError(t, err, code)
+		assert.Equal(t, license.ID, data.LicenseID)
+	}
+	_, err = ClientActivate(product.ID, &model.ClientLicenseRequest{Code: "missing-key", Device: testClientDevice("020")}, "")
+	assert.Equal(t, model.ClientCodeInvalidLicenseKey, clientErrorCode(err))
+}
+
+func TestUpdateLicenseValidatesCode(t *testing.T) {
+	setupTest(t)
+	product := &model.Product{ID: "p-key", Name: "Key", KeyPrefix: "LV"}
+	require.NoError(t, NewProductService().CreateProduct(product))
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	legacyCode := "legacy-key-01"
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).Update("code", legacyCode).Error)
+
+	// 未修改的早期授权码可以保存，修改为校验字符不符的授权码被拒绝
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Description = "edited"
+	})
+	require.NoError(t, err)
+	mistyped := license.Code[:len(license.Code)-1] + "2"
+	if mistyped == license.Code {
+		mistyped = license.Code[:len(license.Code)-1] + "3"
+	}
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Code = mistyped
+	})
+	assert.Error(t, err)
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Code = license.Code
+	})
+	require.NoError(t, err)
+}
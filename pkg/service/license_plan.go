@@ -0,0 +1,322 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLicensePlanInUse    = errors.New("license plan is used by existing licenses")
+	ErrLicensePlanNotFound = errors.New("license plan not found")
+	// ErrLicensePlanSeatsInUse 同步方案会使授权的设备数上限低于已占用的设备数
+	ErrLicensePlanSeatsInUse = errors.New("license plan max_devices is below the devices in use")
+)
+
+// LicensePlanRequest 创建或更新授权方案的请求，更新时整体替换方案内容
+type LicensePlanRequest struct {
+	Name            string                `json:"name" binding:"required"`
+	Description     string                `json:"description"`
+	Type            model.LicenseType     `json:"type" binding:"required"`
+	DurationDays    int                   `json:"duration_days" binding:"required"`
+	MaxDevices      int                   `json:"max_devices"`
+	SeatMode        model.LicenseSeatMode `json:"seat_mode"`
+	LeaseDuration   int                   `json:"lease_duration"`
+	Features        []string              `json:"features"`
+	UsageLimit      int64                 `json:"usage_limit"`
+	GracePeriodDays *int                  `json:"grace_period_days"`
+
+	ActivationPolicy *model.ActivationPolicy `json:"activation_policy"` // 按方案创建的授权未单独设置时使用
+}
+
+// LicenseFromPlanRequest 按授权方案创建授权的请求
+type LicenseFromPlanRequest struct {
+	PlanID      string    `json:"plan_id" binding:"required"`
+	CustomerID  string    `json:"customer_id"`
+	StartTime   time.Time `json:"start_time"` // 为空时从现在起算
+	Description string    `json:"description"`
+}
+
+// applyLicensePlanRequest 校验请求并写入授权方案
+func applyLicensePlanRequest(plan *model.LicensePlan, req *LicensePlanRequest) error {
+	if req.DurationDays <= 0 {
+		return errors.New("duration_days must be positive")
+	}
+	if req.MaxDevices < 0 || req.LeaseDuration < 0 || req.UsageLimit < 0 {
+		return errors.New("max_devices, lease_duration and usage_limit cannot be negative")
+	}
+	if req.GracePeriodDays != nil && *req.GracePeriodDays < 0 {
+		return errors.New("grace_period_days cannot be negative")
+	}
+	seatMode := req.SeatMode
+	switch seatMode {
+	case "":
+		seatMode = model.LicenseSeatModeNamed
+	case model.LicenseSeatModeNamed, model.LicenseSeatModeFloating:
+	default:
+		return fmt.Errorf("invalid seat mode: %s", seatMode)
+	}
+	for _, feature := range req.Features {
+		if _, ok := utils.ParseEntitlement(feature); !ok {
+			return fmt.Errorf("invalid feature: %q", feature)
+		}
+	}
+	if err := ValidateActivationPolicy(req.ActivationPolicy); err != nil {
+		return err
+	}
+
+	plan.Name = req.Name
+	plan.Description = req.Description
+	plan.Type = req.Type
+	plan.DurationDays = req.DurationDays
+	plan.MaxDevices = req.MaxDevices
+	plan.SeatMode = seatMode
+	plan.LeaseDuration = req.LeaseDuration
+	plan.Features = model.StringArray(req.Features)
+	plan.UsageLimit = req.UsageLimit
+	plan.GracePeriodDays = req.GracePeriodDays
+	plan.ActivationPolicy = nil
+	if !req.ActivationPolicy.IsEmpty() {
+		plan.ActivationPolicy = req.ActivationPolicy
+	}
+	return nil
+}
+
+// CreateLicensePlan 为产品创建授权方案
+func CreateLicensePlan(productID string, req *LicensePlanRequest, operatorID, operatorName string) (*model.LicensePlan, error) {
+	if _, err := NewProductService().GetProductByID(productID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan := &model.LicensePlan{
+		ID:        utils.GenerateUUID(),
+		ProductID: productID,
+		CreatedBy: operatorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyLicensePlanRequest(plan, req); err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Create(plan).Error; err != nil {
+		return nil, fmt.Errorf("failed to create license plan: %v", err)
+	}
+
+	LogOperation(operatorID, operatorName, "create", "license_plan", plan.ID, plan)
+	return plan, nil
+}
+
+// GetLicensePlan 获取授权方案
+func GetLicensePlan(id string) (*model.LicensePlan, error) {
+	var plan model.LicensePlan
+	if err := database.GetDB().Where("id = ?", id).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLicensePlanNotFound
+		}
+		return nil, fmt.Errorf("failed to get license plan: %v", err)
+	}
+	return &plan, nil
+}
+
+// GetProductLicensePlans 获取产品下的授权方案
+func GetProductLicensePlans(productID string) ([]model.LicensePlan, error) {
+	plans := []model.LicensePlan{}
+	if err := database.GetDB().
+		Where("product_id = ?", productID).
+		Order("created_at").
+		Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license plans: %v", err)
+	}
+	return plans, nil
+}
+
+// UpdateLicensePlan 更新授权方案，propagate为true时同步到按该方案创建的现有授权，返回同步的授权数
+// 同步不改变现有授权的有效期，有效期通过续期调整
+func UpdateLicensePlan(id string, req *LicensePlanRequest, propagate bool, operatorID, operatorName string) (*model.LicensePlan, int, error) {
+	plan, err := GetLicensePlan(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := applyLicensePlanRequest(plan, req); err != nil {
+		return nil, 0, err
+	}
+	plan.UpdatedAt = time.Now()
+
+	// 方案和同步的授权在同一事务中更新，同步失败时方案也不变
+	var licenseIDs []string
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(plan).Error; err != nil {
+			return fmt.Errorf("failed to update license plan: %v", err)
+		}
+		if !propagate {
+			return nil
+		}
+		licenseIDs, err = propagateLicensePlan(tx, plan)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 重新签发离线授权文件，单个授权失败不影响其他授权
+	for _, licenseID := range licenseIDs {
+		if err := reissueLicenseFiles(licenseID); err != nil {
+			log.Printf("Failed to reissue license files for %s: %v", licenseID, err)
+		}
+	}
+	propagated := len(licenseIDs)
+
+	LogOperation(operatorID, operatorName, "update", "license_plan", plan.ID, map[string]interface{}{
+		"plan":       plan,
+		"propagated": propagated,
+	})
+	return plan, propagated, nil
+}
+
+// propagateLicensePlan 在事务中将授权方案同步到其现有授权，返回同步的授权ID，离线授权文件由调用方在提交后重新签发
+// 已占用席位的授权不切换席位模式，已吊销或转移的授权不同步；
+// 方案的设备数上限低于授权已占用的设备数时拒绝同步，返回超出的授权码
+func propagateLicensePlan(tx *gorm.DB, plan *model.LicensePlan) ([]string, error) {
+	featuresJSON, err := json.Marshal([]string(plan.Features))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal features: %v", err)
+	}
+
+	var licenseIDs []string
+	query := tx.Model(&model.License{}).
+		Where("plan_id = ? AND deleted = ?", plan.ID, false).
+		Where("status NOT IN ?", []model.LicenseStatus{model.LicenseStatusRevoked, model.LicenseStatusTransferred})
+	if err := query.Session(&gorm.Session{}).Pluck("id", &licenseIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get plan licenses: %v", err)
+	}
+	if len(licenseIDs) == 0 {
+		return nil, nil
+	}
+
+	seats := plan.MaxDevices
+	if seats <= 0 {
+		seats = 1
+	}
+	var overAllocated []string
+	if err := tx.Model(&model.License{}).
+		Where("id IN ? AND used_devices > ?", licenseIDs, seats).
+		Pluck("code", &overAllocated).Error; err != nil {
+		return nil, fmt.Errorf("failed to check plan license seats: %v", err)
+	}
+	if len(overAllocated) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLicensePlanSeatsInUse, strings.Join(overAllocated, ", "))
+	}
+
+	before := make(map[string]*model.LicenseSnapshot, len(licenseIDs))
+	for _, licenseID := range licenseIDs {
+		snapshot, err := loadLicenseSnapshot(tx, licenseID)
+		if err != nil {
+			return nil, err
+		}
+		before[licenseID] = snapshot
+	}
+
+	if err := tx.Model(&model.License{}).Where("id IN ?", licenseIDs).
+		Updates(map[string]interface{}{
+			"type":              plan.Type,
+			"max_devices":       plan.MaxDevices,
+			"lease_duration":    plan.LeaseDuration,
+			"features":          string(featuresJSON),
+			"usage_limit":       plan.UsageLimit,
+			"grace_period_days": plan.GracePeriodDays,
+			"updated_at":        time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update plan licenses: %v", err)
+	}
+	if err := tx.Model(&model.License{}).
+		Where("id IN ? AND used_devices = 0", licenseIDs).
+		Update("seat_mode", plan.SeatMode).Error; err != nil {
+		return nil, fmt.Errorf("failed to update plan seat mode: %v", err)
+	}
+
+	for _, licenseID := range licenseIDs {
+		if _, err := recordLicenseVersion(tx, licenseID, before[licenseID], &model.LicenseVersion{
+			Action: model.LicenseChangePlan,
+			Reason: "license plan " + plan.Name + " updated",
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return licenseIDs, nil
+}
+
+// DeleteLicensePlan 删除授权方案，已有授权使用的方案不允许删除
+func DeleteLicensePlan(id string, operatorID, operatorName string) error {
+	if _, err := GetLicensePlan(id); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := database.GetDB().Model(&model.License{}).
+		Where("plan_id = ? AND deleted = ?", id, false).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count plan licenses: %v", err)
+	}
+	if count > 0 {
+		return ErrLicensePlanInUse
+	}
+
+	if err := database.GetDB().Delete(&model.LicensePlan{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete license plan: %v", err)
+	}
+
+	LogOperation(operatorID, operatorName, "delete", "license_plan", id, nil)
+	return nil
+}
+
+// CreateLicenseFromPlan 按授权方案为客户创建授权
+func CreateLicenseFromPlan(req *LicenseFromPlanRequest, operatorID, operatorName string) (*model.License, error) {
+	plan, err := GetLicensePlan(req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateLicenseRelations(req.CustomerID, ""); err != nil {
+		return nil, err
+	}
+
+	startTime := req.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	expireTime := startTime.AddDate(0, 0, plan.DurationDays)
+
+	// 方案条款随授权记录一次写入，签发的离线授权文件即包含方案的宽限期
+	license, err := GenerateLicenseWithOptions(plan.ProductID, plan.Type, plan.MaxDevices, startTime, expireTime, "", []string(plan.Features), plan.UsageLimit,
+		LicenseOptions{
+			SeatMode:      plan.SeatMode,
+			LeaseDuration: plan.LeaseDuration,
+			CustomerID:    req.CustomerID,
+			PlanID:        plan.ID,
+			GracePeriod:   plan.GracePeriodDays,
+			Description:   req.Description,
+			CreatedBy:     operatorID,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	license, err = GetLicenseByID(license.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "create_from_plan", "license", license.ID, map[string]string{
+		"plan_id":     plan.ID,
+		"customer_id": req.CustomerID,
+	})
+	return license, nil
+}
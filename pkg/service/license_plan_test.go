@@ -0,0 +1,126 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestPlan 为产品创建允许两台设备、宽限期5天的授权方案
+func createTestPlan(t *testing.T, productID string) *model.LicensePlan {
+	grace := 5
+	plan, err := CreateLicensePlan(productID, &LicensePlanRequest{
+		Name:            "Pro",
+		Type:            model.LicenseTypeStandard,
+		DurationDays:    30,
+		MaxDevices:      2,
+		Features:        []string{"export", "max_users=10"},
+		GracePeriodDays: &grace,
+	}, "", "")
+	require.NoError(t, err)
+	return plan
+}
+
+func TestCreateLicenseFromPlan(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-plan")
+	customer := createTestCustomer(t, "c-plan")
+	plan := createTestPlan(t, product.ID)
+
+	// 客户不存在时不创建授权
+	_, err := CreateLicenseFromPlan(&LicenseFromPlanRequest{PlanID: plan.ID, CustomerID: "c-missing"}, "", "")
+	assert.Error(t, err)
+	var count int64
+	require.NoError(t, database.GetDB().Model(&model.License{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	license, err := CreateLicenseFromPlan(&LicenseFromPlanRequest{PlanID: plan.ID, CustomerID: customer.ID}, "admin", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, plan.ID, license.PlanID)
+	assert.Equal(t, customer.ID, license.CustomerID)
+	assert.Equal(t, "admin", license.CreatedBy)
+
+	// 首次签发的授权文件即包含方案的宽限期
+	var file model.LicenseFile
+	require.NoError(t, json.Unmarshal([]byte(license.SignedFile), &file))
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	claims, err := utils.VerifyLicenseFile(&file, publicKey, "")
+	require.NoError(t, err)
+	require.NotNil(t, claims.GraceExpireTime)
+	assert.Equal(t, license.ExpireTime.AddDate(0, 0, 5).Unix(), claims.GraceExpireTime.Unix())
+}
+
+func TestPropagatePlanSeatsInUse(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-plan")
+	plan := createTestPlan(t, product.ID)
+	license, err := CreateLicenseFromPlan(&LicenseFromPlanRequest{PlanID: plan.ID}, "", "")
+	require.NoError(t, err)
+	for _, serial := range []string{"001", "002"} {
+		device, _ := registerTestDevice(t, serial)
+		_, err := ActivateLicense(license.Code, device.ID, "")
+		require.NoError(t, err)
+	}
+
+	// 设备数上限低于已占用的设备数时拒绝同步，方案和授权都不变
+	req := &LicensePlanRequest{Name: "Pro", Type: model.LicenseTypeStandard, DurationDays: 30, MaxDevices: 1}
+	_, _, err = UpdateLicensePlan(plan.ID, req, true, "", "")
+	assert.ErrorIs(t, err, ErrLicensePlanSeatsInUse)
+	assert.Contains(t, err.Error(), license.Code)
+	stored, err := GetLicensePlan(plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.MaxDevices)
+	storedLicense, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, storedLicense.MaxDevices)
+
+	req.MaxDevices = 3
+	_, propagated, err := UpdateLicensePlan(plan.ID, req, true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, propagated)
+	storedLicense, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, storedLicense.MaxDevices)
+}
+
+func TestUpdateLicensePlan(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-plan")
+	plan := createTestPlan(t, product.ID)
+	license, err := CreateLicenseFromPlan(&LicenseFromPlanRequest{PlanID: plan.ID}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, product.ID, license.ProductID)
+	assert.Equal(t, 2, license.MaxDevices)
+
+	// 不同步时只更新方案
+	req := &LicensePlanRequest{Name: "Pro", Type: model.LicenseTypeStandard, DurationDays: 30, MaxDevices: 5, Features: []string{"export", "report"}}
+	_, propagated, err := UpdateLicensePlan(plan.ID, req, false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, propagated)
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.MaxDevices)
+
+	// 同步到现有授权，不改变有效期
+	_, propagated, err = UpdateLicensePlan(plan.ID, req, true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, propagated)
+	stored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.MaxDevices)
+	assert.Equal(t, []string{"export", "report"}, stored.Features)
+	assert.True(t, stored.ExpireTime.Equal(license.ExpireTime))
+
+	// 仍有授权使用的方案不能删除
+	assert.ErrorIs(t, DeleteLicensePlan(plan.ID, "", ""), ErrLicensePlanInUse)
+	require.NoError(t, DeleteLicense(license.ID))
+	require.NoError(t, DeleteLicensePlan(plan.ID, "", ""))
+	_, err = GetLicensePlan(plan.ID)
+	assert.ErrorIs(t, err, ErrLicensePlanNotFound)
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 授权搜索分页大小
+const (
+	defaultLicenseSearchLimit = 20
+	maxLicenseSearchLimit     = 200
+	defaultLicenseSearchSort  = "-created_at"
+)
+
+var (
+	ErrInvalidLicenseFilter = errors.New("invalid license filter")
+	ErrInvalidLicenseCursor = errors.New("invalid license cursor")
+)
+
+// licenseSortKind 排序字段的值类型，用于解析游标中的值
+type licenseSortKind int
+
+const (
+	licenseSortString licenseSortKind = iota
+	licenseSortInt
+	licenseSortTime
+)
+
+// licenseSortFields 允许排序的字段
+var licenseSortFields = map[string]licenseSortKind{
+	"created_at":   licenseSortTime,
+	"updated_at":   licenseSortTime,
+	"start_time":   licenseSortTime,
+	"expire_time":  licenseSortTime,
+	"code":         licenseSortString,
+	"type":         licenseSortString,
+	"status":       licenseSortString,
+	"max_devices":  licenseSortInt,
+	"used_devices": licenseSortInt,
+	"usage_count":  licenseSortInt,
+}
+
+// licenseFilterColumns 过滤表达式中按列匹配的字段
+var licenseFilterColumns = map[string]string{
+	"type":     "type",
+	"status":   "status",
+	"customer": "customer_id",
+	"product":  "product_id",
+	"group":    "group_id",
+	"plan":     "plan_id",
+	"code":     "code",
+}
+
+// licenseMetadataKey 元数据字段名，只允许字母、数字、下划线和点
+var licenseMetadataKey = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// licenseSortKey 排序字段及方向
+type licenseSortKey struct {
+	Column string
+	Desc   bool
+}
+
+// licenseCursor 游标记录上一页最后一条授权的排序字段值和ID
+type licenseCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     string            `json:"id"`
+}
+
+// SearchLicenses 按过滤表达式搜索授权，支持多字段排序和基于游标的分页
+// 游标按排序字段的值定位，翻页不受偏移量影响，数据量大时仍能保持稳定的查询速度
+func SearchLicenses(req *model.LicenseSearchRequest) (*model.LicenseSearchResult, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLicenseSearchLimit
+	}
+	if limit > maxLicenseSearchLimit {
+		limit = maxLicenseSearchLimit
+	}
+
+	sortSpec := strings.TrimSpace(req.Sort)
+	if sortSpec == "" {
+		sortSpec = defaultLicenseSearchSort
+	}
+	sortKeys, err := parseLicenseSort(sortSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	query := database.GetDB().Model(&model.License{}).Where("deleted = ?", false)
+	if query, err = applyLicenseFilter(query, req.Query); err != nil {
+		return nil, err
+	}
+
+	result := &model.LicenseSearchResult{Items: []model.License{}}
+	if req.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count licenses: %v", err)
+		}
+		result.Total = &total
+	}
+
+	if req.Cursor != "" {
+		condition, args, err := licenseCursorCondition(req.Cursor, sortSpec, sortKeys)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+	for _, key := range sortKeys {
+		direction := "ASC"
+		if key.Desc {
+			direction = "DESC"
+		}
+		query = query.Order(key.Column + " " + direction)
+	}
+	query = query.Order("id ASC")
+
+	var licenses []model.License
+	if err := query.Preload("Tags").Limit(limit + 1).Find(&licenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to search licenses: %v", err)
+	}
+	if len(licenses) > limit {
+		licenses = licenses[:limit]
+		result.HasMore = true
+		cursor, err := encodeLicenseCursor(sortSpec, sortKeys, &licenses[limit-1])
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = cursor
+	}
+
+	for i := range licenses {
+		if licenses[i].FeaturesStr != "" {
+			if err := json.Unmarshal([]byte(licenses[i].FeaturesStr), &licenses[i].Features); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal features: %v", err)
+			}
+		}
+	}
+	result.Items = licenses
+	return result, nil
+}
+
+// parseLicenseSort 解析排序字段，如 expire_time,-created_at
+func parseLicenseSort(spec string) ([]licenseSortKey, error) {
+	var keys []licenseSortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := licenseSortKey{Column: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := licenseSortFields[key.Column]; !ok {
+			return nil, fmt.Errorf("%w: unsupported sort field %s", ErrInvalidLicenseFilter, key.Column)
+		}
+		if seen[key.Column] {
+			continue
+		}
+		seen[key.Column] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// applyLicenseFilter 将过滤表达式转换为查询条件，各条件之间为且关系
+// 表达式由空格分隔的 field:value 组成，多个值以逗号分隔表示或关系，前缀-表示取反，
+// 不带字段名的词匹配授权码或描述；值中包含空格时使用双引号
+func applyLicenseFilter(query *gorm.DB, expr string) (*gorm.DB, error) {
+	terms, err := tokenizeLicenseFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, term := range terms {
+		negate := strings.HasPrefix(term, "-")
+		term = strings.TrimPrefix(term, "-")
+
+		field, value, hasField := strings.Cut(term, ":")
+		if !hasField {
+			field, value = "", term
+		}
+		field = strings.ToLower(field)
+		if value == "" {
+			return nil, fmt.Errorf("%w: empty value for %s", ErrInvalidLicenseFilter, field)
+		}
+		values := splitFilterValues(value)
+
+		var condition string
+		var args []interface{}
+		switch {
+		case field == "":
+			condition = "(code LIKE ? OR description LIKE ?)"
+			args = []interface{}{"%" + value + "%", "%" + value + "%"}
+		case licenseFilterColumns[field] != "":
+			condition = licenseFilterColumns[field] + " IN ?"
+			args = []interface{}{values}
+		case field == "tag":
+			condition = "id IN (SELECT license_id FROM license_tag_mapping WHERE tag_id IN (SELECT id FROM license_tags WHERE id IN ? OR name IN ?))"
+			args = []interface{}{values, values}
+		case field == "feature":
+			// 功能列表以JSON数组存储，带值的权益(如seats=5)按名称匹配
+			var parts []string
+			for _, feature := range values {
+				parts = append(parts, "(value = ? OR value LIKE ?)")
+				args = append(args, feature, feature+"=%")
+			}
+			condition = "EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(licenses.features) THEN licenses.features ELSE '[]' END) WHERE " +
+				strings.Join(parts, " OR ") + ")"
+		case field == "expiring":
+			days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+			if err != nil || days < 0 {
+				return nil, fmt.Errorf("%w: expiring must be a number of days", ErrInvalidLicenseFilter)
+			}
+			now := time.Now()
+			condition = "expire_time BETWEEN ? AND ?"
+			args = []interface{}{now, now.AddDate(0, 0, days)}
+		case strings.HasPrefix(field, "meta.") || strings.HasPrefix(field, "metadata."):
+			key := field[strings.Index(field, ".")+1:]
+			if !licenseMetadataKey.MatchString(key) {
+				return nil, fmt.Errorf("%w: invalid metadata field %s", ErrInvalidLicenseFilter, key)
+			}
+			condition = "CASE WHEN json_valid(metadata) THEN json_extract(metadata, ?) END IN ?"
+			args = []interface{}{"$." + key, values}
+		default:
+			return nil, fmt.Errorf("%w: unsupported field %s", ErrInvalidLicenseFilter, field)
+		}
+
+		if negate {
+			condition = "NOT (COALESCE(" + condition + ", FALSE))"
+		}
+		query = query.Where(condition, args...)
+	}
+	return query, nil
+}
+
+// tokenizeLicenseFilter 按空格拆分过滤表达式，双引号内的空格保留
+func tokenizeLicenseFilter(expr string) ([]string, error) {
+	var terms []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidLicenseFilter)
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms, nil
+}
+
+// splitFilterValues 拆分逗号分隔的过滤值
+func splitFilterValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// encodeLicenseCursor 根据本页最后一条授权生成下一页游标
+func encodeLicenseCursor(sortSpec string, keys []licenseSortKey, license *model.License) (string, error) {
+	cursor := licenseCursor{Sort: sortSpec, ID: license.ID}
+	for _, key := range keys {
+		value, err := json.Marshal(licenseSortValue(license, key.Column))
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// licenseCursorCondition 将游标转换为定位到其后一条记录的查询条件
+// 排序为(a, b, id)时条件为 a > va OR (a = va AND b > vb) OR (a = va AND b = vb AND id > vid)，降序字段使用 <
+func licenseCursorCondition(encoded string, sortSpec string, keys []licenseSortKey) (string, []interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, ErrInvalidLicenseCursor
+	}
+	var cursor licenseCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || len(cursor.Values) != len(keys) {
+		return "", nil, ErrInvalidLicenseCursor
+	}
+	if cursor.Sort != sortSpec {
+		return "", nil, fmt.Errorf("%w: cursor was created with a different sort", ErrInvalidLicenseCursor)
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		var err error
+		switch licenseSortFields[key.Column] {
+		case licenseSortTime:
+			var t time.Time
+			err = json.Unmarshal(cursor.Values[i], &t)
+			values[i] = t
+		case licenseSortInt:
+			var n int64
+			err = json.Unmarshal(cursor.Values[i], &n)
+			values[i] = n
+		default:
+			var s string
+			err = json.Unmarshal(cursor.Values[i], &s)
+			values[i] = s
+		}
+		if err != nil {
+			return "", nil, ErrInvalidLicenseCursor
+		}
+	}
+
+	var clauses []string
+	var args []interface{}
+	for i := 0; i <= len(keys); i++ {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		if i < len(keys) {
+			op := ">"
+			if keys[i].Desc {
+				op = "<"
+			}
+			parts = append(parts, keys[i].Column+" "+op+" ?")
+			args = append(args, values[i])
+		} else {
+			parts = append(parts, "id > ?")
+			args = append(args, cursor.ID)
+		}
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args, nil
+}
+
+// licenseSortValue 获取授权在排序字段上的值
+func licenseSortValue(license *model.License, column string) interface{} {
+	switch column {
+	case "created_at":
+		return license.CreatedAt
+	case "updated_at":
+		return license.UpdatedAt
+	case "start_time":
+		return license.StartTime
+	case "expire_time":
+		return license.ExpireTime
+	case "code":
+		return license.Code
+	case "type":
+		return string(license.Type)
+	case "status":
+		return string(license.Status)
+	case "max_devices":
+		return int64(license.MaxDevices)
+	case "used_devices":
+		return int64(license.UsedDevices)
+	case "usage_count":
+		return license.UsageCount
+	}
+	return nil
+}
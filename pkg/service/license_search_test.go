@@ -0,0 +1,86 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchLicenses(t *testing.T) {
+	setupTest(t)
+
+	tag, err := CreateLicenseTag("reseller", "#00ff00")
+	require.NoError(t, err)
+
+	var ids []string
+	for i := 0; i < 7; i++ {
+		licenseType := model.LicenseTypeStandard
+		if i%2 == 1 {
+			licenseType = model.LicenseTypePro
+		}
+		features := []string{"export"}
+		if i < 3 {
+			features = append(features, "seats=5")
+		}
+		license, err := GenerateLicense(licenseType, 1, time.Now(), time.Now().AddDate(0, 0, 10+20*(i%4)), "", features, 0)
+		require.NoError(t, err)
+		ids = append(ids, license.ID)
+	}
+	require.NoError(t, UpdateLicenseMetadata(ids[0], `{"region":"eu","channel":{"name":"web"}}`))
+	require.NoError(t, UpdateLicenseMetadata(ids[1], `{"region":"us"}`))
+	_, _, err = BulkTagLicenses(&BulkTagRequest{LicenseIDs: ids[:4], AddTagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+
+	count := func(query string) int {
+		result, err := SearchLicenses(&model.LicenseSearchRequest{Query: query, Limit: 100})
+		require.NoError(t, err)
+		return len(result.Items)
+	}
+	assert.Equal(t, 3, count("type:pro"))
+	assert.Equal(t, 7, count("type:pro,standard"))
+	assert.Equal(t, 4, count("-type:pro"))
+	assert.Equal(t, 2, count("type:pro tag:reseller"))
+	assert.Equal(t, 3, count("feature:seats"))
+	assert.Equal(t, 2, count("expiring:15"))
+	assert.Equal(t, 1, count("meta.region:eu"))
+	assert.Equal(t, 1, count(`meta.channel.name:"web"`))
+	assert.Equal(t, 6, count("-meta.region:eu"))
+
+	_, err = SearchLicenses(&model.LicenseSearchRequest{Query: "owner:me"})
+	assert.ErrorIs(t, err, ErrInvalidLicenseFilter)
+
+	// 游标分页遍历的结果与一次性查询的顺序一致
+	sort := "-expire_time,type"
+	all, err := SearchLicenses(&model.LicenseSearchRequest{Sort: sort, Limit: 100, WithTotal: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), *all.Total)
+	var paged []string
+	cursor := ""
+	for {
+		page, err := SearchLicenses(&model.LicenseSearchRequest{Sort: sort, Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		for _, license := range page.Items {
+			paged = append(paged, license.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	var expected []string
+	for _, license := range all.Items {
+		expected = append(expected, license.ID)
+	}
+	assert.Equal(t, expected, paged)
+	for i := 1; i < len(all.Items); i++ {
+		assert.False(t, all.Items[i].ExpireTime.After(all.Items[i-1].ExpireTime))
+	}
+
+	first, err := SearchLicenses(&model.LicenseSearchRequest{Sort: sort, Limit: 2})
+	require.NoError(t, err)
+	_, err = SearchLicenses(&model.LicenseSearchRequest{Sort: "code", Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, ErrInvalidLicenseCursor)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrLicenseTagNotFound = errors.New("license tag not found")
+
+// BulkTagRequest 批量为授权添加或移除标签
+type BulkTagRequest struct {
+	LicenseIDs   []string `json:"license_ids" binding:"required"`
+	AddTagIDs    []string `json:"add_tag_ids"`
+	RemoveTagIDs []string `json:"remove_tag_ids"`
+}
+
+// ListLicenseTags 获取授权标签列表及每个标签的授权数
+func ListLicenseTags() ([]model.LicenseTag, error) {
+	tags := []model.LicenseTag{}
+	if err := database.GetDB().Model(&model.LicenseTag{}).
+		Select("license_tags.*, (SELECT COUNT(*) FROM license_tag_mapping WHERE license_tag_mapping.tag_id = license_tags.id) AS license_count").
+		Order("name").
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license tags: %v", err)
+	}
+	return tags, nil
+}
+
+// GetLicenseTag 获取授权标签
+func GetLicenseTag(id string) (*model.LicenseTag, error) {
+	var tag model.LicenseTag
+	if err := database.GetDB().Where("id = ?", id).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLicenseTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get license tag: %v", err)
+	}
+	return &tag, nil
+}
+
+// UpdateLicenseTag 更新授权标签名称和颜色
+func UpdateLicenseTag(id string, name string, color string) (*model.LicenseTag, error) {
+	tag, err := GetLicenseTag(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	tag.Color = color
+	tag.UpdatedAt = time.Now()
+	if err := database.GetDB().Model(tag).Updates(map[string]interface{}{
+		"name":       tag.Name,
+		"color":      tag.Color,
+		"updated_at": tag.UpdatedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update license tag: %v", err)
+	}
+	return tag, nil
+}
+
+// DeleteLicenseTag 删除授权标签及其与授权的关联
+func DeleteLicenseTag(id string) error {
+	if _, err := GetLicenseTag(id); err != nil {
+		return err
+	}
+
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&model.LicenseTagMapping{}).Error; err != nil {
+			return fmt.Errorf("failed to delete license tag mappings: %v", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&model.LicenseTag{}).Error; err != nil {
+			return fmt.Errorf("failed to delete license tag: %v", err)
+		}
+		return nil
+	})
+}
+
+// BulkTagLicenses 在同一事务中为多个授权添加和移除标签，已有的标签不会重复添加
+// 返回新增和移除的关联数
+func BulkTagLicenses(req *BulkTagRequest) (int64, int64, error) {
+	if len(req.LicenseIDs) == 0 {
+		return 0, 0, errors.New("license ids cannot be empty")
+	}
+	if len(req.AddTagIDs) == 0 && len(req.RemoveTagIDs) == 0 {
+		return 0, 0, errors.New("add_tag_ids or remove_tag_ids is required")
+	}
+
+	if len(req.AddTagIDs) > 0 {
+		var count int64
+		if err := database.GetDB().Model(&model.LicenseTag{}).Where("id IN ?", req.AddTagIDs).Count(&count).Error; err != nil {
+			return 0, 0, fmt.Errorf("failed to check license tags: %v", err)
+		}
+		if int(count) != len(uniqueStrings(req.AddTagIDs)) {
+			return 0, 0, ErrLicenseTagNotFound
+		}
+	}
+
+	var licenseIDs []string
+	if err := database.GetDB().Model(&model.License{}).
+		Where("id IN ? AND deleted = ?", req.LicenseIDs, false).
+		Pluck("id", &licenseIDs).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get licenses: %v", err)
+	}
+	if len(licenseIDs) == 0 {
+		return 0, 0, errors.New("no licenses found")
+	}
+
+	var added, removed int64
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if len(req.RemoveTagIDs) > 0 {
+			result := tx.Where("license_id IN ? AND tag_id IN ?", licenseIDs, req.RemoveTagIDs).Delete(&model.LicenseTagMapping{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to remove license tags: %v", result.Error)
+			}
+			removed = result.RowsAffected
+		}
+
+		if len(req.AddTagIDs) > 0 {
+			now := time.Now()
+			mappings := make([]model.LicenseTagMapping, 0, len(licenseIDs)*len(req.AddTagIDs))
+			for _, licenseID := range licenseIDs {
+				for _, tagID := range uniqueStrings(req.AddTagIDs) {
+					mappings = append(mappings, model.LicenseTagMapping{LicenseID: licenseID, TagID: tagID, CreatedAt: now})
+				}
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(mappings, 100)
+			if result.Error != nil {
+				return fmt.Errorf("failed to add license tags: %v", result.Error)
+			}
+			added = result.RowsAffected
+		}
+		return nil
+	})
+	return added, removed, err
+}
+
+// uniqueStrings 去除重复的字符串并保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
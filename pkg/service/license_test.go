@@ -0,0 +1,160 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// editTestLicense 以管理端编辑表单的方式提交授权当前的字段，edit修改需要变更的字段
+func editTestLicense(t *testing.T, licenseID string, edit func(license *model.License)) (*model.License, error) {
+	license, err := GetLicenseByID(licenseID)
+	require.NoError(t, err)
+	form := model.License{
+		ID:          license.ID,
+		Code:        license.Code,
+		Status:      license.Status,
+		CustomerID:  license.CustomerID,
+		ProductID:   license.ProductID,
+		Type:        license.Type,
+		MaxDevices:  license.MaxDevices,
+		Features:    license.Features,
+		Description: license.Description,
+		UsageLimit:  license.UsageLimit,
+		StartTime:   license.StartTime,
+		ExpireTime:  license.ExpireTime,
+	}
+	edit(&form)
+	return UpdateLicenseComprehensive(form, "", "u-1", "admin")
+}
+
+func TestUpdateLicenseKeepsActivationPolicy(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	policy := &model.ActivationPolicy{DeniedCountries: []string{"DE"}}
+	_, err = SetLicenseActivationPolicy(license.ID, policy, "", "")
+	require.NoError(t, err)
+
+	// 编辑表单不包含激活限制，保存后保持不变
+	updated, err := editTestLicense(t, license.ID, func(form *model.License) {
+		form.Description = "edited"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, policy, updated.ActivationPolicy)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", stored.Description)
+	assert.Equal(t, policy, stored.ActivationPolicy)
+}
+
+func TestUpdateLicenseKeepsPartner(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	partner, err := CreatePartner(&PartnerRequest{Name: "Reseller"}, "", "")
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).Update("partner_id", partner.ID).Error)
+
+	// 管理端编辑后授权仍在合作伙伴的范围内
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Description = "edited"
+	})
+	require.NoError(t, err)
+	scoped, err := GetPartnerLicense(partner.ID, license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", scoped.Description)
+
+	versions, err := GetLicenseVersions(license.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+	assert.Equal(t, partner.ID, versions[0].Snapshot.PartnerID)
+}
+
+func TestUpdateLicenseSyncsRevocationList(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	revoked := func() []model.RevocationListEntry {
+		list, err := GetRevocationList(0)
+		require.NoError(t, err)
+		return list.Entries
+	}
+
+	// 编辑为禁用后授权码进入吊销列表，修改授权码时吊销列表随之更新
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Status = model.LicenseStatusDisabled
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []model.RevocationListEntry{{Type: model.RevocationTypeLicense, Value: license.Code, Action: model.RevocationActionAdd}}, revoked())
+
+	newCode, err := GenerateLicenseCode("")
+	require.NoError(t, err)
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Code = newCode
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []model.RevocationListEntry{{Type: model.RevocationTypeLicense, Value: newCode, Action: model.RevocationActionAdd}}, revoked())
+
+	// 恢复为可用状态后移出吊销列表
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Status = model.LicenseStatusUnused
+	})
+	require.NoError(t, err)
+	assert.Empty(t, revoked())
+}
+
+func TestUpdateLicenseValidatesSeats(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 3, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	for _, serial := range []string{"001", "002"} {
+		device, _ := registerTestDevice(t, serial)
+		_, err = ActivateLicense(license.Code, device.ID, "")
+		require.NoError(t, err)
+	}
+
+	// 租约时长不能为负数，最大设备数不能小于已占用的名额
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.LeaseDuration = -1
+	})
+	assert.Error(t, err)
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.MaxDevices = 1
+	})
+	assert.Error(t, err)
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.MaxDevices = 2
+	})
+	require.NoError(t, err)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.MaxDevices)
+	assert.Equal(t, 2, stored.UsedDevices)
+}
+
+func TestUpdateLicenseRejectsExpireTime(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 到期时间只能通过续期修改，提交原到期时间的编辑不受影响
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.ExpireTime = form.ExpireTime.AddDate(1, 0, 0)
+	})
+	assert.ErrorIs(t, err, ErrLicenseExpireTimeEdit)
+	_, err = editTestLicense(t, license.ID, func(form *model.License) {
+		form.Description = "edited"
+	})
+	require.NoError(t, err)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, license.ExpireTime, stored.ExpireTime, time.Second)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUsageLimitReached = errors.New("license usage limit reached")
+
+// 用量统计粒度
+const (
+	UsageGranularityDay   = "day"
+	UsageGranularityMonth = "month"
+)
+
+// ReportUsage 上报授权用量
+// 同一授权下的幂等键只计量一次，重复上报返回首次上报的记录；计数通过条件更新原子递增，超过UsageLimit时拒绝
+func ReportUsage(licenseID string, deviceID string, idempotencyKey string, quantity int64) (*model.MeteredUsage, bool, error) {
+	if idempotencyKey == "" {
+		return nil, false, errors.New("idempotency key is required")
+	}
+	if quantity < 0 {
+		return nil, false, errors.New("quantity cannot be negative")
+	}
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	if existing, err := getMeteredUsage(licenseID, idempotencyKey); err == nil {
+		return existing, true, nil
+	}
+
+	now := time.Now()
+	usage := &model.MeteredUsage{
+		ID:             utils.GenerateUUID(),
+		LicenseID:      licenseID,
+		DeviceID:       deviceID,
+		IdempotencyKey: idempotencyKey,
+		Quantity:       quantity,
+		RecordedAt:     now,
+		CreatedAt:      now,
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.License{}).
+			Where("id = ? AND (usage_limit <= 0 OR usage_count + ? <= usage_limit)", licenseID, quantity).
+			Updates(map[string]interface{}{
+				"usage_count": gorm.Expr("usage_count + ?", quantity),
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update usage count: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrUsageLimitReached
+		}
+
+		if err := tx.Create(usage).Error; err != nil {
+			return fmt.Errorf("failed to create usage record: %v", err)
+		}
+
+		bucket := &model.UsageBucket{
+			ID:          utils.GenerateUUID(),
+			LicenseID:   licenseID,
+			DeviceID:    deviceID,
+			PeriodStart: usagePeriodStart(now),
+			Quantity:    quantity,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "license_id"}, {Name: "device_id"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":   gorm.Expr("quantity + ?", quantity),
+				"updated_at": now,
+			}),
+		}).Create(bucket).Error
+	})
+	if err != nil {
+		// 并发上报同一幂等键时唯一索引冲突，以先写入的记录为准
+		if existing, getErr := getMeteredUsage(licenseID, idempotencyKey); getErr == nil {
+			return existing, true, nil
+		}
+		return nil, false, err
+	}
+	return usage, false, nil
+}
+
+// getMeteredUsage 根据幂等键获取用量记录
+func getMeteredUsage(licenseID string, idempotencyKey string) (*model.MeteredUsage, error) {
+	var usage model.MeteredUsage
+	if err := database.GetDB().
+		Where("license_id = ? AND idempotency_key = ?", licenseID, idempotencyKey).
+		First(&usage).Error; err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// usagePeriodStart 用量所属的统计日(UTC零点)
+func usagePeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetLicenseUsageSummary 按周期和设备汇总授权在指定时间区间内的用量
+func GetLicenseUsageSummary(licenseID string, deviceID string, startTime, endTime time.Time, granularity string) (*model.LicenseUsageSummary, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := "2006-01-02"
+	switch granularity {
+	case "", UsageGranularityDay:
+		granularity = UsageGranularityDay
+	case UsageGranularityMonth:
+		layout = "2006-01"
+	default:
+		return nil, fmt.Errorf("invalid granularity: %s", granularity)
+	}
+
+	query := database.GetDB().Where("license_id = ?", licenseID)
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if !startTime.IsZero() {
+		query = query.Where("period_start >= ?", usagePeriodStart(startTime))
+	}
+	if !endTime.IsZero() {
+		query = query.Where("period_start <= ?", usagePeriodStart(endTime))
+	}
+
+	var buckets []model.UsageBucket
+	if err := query.Order("period_start").Find(&buckets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get usage buckets: %v", err)
+	}
+
+	summary := &model.LicenseUsageSummary{
+		LicenseID:   license.ID,
+		UsageLimit:  license.UsageLimit,
+		UsageCount:  license.UsageCount,
+		Periods:     []model.UsagePeriodStat{},
+		Devices:     []model.DeviceUsageStat{},
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Granularity: granularity,
+	}
+
+	periodIndex := make(map[string]int)
+	deviceIndex := make(map[string]int)
+	for _, bucket := range buckets {
+		summary.Quantity += bucket.Quantity
+
+		period := bucket.PeriodStart.UTC().Format(layout)
+		if i, ok := periodIndex[period]; ok {
+			summary.Periods[i].Quantity += bucket.Quantity
+		} else {
+			periodIndex[period] = len(summary.Periods)
+			summary.Periods = append(summary.Periods, model.UsagePeriodStat{Period: period, Quantity: bucket.Quantity})
+		}
+
+		if i, ok := deviceIndex[bucket.DeviceID]; ok {
+			summary.Devices[i].Quantity += bucket.Quantity
+		} else {
+			deviceIndex[bucket.DeviceID] = len(summary.Devices)
+			summary.Devices = append(summary.Devices, model.DeviceUsageStat{DeviceID: bucket.DeviceID, Quantity: bucket.Quantity})
+		}
+	}
+
+	for i := range summary.Devices {
+		if device, err := GetDevice(summary.Devices[i].DeviceID); err == nil {
+			summary.Devices[i].DeviceName = device.Name
+		}
+	}
+	sort.Slice(summary.Devices, func(i, j int) bool {
+		return summary.Devices[i].Quantity > summary.Devices[j].Quantity
+	})
+
+	return summary, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportUsage(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypePay, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 5)
+	require.NoError(t, err)
+	first, _ := registerTestDevice(t, "001")
+	second, _ := registerTestDevice(t, "002")
+
+	_, _, err = ReportUsage(license.ID, first.ID, "", 1)
+	assert.Error(t, err)
+	_, _, err = ReportUsage(license.ID, first.ID, "job-0", -1)
+	assert.Error(t, err)
+
+	usage, duplicate, err := ReportUsage(license.ID, first.ID, "job-1", 3)
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+	assert.Equal(t, int64(3), usage.Quantity)
+
+	// 相同幂等键重试不重复计量，返回首次上报的记录
+	retried, duplicate, err := ReportUsage(license.ID, first.ID, "job-1", 3)
+	require.NoError(t, err)
+	assert.True(t, duplicate)
+	assert.Equal(t, usage.ID, retried.ID)
+
+	// 超出用量上限时拒绝，不计入用量
+	_, _, err = ReportUsage(license.ID, second.ID, "job-2", 3)
+	assert.ErrorIs(t, err, ErrUsageLimitReached)
+	_, _, err = ReportUsage(license.ID, second.ID, "job-3", 2)
+	require.NoError(t, err)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), stored.UsageCount)
+
+	summary, err := GetLicenseUsageSummary(license.ID, "", time.Time{}, time.Time{}, UsageGranularityMonth)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), summary.Quantity)
+	require.Len(t, summary.Periods, 1)
+	assert.Equal(t, time.Now().UTC().Format("2006-01"), summary.Periods[0].Period)
+	require.Len(t, summary.Devices, 2)
+	assert.Equal(t, first.ID, summary.Devices[0].DeviceID)
+	assert.Equal(t, int64(3), summary.Devices[0].Quantity)
+	assert.Equal(t, "device-001", summary.Devices[0].DeviceName)
+
+	// 按设备和时间区间过滤
+	summary, err = GetLicenseUsageSummary(license.ID, second.ID, time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), summary.Quantity)
+	assert.Equal(t, UsageGranularityDay, summary.Granularity)
+	summary, err = GetLicenseUsageSummary(license.ID, "", time.Now().AddDate(0, 0, 1), time.Time{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), summary.Quantity)
+
+	_, err = GetLicenseUsageSummary(license.ID, "", time.Time{}, time.Time{}, "week")
+	assert.Error(t, err)
+}
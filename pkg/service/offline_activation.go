@@ -0,0 +1,67 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrOfflineRequestMismatch = errors.New("offline activation request does not match")
+
+// ActivateOffline 处理离线激活请求文件，以离线状态激活设备并返回绑定该设备指纹的签名响应文件
+// 同一设备重复提交时返回重新签发的响应文件，不重复占用设备名额
+func ActivateOffline(licenseID string, req *model.OfflineActivationRequest, operatorID, operatorName string) (*model.OfflineActivationResponse, error) {
+	if req.Version != model.OfflineActivationRequestVersion {
+		return nil, fmt.Errorf("unsupported offline activation request version: %d", req.Version)
+	}
+
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Code != license.Code {
+		return nil, fmt.Errorf("%w: license code", ErrOfflineRequestMismatch)
+	}
+
+	fingerprint := utils.GenerateFingerprint(req.Device.DiskID, req.Device.BIOS, req.Device.Motherboard)
+	if req.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: device fingerprint", ErrOfflineRequestMismatch)
+	}
+
+	device, err := resolveClientDevice(&req.Device, license)
+	if err != nil {
+		return nil, err
+	}
+
+	activation, err := activateLicense(license.Code, device.ID, "", model.ActivationStatusOffline)
+	if err != nil {
+		return nil, err
+	}
+
+	// 激活后授权状态和主设备可能已变化，重新读取后签发
+	license, err = GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := GetActivationLicenseFile(license, activation, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "offline_activate", "license", license.ID, map[string]string{
+		"device_id":     device.ID,
+		"activation_id": activation.ID,
+	})
+
+	return &model.OfflineActivationResponse{
+		Version:      model.OfflineActivationRequestVersion,
+		LicenseID:    license.ID,
+		ActivationID: activation.ID,
+		Code:         license.Code,
+		Fingerprint:  fingerprint,
+		LicenseFile:  file,
+		IssuedAt:     time.Now(),
+	}, nil
+}
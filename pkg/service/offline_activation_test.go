@@ -0,0 +1,88 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOfflineRequest 返回按序号区分的设备生成的离线激活请求
+func newOfflineRequest(code string, serial string) *model.OfflineActivationRequest {
+	device := testClientDevice(serial)
+	return &model.OfflineActivationRequest{
+		Version:     model.OfflineActivationRequestVersion,
+		Code:        code,
+		Device:      device,
+		Fingerprint: utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestOfflineActivationMarksLicenseFile(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-offline")
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 2, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+
+	req := newOfflineRequest(license.Code, "001")
+	resp, err := ActivateOffline(license.ID, req, "", "")
+	require.NoError(t, err)
+	claims, err := utils.VerifyLicenseFile(resp.LicenseFile, publicKey, req.Fingerprint)
+	require.NoError(t, err)
+	assert.True(t, claims.Offline)
+
+	// 在线激活签发的文件不带离线标记
+	data, err := ClientActivate(product.ID, &model.ClientLicenseRequest{Code: license.Code, Device: testClientDevice("002")}, "127.0.0.1")
+	require.NoError(t, err)
+	claims, err = utils.VerifyLicenseFile(data.LicenseFile, publicKey, "")
+	require.NoError(t, err)
+	assert.False(t, claims.Offline)
+}
+
+func TestActivateOffline(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	other, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 篡改指纹、授权码不符和不支持的版本的请求文件被拒绝
+	tampered := newOfflineRequest(license.Code, "001")
+	tampered.Fingerprint = "tampered"
+	_, err = ActivateOffline(license.ID, tampered, "", "")
+	assert.ErrorIs(t, err, ErrOfflineRequestMismatch)
+	_, err = ActivateOffline(license.ID, newOfflineRequest(other.Code, "001"), "", "")
+	assert.ErrorIs(t, err, ErrOfflineRequestMismatch)
+	unsupported := newOfflineRequest(license.Code, "001")
+	unsupported.Version++
+	_, err = ActivateOffline(license.ID, unsupported, "", "")
+	assert.Error(t, err)
+
+	req := newOfflineRequest(license.Code, "001")
+	resp, err := ActivateOffline(license.ID, req, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, req.Fingerprint, resp.Fingerprint)
+
+	// 同一设备重复提交返回同一激活记录，不重复占用名额
+	again, err := ActivateOffline(license.ID, req, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, resp.ActivationID, again.ActivationID)
+
+	activations, err := GetLicenseActivationsByID(license.ID)
+	require.NoError(t, err)
+	require.Len(t, activations, 1)
+	assert.Equal(t, model.ActivationStatusOffline, activations[0].Status)
+
+	// 离线激活占用设备名额
+	device, _ := registerTestDevice(t, "002")
+	_, err = ActivateLicense(license.Code, device.ID, "")
+	assert.ErrorIs(t, err, ErrDeviceLimitReached)
+	_, err = ActivateOffline(license.ID, newOfflineRequest(license.Code, "003"), "", "")
+	assert.ErrorIs(t, err, ErrDeviceLimitReached)
+}
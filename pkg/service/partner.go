@@ -0,0 +1,516 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// partnerRoleID 合作伙伴用户使用的角色
+const partnerRoleID = "role-partner"
+
+var (
+	ErrPartnerNotFound      = errors.New("partner not found")
+	ErrPartnerDisabled      = errors.New("partner is disabled")
+	ErrPartnerQuotaExceeded = errors.New("partner license quota exceeded")
+	ErrPartnerAccessDenied  = errors.New("resource does not belong to this partner")
+)
+
+// PartnerRequest 创建或更新合作伙伴的请求
+type PartnerRequest struct {
+	Name         string `json:"name" binding:"required"`
+	ParentID     string `json:"parent_id"` // 仅创建时有效
+	ContactName  string `json:"contact_name"`
+	ContactEmail string `json:"contact_email"`
+	Status       string `json:"status"` // 仅更新时有效
+}
+
+// PartnerLicenseRequest 合作伙伴按授权方案为客户签发授权的请求
+type PartnerLicenseRequest struct {
+	PlanID      string    `json:"plan_id" binding:"required"`
+	CustomerID  string    `json:"customer_id" binding:"required"`
+	Count       int       `json:"count"`      // 为0时签发1个
+	StartTime   time.Time `json:"start_time"` // 为空时从现在起算
+	Description string    `json:"description"`
+}
+
+// CreatePartner 创建合作伙伴，ParentID不为空时创建其下级合作伙伴
+func CreatePartner(req *PartnerRequest, operatorID, operatorName string) (*model.Partner, error) {
+	if req.ParentID != "" {
+		parent, err := GetPartner(req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Status == model.PartnerStatusDisabled {
+			return nil, ErrPartnerDisabled
+		}
+	}
+
+	partner := &model.Partner{
+		ID:           utils.GenerateUUID(),
+		Name:         req.Name,
+		ParentID:     req.ParentID,
+		ContactName:  req.ContactName,
+		ContactEmail: req.ContactEmail,
+		Status:       model.PartnerStatusActive,
+		CreatedBy:    operatorID,
+	}
+	if err := database.GetDB().Create(partner).Error; err != nil {
+		return nil, fmt.Errorf("failed to create partner: %v", err)
+	}
+
+	LogOperation(operatorID, operatorName, "create", "partner", partner.ID, req)
+	return partner, nil
+}
+
+// UpdatePartner 更新合作伙伴的名称、联系方式和状态，上级关系创建后不可修改
+func UpdatePartner(id string, req *PartnerRequest, operatorID, operatorName string) (*model.Partner, error) {
+	partner, err := GetPartner(id)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{
+		"name":          req.Name,
+		"contact_name":  req.ContactName,
+		"contact_email": req.ContactEmail,
+		"updated_at":    time.Now(),
+	}
+	switch req.Status {
+	case "":
+	case model.PartnerStatusActive, model.PartnerStatusDisabled:
+		updates["status"] = req.Status
+	default:
+		return nil, fmt.Errorf("invalid partner status: %s", req.Status)
+	}
+
+	if err := database.GetDB().Model(partner).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update partner: %v", err)
+	}
+
+	LogOperation(operatorID, operatorName, "update", "partner", partner.ID, req)
+	return GetPartner(id)
+}
+
+// GetPartner 获取合作伙伴
+func GetPartner(id string) (*model.Partner, error) {
+	var partner model.Partner
+	if err := database.GetDB().Where("id = ?", id).First(&partner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPartnerNotFound
+		}
+		return nil, fmt.Errorf("failed to get partner: %v", err)
+	}
+	return &partner, nil
+}
+
+// ListPartners 获取合作伙伴列表，partnerID不为空时只返回该合作伙伴及其全部下级
+func ListPartners(partnerID string) ([]model.Partner, error) {
+	query := database.GetDB().Model(&model.Partner{})
+	if partnerID != "" {
+		ids, err := partnerSubtree(partnerID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	partners := []model.Partner{}
+	if err := query.Order("created_at").Find(&partners).Error; err != nil {
+		return nil, fmt.Errorf("failed to get partners: %v", err)
+	}
+	return partners, nil
+}
+
+// partnerSubtree 返回合作伙伴及其全部下级的ID
+func partnerSubtree(partnerID string) ([]string, error) {
+	ids := []string{partnerID}
+	for level := []string{partnerID}; len(level) > 0; {
+		var children []string
+		if err := database.GetDB().Model(&model.Partner{}).
+			Where("parent_id IN ?", level).
+			Pluck("id", &children).Error; err != nil {
+			return nil, fmt.Errorf("failed to get sub-partners: %v", err)
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	return ids, nil
+}
+
+// GetUserPartnerID 获取用户所属的合作伙伴，内部用户返回空字符串
+func GetUserPartnerID(userID string) (string, error) {
+	var partnerIDs []string
+	if err := database.GetDB().Model(&model.User{}).
+		Where("id = ?", userID).
+		Pluck("partner_id", &partnerIDs).Error; err != nil {
+		return "", fmt.Errorf("failed to get user partner: %v", err)
+	}
+	if len(partnerIDs) == 0 {
+		return "", nil
+	}
+	return partnerIDs[0], nil
+}
+
+// ensurePartnerRole 确保合作伙伴角色存在
+func ensurePartnerRole(tx *gorm.DB) error {
+	role := model.Role{
+		ID:          partnerRoleID,
+		Name:        "合作伙伴",
+		Type:        model.RoleTypePartner,
+		Description: "经销商或合作伙伴，只能为本合作伙伴的客户签发授权",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	return tx.Where("id = ?", partnerRoleID).FirstOrCreate(&role).Error
+}
+
+// AddPartnerUser 将用户设为合作伙伴用户，用户角色改为合作伙伴角色
+func AddPartnerUser(partnerID string, userID string, operatorID, operatorName string) error {
+	if _, err := GetPartner(partnerID); err != nil {
+		return err
+	}
+	if _, err := GetUserByID(userID); err != nil {
+		return err
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := ensurePartnerRole(tx); err != nil {
+			return fmt.Errorf("failed to create partner role: %v", err)
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"partner_id":  partnerID,
+				"role_id":     partnerRoleID,
+				"update_time": time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update user: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
+			return fmt.Errorf("failed to clear user roles: %v", err)
+		}
+		return tx.Create(&model.UserRole{UserID: userID, RoleID: partnerRoleID, CreatedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	LogOperation(operatorID, operatorName, "add_user", "partner", partnerID, map[string]string{"user_id": userID})
+	return nil
+}
+
+// GetPartnerQuotas 获取合作伙伴的授权配额
+func GetPartnerQuotas(partnerID string) ([]model.PartnerQuota, error) {
+	quotas := []model.PartnerQuota{}
+	if err := database.GetDB().Where("partner_id = ?", partnerID).
+		Order("product_id").Order("plan_id").
+		Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("failed to get partner quotas: %v", err)
+	}
+	return quotas, nil
+}
+
+// addPartnerQuota 为合作伙伴的授权方案配额增加quantity个名额，配额不存在时创建
+func addPartnerQuota(tx *gorm.DB, partnerID string, plan *model.LicensePlan, quantity int) (*model.PartnerQuota, error) {
+	var quota model.PartnerQuota
+	err := tx.Where("partner_id = ? AND plan_id = ?", partnerID, plan.ID).First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		quota = model.PartnerQuota{
+			ID:        utils.GenerateUUID(),
+			PartnerID: partnerID,
+			ProductID: plan.ProductID,
+			PlanID:    plan.ID,
+		}
+		err = tx.Create(&quota).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partner quota: %v", err)
+	}
+
+	if quota.Available()+quantity < 0 {
+		return nil, fmt.Errorf("%w: only %d licenses are unused", ErrPartnerQuotaExceeded, quota.Available())
+	}
+	if err := tx.Model(&quota).Updates(map[string]interface{}{
+		"quantity":   gorm.Expr("quantity + ?", quantity),
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update partner quota: %v", err)
+	}
+	quota.Quantity += quantity
+	return &quota, nil
+}
+
+// AllocatePartnerQuota 管理员调整合作伙伴某个授权方案的配额，quantity为负数时收回未使用的名额
+func AllocatePartnerQuota(partnerID string, planID string, quantity int, operatorID, operatorName string) (*model.PartnerQuota, error) {
+	if _, err := GetPartner(partnerID); err != nil {
+		return nil, err
+	}
+	plan, err := GetLicensePlan(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	var quota *model.PartnerQuota
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		quota, err = addPartnerQuota(tx, partnerID, plan, quantity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "allocate_quota", "partner", partnerID, map[string]interface{}{
+		"plan_id":  planID,
+		"quantity": quantity,
+	})
+	return quota, nil
+}
+
+// DelegatePartnerQuota 合作伙伴将自己配额中未使用的名额分配给直属下级
+func DelegatePartnerQuota(partnerID string, childID string, planID string, quantity int, operatorID, operatorName string) (*model.PartnerQuota, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	child, err := GetPartner(childID)
+	if err != nil {
+		return nil, err
+	}
+	if child.ParentID != partnerID {
+		return nil, ErrPartnerAccessDenied
+	}
+	plan, err := GetLicensePlan(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	var quota *model.PartnerQuota
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发分配时不会超出可用名额
+		result := tx.Model(&model.PartnerQuota{}).
+			Where("partner_id = ? AND plan_id = ? AND quantity - used - delegated >= ?", partnerID, planID, quantity).
+			Updates(map[string]interface{}{
+				"delegated":  gorm.Expr("delegated + ?", quantity),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update partner quota: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrPartnerQuotaExceeded
+		}
+		quota, err = addPartnerQuota(tx, childID, plan, quantity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "delegate_quota", "partner", childID, map[string]interface{}{
+		"from":     partnerID,
+		"plan_id":  planID,
+		"quantity": quantity,
+	})
+	return quota, nil
+}
+
+// CreatePartnerCustomer 为合作伙伴创建客户
+func CreatePartnerCustomer(partnerID string, customer *model.Customer) error {
+	partner, err := GetPartner(partnerID)
+	if err != nil {
+		return err
+	}
+	if partner.Status == model.PartnerStatusDisabled {
+		return ErrPartnerDisabled
+	}
+	customer.PartnerID = partnerID
+	return NewCustomerService().CreateCustomer(customer)
+}
+
+// ListPartnerCustomers 获取合作伙伴及其下级的客户
+func ListPartnerCustomers(partnerID string) ([]model.Customer, error) {
+	ids, err := partnerSubtree(partnerID)
+	if err != nil {
+		return nil, err
+	}
+	customers := []model.Customer{}
+	if err := database.GetDB().Where("partner_id IN ?", ids).Order("created_at DESC").Find(&customers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get partner customers: %v", err)
+	}
+	return customers, nil
+}
+
+// CreatePartnerLicenses 合作伙伴按授权方案为本合作伙伴的客户批量签发授权，每个授权占用一个配额名额
+func CreatePartnerLicenses(partnerID string, req *PartnerLicenseRequest, operatorID, operatorName string) ([]*model.License, error) {
+	partner, err := GetPartner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+	if partner.Status == model.PartnerStatusDisabled {
+		return nil, ErrPartnerDisabled
+	}
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	customer, err := NewCustomerService().GetCustomerByID(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer %s not found", req.CustomerID)
+	}
+	if customer.PartnerID != partnerID {
+		return nil, ErrPartnerAccessDenied
+	}
+	plan, err := GetLicensePlan(req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := req.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	codes, err := GenerateLicenseCodes(plan.ProductID, count)
+	if err != nil {
+		return nil, err
+	}
+	// 方案条款和所属合作伙伴随授权记录一次写入，签发的离线授权文件即包含方案的宽限期
+	licenses := make([]*model.License, 0, count)
+	for _, code := range codes {
+		license, err := newLicenseWithOptions(code, plan.ProductID, plan.Type, plan.MaxDevices, startTime,
+			startTime.AddDate(0, 0, plan.DurationDays), "", []string(plan.Features), plan.UsageLimit,
+			LicenseOptions{
+				SeatMode:      plan.SeatMode,
+				LeaseDuration: plan.LeaseDuration,
+				CustomerID:    customer.ID,
+				PlanID:        plan.ID,
+				PartnerID:     partnerID,
+				GracePeriod:   plan.GracePeriodDays,
+				Description:   req.Description,
+				CreatedBy:     operatorID,
+			})
+		if err != nil {
+			return nil, err
+		}
+		licenses = append(licenses, license)
+	}
+
+	// 占用配额和写入授权在同一事务中完成，写入失败时配额不会被占用
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.PartnerQuota{}).
+			Where("partner_id = ? AND plan_id = ? AND quantity - used - delegated >= ?", partnerID, plan.ID, count).
+			Updates(map[string]interface{}{
+				"used":       gorm.Expr("used + ?", count),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to reserve partner quota: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrPartnerQuotaExceeded
+		}
+		if err := tx.Create(&licenses).Error; err != nil {
+			return fmt.Errorf("failed to create licenses: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "create_licenses", "partner", partnerID, map[string]interface{}{
+		"plan_id":     plan.ID,
+		"customer_id": customer.ID,
+		"count":       count,
+	})
+	return licenses, nil
+}
+
+// ListPartnerLicenses 分页获取合作伙伴及其下级签发的授权
+func ListPartnerLicenses(partnerID string, page, pageSize string, customerID string) ([]model.License, int64, error) {
+	ids, err := partnerSubtree(partnerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset, limit := utils.GetPagination(page, pageSize)
+	query := database.GetDB().Model(&model.License{}).Where("partner_id IN ? AND deleted = ?", ids, false)
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count partner licenses: %v", err)
+	}
+	licenses := []model.License{}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&licenses).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get partner licenses: %v", err)
+	}
+	return licenses, total, nil
+}
+
+// GetPartnerLicense 获取合作伙伴或其下级签发的授权，其他授权返回ErrPartnerAccessDenied
+func GetPartnerLicense(partnerID string, licenseID string) (*model.License, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+	if license.Deleted {
+		return nil, errors.New("license not found")
+	}
+	ids, err := partnerSubtree(partnerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if license.PartnerID == id {
+			return license, nil
+		}
+	}
+	return nil, ErrPartnerAccessDenied
+}
+
+// GetPartnerConsumption 按合作伙伴统计配额消耗和签发的授权，partnerID不为空时只统计该合作伙伴及其下级
+func GetPartnerConsumption(partnerID string) ([]model.PartnerConsumption, error) {
+	partners, err := ListPartners(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.GetDB()
+	consumption := make([]model.PartnerConsumption, 0, len(partners))
+	for _, partner := range partners {
+		item := model.PartnerConsumption{Partner: partner}
+		if item.Quotas, err = GetPartnerQuotas(partner.ID); err != nil {
+			return nil, err
+		}
+		for _, quota := range item.Quotas {
+			item.Quantity += quota.Quantity
+			item.Used += quota.Used
+			item.Delegated += quota.Delegated
+		}
+		if err := db.Model(&model.License{}).
+			Where("partner_id = ? AND deleted = ?", partner.ID, false).
+			Count(&item.LicenseCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to count partner licenses: %v", err)
+		}
+		if err := db.Model(&model.License{}).
+			Where("partner_id = ? AND deleted = ? AND used_devices > 0", partner.ID, false).
+			Count(&item.ActiveLicenses).Error; err != nil {
+			return nil, fmt.Errorf("failed to count partner licenses: %v", err)
+		}
+		if err := db.Model(&model.Customer{}).
+			Where("partner_id = ?", partner.ID).
+			Count(&item.CustomerCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to count partner customers: %v", err)
+		}
+		consumption = append(consumption, item)
+	}
+	return consumption, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartnerQuotas(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-partner")
+
+	plan, err := CreateLicensePlan(product.ID, &LicensePlanRequest{
+		Name:         "Reseller",
+		Type:         model.LicenseTypeStandard,
+		DurationDays: 365,
+		MaxDevices:   1,
+	}, "", "")
+	require.NoError(t, err)
+
+	distributor, err := CreatePartner(&PartnerRequest{Name: "Distributor"}, "", "")
+	require.NoError(t, err)
+	reseller, err := CreatePartner(&PartnerRequest{Name: "Reseller", ParentID: distributor.ID}, "", "")
+	require.NoError(t, err)
+	_, err = AllocatePartnerQuota(distributor.ID, plan.ID, 5, "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, CreatePartnerCustomer(distributor.ID, &model.Customer{ID: "customer-d", Name: "Distributor Customer"}))
+	require.NoError(t, CreatePartnerCustomer(reseller.ID, &model.Customer{ID: "customer-r", Name: "Reseller Customer"}))
+	require.NoError(t, NewCustomerService().CreateCustomer(&model.Customer{ID: "customer-direct", Name: "Direct Customer"}))
+
+	// 合作伙伴只能为自己的客户签发授权
+	licenses, err := CreatePartnerLicenses(distributor.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-d", Count: 2}, "", "")
+	require.NoError(t, err)
+	require.Len(t, licenses, 2)
+	assert.Equal(t, distributor.ID, licenses[0].PartnerID)
+	assert.Equal(t, "customer-d", licenses[0].CustomerID)
+	assert.Equal(t, plan.ID, licenses[0].PlanID)
+	for _, customerID := range []string{"customer-r", "customer-direct"} {
+		_, err = CreatePartnerLicenses(distributor.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: customerID}, "", "")
+		assert.ErrorIs(t, err, ErrPartnerAccessDenied)
+	}
+
+	// 上级从自己的配额中分配给下级，签发和分配都不能超出可用名额
+	_, err = DelegatePartnerQuota(distributor.ID, reseller.ID, plan.ID, 2, "", "")
+	require.NoError(t, err)
+	_, err = DelegatePartnerQuota(distributor.ID, reseller.ID, plan.ID, 2, "", "")
+	assert.ErrorIs(t, err, ErrPartnerQuotaExceeded)
+	_, err = CreatePartnerLicenses(reseller.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-r", Count: 3}, "", "")
+	assert.ErrorIs(t, err, ErrPartnerQuotaExceeded)
+	_, err = CreatePartnerLicenses(reseller.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-r", Count: 2}, "", "")
+	require.NoError(t, err)
+	_, err = CreatePartnerLicenses(distributor.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-d", Count: 2}, "", "")
+	assert.ErrorIs(t, err, ErrPartnerQuotaExceeded)
+
+	// 上级可以看到下级签发的授权，下级看不到上级的
+	_, total, err := ListPartnerLicenses(distributor.ID, "1", "20", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	_, total, err = ListPartnerLicenses(reseller.ID, "1", "20", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	_, err = GetPartnerLicense(reseller.ID, licenses[0].ID)
+	assert.ErrorIs(t, err, ErrPartnerAccessDenied)
+
+	consumption, err := GetPartnerConsumption("")
+	require.NoError(t, err)
+	require.Len(t, consumption, 2)
+	assert.Equal(t, distributor.ID, consumption[0].Partner.ID)
+	assert.Equal(t, 5, consumption[0].Quantity)
+	assert.Equal(t, 2, consumption[0].Used)
+	assert.Equal(t, 2, consumption[0].Delegated)
+	assert.Equal(t, int64(2), consumption[0].LicenseCount)
+	assert.Equal(t, 2, consumption[1].Used)
+
+	// 合作伙伴用户关联到所属合作伙伴
+	require.NoError(t, database.GetDB().Create(&model.User{ID: "u-partner", Username: "partner", Password: "-"}).Error)
+	require.NoError(t, AddPartnerUser(reseller.ID, "u-partner", "", ""))
+	partnerID, err := GetUserPartnerID("u-partner")
+	require.NoError(t, err)
+	assert.Equal(t, reseller.ID, partnerID)
+}
+
+func TestCreatePartnerLicensesAtomically(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-partner")
+	grace := 7
+	plan, err := CreateLicensePlan(product.ID, &LicensePlanRequest{
+		Name:            "Floating",
+		Type:            model.LicenseTypeStandard,
+		DurationDays:    365,
+		MaxDevices:      2,
+		SeatMode:        model.LicenseSeatModeFloating,
+		LeaseDuration:   600,
+		GracePeriodDays: &grace,
+	}, "", "")
+	require.NoError(t, err)
+	partner, err := CreatePartner(&PartnerRequest{Name: "Distributor"}, "", "")
+	require.NoError(t, err)
+	_, err = AllocatePartnerQuota(partner.ID, plan.ID, 2, "", "")
+	require.NoError(t, err)
+	require.NoError(t, CreatePartnerCustomer(partner.ID, &model.Customer{ID: "customer-p", Name: "Partner Customer"}))
+
+	// 授权创建时即带有方案条款和所属合作伙伴
+	licenses, err := CreatePartnerLicenses(partner.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-p"}, "", "")
+	require.NoError(t, err)
+	require.Len(t, licenses, 1)
+	stored, err := GetLicenseByID(licenses[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, partner.ID, stored.PartnerID)
+	assert.Equal(t, plan.ID, stored.PlanID)
+	assert.Equal(t, model.LicenseSeatModeFloating, stored.SeatMode)
+	assert.Equal(t, 600, stored.LeaseDuration)
+	require.NotNil(t, stored.GracePeriodDays)
+	assert.Equal(t, grace, *stored.GracePeriodDays)
+
+	// 方案的席位模式无效时不签发授权，也不占用配额
+	require.NoError(t, database.GetDB().Model(&model.LicensePlan{}).Where("id = ?", plan.ID).Update("seat_mode", "shared").Error)
+	_, err = CreatePartnerLicenses(partner.ID, &PartnerLicenseRequest{PlanID: plan.ID, CustomerID: "customer-p"}, "", "")
+	assert.Error(t, err)
+	quotas, err := GetPartnerQuotas(partner.ID)
+	require.NoError(t, err)
+	require.Len(t, quotas, 1)
+	assert.Equal(t, 1, quotas[0].Used)
+	_, total, err := ListPartnerLicenses(partner.ID, "1", "20", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// 已删除的授权不再对合作伙伴可见
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", stored.ID).Update("deleted", true).Error)
+	_, err = GetPartnerLicense(partner.ID, stored.ID)
+	assert.Error(t, err)
+}
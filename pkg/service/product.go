@@ -4,9 +4,15 @@ package service
 import (
 	"LVerity/pkg/database"
 	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 )
 
+var ErrProductHasLicenses = errors.New("product still has licenses")
+
 // ProductService 产品服务接口
 type ProductService interface {
 	GetProducts() ([]model.Product, error)
@@ -14,6 +20,8 @@ type ProductService interface {
 	CreateProduct(product *model.Product) error
 	UpdateProduct(product *model.Product) error
 	DeleteProduct(id string) error
+	GetProductByClientKey(clientKey string) (*model.Product, error)
+	RegenerateClientKey(id string) (*model.Product, error)
 }
 
 // productService 产品服务实现
@@ -44,6 +52,16 @@ func (s *productService) GetProductByID(id string) (*model.Product, error) {
 
 // CreateProduct 创建产品
 func (s *productService) CreateProduct(product *model.Product) error {
+	if err := utils.ValidateLicenseKeyPrefix(product.KeyPrefix); err != nil {
+		return err
+	}
+	if product.ClientKey == "" {
+		clientKey, err := generateClientKey()
+		if err != nil {
+			return fmt.Errorf("生成客户端密钥失败: %w", err)
+		}
+		product.ClientKey = clientKey
+	}
 	if err := database.DB.Create(product).Error; err != nil {
 		return fmt.Errorf("创建产品失败: %w", err)
 	}
@@ -52,16 +70,70 @@ func (s *productService) CreateProduct(product *model.Product) error {
 
 // UpdateProduct 更新产品
 func (s *productService) UpdateProduct(product *model.Product) error {
+	if err := utils.ValidateLicenseKeyPrefix(product.KeyPrefix); err != nil {
+		return err
+	}
 	if err := database.DB.Save(product).Error; err != nil {
 		return fmt.Errorf("更新产品失败: %w", err)
 	}
 	return nil
 }
 
-// DeleteProduct 删除产品
+// DeleteProduct 删除产品，产品下仍有授权时拒绝删除
 func (s *productService) DeleteProduct(id string) error {
+	var count int64
+	if err := database.DB.Model(&model.License{}).
+		Where("product_id = ? AND deleted = ?", id, false).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("统计产品授权失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d", ErrProductHasLicenses, count)
+	}
+
 	if err := database.DB.Delete(&model.Product{}, "id = ?", id).Error; err != nil {
 		return fmt.Errorf("删除产品失败: %w", err)
 	}
 	return nil
 }
+
+// GetProductByClientKey 根据客户端密钥获取产品
+func (s *productService) GetProductByClientKey(clientKey string) (*model.Product, error) {
+	if clientKey == "" {
+		return nil, fmt.Errorf("客户端密钥不能为空")
+	}
+	var product model.Product
+	if err := database.DB.Where("client_key = ?", clientKey).First(&product).Error; err != nil {
+		return nil, fmt.Errorf("获取产品失败: %w", err)
+	}
+	return &product, nil
+}
+
+// RegenerateClientKey 重新生成产品的客户端密钥，旧密钥立即失效
+func (s *productService) RegenerateClientKey(id string) (*model.Product, error) {
+	product, err := s.GetProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	clientKey, err := generateClientKey()
+	if err != nil {
+		return nil, fmt.Errorf("生成客户端密钥失败: %w", err)
+	}
+	product.ClientKey = clientKey
+	product.UpdatedAt = time.Now()
+
+	if err := database.DB.Save(product).Error; err != nil {
+		return nil, fmt.Errorf("更新产品失败: %w", err)
+	}
+	return product, nil
+}
+
+// generateClientKey 生成随机客户端密钥
+func generateClientKey() (string, error) {
+	bytes, err := utils.GenerateRandomBytes(24)
+	if err != nil {
+		return "", err
+	}
+	return "ck_" + hex.EncodeToString(bytes), nil
+}
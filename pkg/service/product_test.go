@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductClientKey(t *testing.T) {
+	setupTest(t)
+	products := NewProductService()
+	product := createTestProduct(t, "p-key")
+	assert.True(t, strings.HasPrefix(product.ClientKey, "ck_"))
+
+	found, err := products.GetProductByClientKey(product.ClientKey)
+	require.NoError(t, err)
+	assert.Equal(t, product.ID, found.ID)
+
+	// 缺少或未知的客户端密钥不能认证
+	_, err = products.GetProductByClientKey("")
+	assert.Error(t, err)
+	_, err = products.GetProductByClientKey("ck_unknown")
+	assert.Error(t, err)
+
+	// 重新生成后旧密钥立即失效
+	regenerated, err := products.RegenerateClientKey(product.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, product.ClientKey, regenerated.ClientKey)
+	_, err = products.GetProductByClientKey(product.ClientKey)
+	assert.Error(t, err)
+	found, err = products.GetProductByClientKey(regenerated.ClientKey)
+	require.NoError(t, err)
+	assert.Equal(t, product.ID, found.ID)
+}
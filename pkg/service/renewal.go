@@ -0,0 +1,233 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LicenseRenewalRequest 授权续期请求，ExtendDays与ExpireTime二选一
+type LicenseRenewalRequest struct {
+	ExtendDays int       `json:"extend_days"` // 续期天数，从当前到期时间起算，已过期时从现在起算
+	ExpireTime time.Time `json:"expire_time"` // 指定新的到期时间
+	Reason     string    `json:"reason"`
+	OrderRef   string    `json:"order_ref"`
+}
+
+// BulkRenewalRequest 按授权组或标签批量续期
+type BulkRenewalRequest struct {
+	LicenseRenewalRequest
+	GroupID string `json:"group_id"`
+	TagID   string `json:"tag_id"`
+}
+
+// RenewLicense 续期授权，追加有效期记录，恢复宽限期或已过期的授权并重新签发离线授权文件
+func RenewLicense(licenseID string, req *LicenseRenewalRequest, operatorID, operatorName string) (*model.LicenseTerm, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch license.Status {
+	case model.LicenseStatusRevoked, model.LicenseStatusTransferred:
+		return nil, fmt.Errorf("license is %s and cannot be renewed", license.Status)
+	}
+
+	now := time.Now()
+	start := license.ExpireTime
+	if start.Before(now) {
+		start = now
+	}
+
+	var end time.Time
+	switch {
+	case !req.ExpireTime.IsZero():
+		end = req.ExpireTime
+	case req.ExtendDays > 0:
+		end = start.AddDate(0, 0, req.ExtendDays)
+	default:
+		return nil, errors.New("extend_days or expire_time is required")
+	}
+	if !end.After(license.ExpireTime) || !end.After(now) {
+		return nil, errors.New("new expire time must be later than the current expire time and now")
+	}
+
+	term := &model.LicenseTerm{
+		ID:           utils.GenerateUUID(),
+		LicenseID:    license.ID,
+		StartTime:    start,
+		EndTime:      end,
+		Reason:       req.Reason,
+		OrderRef:     req.OrderRef,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+		CreatedAt:    now,
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		before, err := loadLicenseSnapshot(tx, license.ID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.LicenseTerm{}).Where("license_id = ?", license.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count license terms: %v", err)
+		}
+		// 首次续期时补记初始有效期
+		if count == 0 {
+			if err := tx.Create(&model.LicenseTerm{
+				ID:        utils.GenerateUUID(),
+				LicenseID: license.ID,
+				Sequence:  1,
+				StartTime: license.StartTime,
+				EndTime:   license.ExpireTime,
+				Reason:    "initial term",
+				CreatedAt: license.CreatedAt,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to create initial term: %v", err)
+			}
+			count = 1
+		}
+		term.Sequence = int(count) + 1
+		if err := tx.Create(term).Error; err != nil {
+			return fmt.Errorf("failed to create license term: %v", err)
+		}
+
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+			Updates(map[string]interface{}{"expire_time": end, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to update expire time: %v", err)
+		}
+
+		// 宽限期或已过期的授权恢复为可用状态
+		if license.Status == model.LicenseStatusGrace || license.Status == model.LicenseStatusExpired {
+			restored := model.LicenseStatusUnused
+			if license.UsedDevices > 0 {
+				restored = model.LicenseStatusUsed
+			}
+			if _, err := transitionLicenseStatus(tx, license.ID, license.Status, restored, "license renewed"); err != nil {
+				return err
+			}
+		}
+
+		_, err = recordLicenseVersion(tx, license.ID, before, &model.LicenseVersion{
+			Action:       model.LicenseChangeRenew,
+			Reason:       req.Reason,
+			OperatorID:   operatorID,
+			OperatorName: operatorName,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reissueLicenseFiles(license.ID); err != nil {
+		log.Printf("Failed to reissue license files for %s: %v", license.ID, err)
+	}
+
+	LogOperation(operatorID, operatorName, "renew", "license", license.ID, term)
+	return term, nil
+}
+
+// reissueLicenseFiles 重新签发授权及其所有占用名额的激活(含离线激活)的离线授权文件
+func reissueLicenseFiles(licenseID string) error {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return err
+	}
+
+	if err := signLicenseFile(license, ""); err != nil {
+		return err
+	}
+	if err := database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).
+		Update("signed_file", license.SignedFile).Error; err != nil {
+		return fmt.Errorf("failed to save license file: %v", err)
+	}
+
+	var activations []model.LicenseActivation
+	if err := database.GetDB().
+		Where("license_id = ? AND status IN ?", license.ID, seatActivationStatuses).
+		Find(&activations).Error; err != nil {
+		return fmt.Errorf("failed to get license activations: %v", err)
+	}
+	for i := range activations {
+		fingerprint, err := GetDeviceFingerprint(activations[i].DeviceID)
+		if err != nil {
+			return err
+		}
+		if _, err := GetActivationLicenseFile(license, &activations[i], fingerprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkRenewLicenses 批量续期授权组或标签下的授权，单个授权失败不影响其他授权
+func BulkRenewLicenses(req *BulkRenewalRequest, operatorID, operatorName string) ([]model.LicenseRenewalResult, error) {
+	if req.GroupID == "" && req.TagID == "" {
+		return nil, errors.New("group_id or tag_id is required")
+	}
+
+	query := database.GetDB().Model(&model.License{}).
+		Where("deleted = ?", false).
+		Where("status NOT IN ?", []model.LicenseStatus{model.LicenseStatusRevoked, model.LicenseStatusTransferred})
+	if req.GroupID != "" {
+		query = query.Where("group_id = ?", req.GroupID)
+	}
+	if req.TagID != "" {
+		query = query.Where("id IN (?)", database.GetDB().Table("license_tag_mapping").Select("license_id").Where("tag_id = ?", req.TagID))
+	}
+
+	var licenses []model.License
+	if err := query.Find(&licenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get licenses: %v", err)
+	}
+
+	results := make([]model.LicenseRenewalResult, 0, len(licenses))
+	for _, license := range licenses {
+		result := model.LicenseRenewalResult{LicenseID: license.ID, Code: license.Code}
+		term, err := RenewLicense(license.ID, &req.LicenseRenewalRequest, operatorID, operatorName)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+			result.Term = term
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GetLicenseTerms 获取授权的有效期历史，未续期过的授权返回初始有效期
+func GetLicenseTerms(licenseID string) ([]model.LicenseTerm, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []model.LicenseTerm{}
+	if err := database.GetDB().
+		Where("license_id = ?", licenseID).
+		Order("sequence").
+		Find(&terms).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license terms: %v", err)
+	}
+	if len(terms) == 0 {
+		terms = append(terms, model.LicenseTerm{
+			LicenseID: license.ID,
+			Sequence:  1,
+			StartTime: license.StartTime,
+			EndTime:   license.ExpireTime,
+			Reason:    "initial term",
+			CreatedAt: license.CreatedAt,
+		})
+	}
+	return terms, nil
+}
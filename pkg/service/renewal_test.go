@@ -0,0 +1,128 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewalReissuesOfflineActivation(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device := testClientDevice("001")
+	fingerprint := utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard)
+	resp, err := ActivateOffline(license.ID, &model.OfflineActivationRequest{
+		Version:     model.OfflineActivationRequestVersion,
+		Code:        license.Code,
+		Device:      device,
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	}, "", "")
+	require.NoError(t, err)
+
+	term, err := RenewLicense(license.ID, &LicenseRenewalRequest{ExtendDays: 30}, "", "")
+	require.NoError(t, err)
+
+	// 续期后离线激活保存的授权文件按新的到期时间重新签发
+	var activation model.LicenseActivation
+	require.NoError(t, database.GetDB().Where("id = ?", resp.ActivationID).First(&activation).Error)
+	var file model.LicenseFile
+	require.NoError(t, json.Unmarshal([]byte(activation.SignedFile), &file))
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	claims, err := utils.VerifyLicenseFile(&file, publicKey, fingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, term.EndTime.Unix(), claims.ExpireTime.Unix())
+	assert.True(t, claims.Offline)
+}
+
+func TestRenewLicense(t *testing.T) {
+	setupTest(t)
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+
+	// 未续期过的授权返回初始有效期
+	terms, err := GetLicenseTerms(license.ID)
+	require.NoError(t, err)
+	require.Len(t, terms, 1)
+	assert.Equal(t, 1, terms[0].Sequence)
+
+	_, err = RenewLicense(license.ID, &LicenseRenewalRequest{}, "", "")
+	assert.Error(t, err)
+	_, err = RenewLicense(license.ID, &LicenseRenewalRequest{ExpireTime: time.Now().AddDate(0, 0, 1)}, "", "")
+	assert.Error(t, err)
+
+	// 已过期的授权从现在起算续期并恢复为可用状态
+	setTestExpireTime(t, license.ID, time.Now().AddDate(0, 0, -30))
+	_, _, err = ProcessLicenseExpiry()
+	require.NoError(t, err)
+	term, err := RenewLicense(license.ID, &LicenseRenewalRequest{ExtendDays: 30, OrderRef: "SO-1001"}, "u-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 2, term.Sequence)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), term.EndTime, time.Minute)
+
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusUnused, stored.Status)
+	assert.True(t, stored.ExpireTime.Equal(term.EndTime))
+
+	terms, err = GetLicenseTerms(license.ID)
+	require.NoError(t, err)
+	require.Len(t, terms, 2)
+	assert.Equal(t, "initial term", terms[0].Reason)
+	assert.Equal(t, "SO-1001", terms[1].OrderRef)
+
+	transitions, err := GetLicenseStatusTransitions(license.ID)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, model.LicenseStatusExpired, transitions[0].ToStatus)
+	assert.Equal(t, model.LicenseStatusUnused, transitions[1].ToStatus)
+	assert.Equal(t, "license renewed", transitions[1].Reason)
+
+	// 已吊销的授权不能续期
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).
+		Update("status", model.LicenseStatusRevoked).Error)
+	_, err = RenewLicense(license.ID, &LicenseRenewalRequest{ExtendDays: 30}, "", "")
+	assert.Error(t, err)
+}
+
+func TestBulkRenewLicenses(t *testing.T) {
+	setupTest(t)
+	expireTime := time.Now().AddDate(0, 1, 0)
+	first, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), expireTime, "group-1", nil, 0)
+	require.NoError(t, err)
+	second, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), expireTime, "group-1", nil, 0)
+	require.NoError(t, err)
+	other, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), expireTime, "group-2", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", second.ID).
+		Update("expire_time", time.Now().AddDate(0, 2, 0)).Error)
+
+	_, err = BulkRenewLicenses(&BulkRenewalRequest{}, "", "")
+	assert.Error(t, err)
+
+	// 单个授权失败不影响其他授权
+	results, err := BulkRenewLicenses(&BulkRenewalRequest{
+		LicenseRenewalRequest: LicenseRenewalRequest{ExpireTime: time.Now().AddDate(0, 1, 15)},
+		GroupID:               "group-1",
+	}, "", "")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	succeeded := map[string]bool{}
+	for _, result := range results {
+		succeeded[result.LicenseID] = result.Success
+	}
+	assert.True(t, succeeded[first.ID])
+	assert.False(t, succeeded[second.ID])
+
+	stored, err := GetLicenseByID(other.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.ExpireTime.Equal(other.ExpireTime))
+}
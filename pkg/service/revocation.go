@@ -0,0 +1,208 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokeLicenseCode 将授权码加入吊销列表
+func RevokeLicenseCode(code string, reason string) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return addRevocation(tx, model.RevocationTypeLicense, code, model.RevocationActionAdd, reason)
+	})
+}
+
+// RevokeDeviceFingerprint 将设备的硬件指纹加入吊销列表
+func RevokeDeviceFingerprint(deviceID string, reason string) error {
+	fingerprint, err := deviceRevocationFingerprint(deviceID)
+	if err != nil || fingerprint == "" {
+		return err
+	}
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return addRevocation(tx, model.RevocationTypeDevice, fingerprint, model.RevocationActionAdd, reason)
+	})
+}
+
+// RestoreDeviceFingerprint 将设备的硬件指纹从吊销列表移除
+func RestoreDeviceFingerprint(deviceID string, reason string) error {
+	fingerprint, err := deviceRevocationFingerprint(deviceID)
+	if err != nil || fingerprint == "" {
+		return err
+	}
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return addRevocation(tx, model.RevocationTypeDevice, fingerprint, model.RevocationActionRemove, reason)
+	})
+}
+
+// deviceRevocationFingerprint 获取用于吊销的设备指纹，设备没有硬件信息时返回空，避免吊销所有无硬件信息的设备
+func deviceRevocationFingerprint(deviceID string) (string, error) {
+	device, err := GetDevice(deviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get device: %v", err)
+	}
+	if device.DiskID == "" && device.BIOS == "" && device.Motherboard == "" {
+		return "", nil
+	}
+	return utils.GenerateFingerprint(device.DiskID, device.BIOS, device.Motherboard), nil
+}
+
+// addRevocation 在事务中追加吊销列表变更，版本号由锁定的计数行分配；对象状态未变化时不追加
+// tx必须是事务，计数行的锁持有到事务结束
+func addRevocation(tx *gorm.DB, entryType model.RevocationType, value string, action model.RevocationAction, reason string) error {
+	if value == "" {
+		return errors.New("revocation value is required")
+	}
+
+	// 先锁定版本计数行，并发事务按顺序检查对象状态并分配版本号
+	counter, err := lockRevocationCounter(tx)
+	if err != nil {
+		return err
+	}
+
+	var last model.RevocationEntry
+	err = tx.Where("type = ? AND value = ?", entryType, value).Order("version DESC").First(&last).Error
+	switch {
+	case err == nil:
+		if last.Action == action {
+			return nil
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 从未被吊销的对象无需移除
+		if action == model.RevocationActionRemove {
+			return nil
+		}
+	default:
+		return fmt.Errorf("failed to get revocation entry: %v", err)
+	}
+
+	version := counter.Version + 1
+	if err := tx.Model(counter).Update("version", version).Error; err != nil {
+		return fmt.Errorf("failed to update revocation version: %v", err)
+	}
+	if err := tx.Create(&model.RevocationEntry{
+		ID:        utils.GenerateUUID(),
+		Version:   version,
+		Type:      entryType,
+		Value:     value,
+		Action:    action,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to create revocation entry: %v", err)
+	}
+	return nil
+}
+
+// lockRevocationCounter 以SELECT ... FOR UPDATE锁定吊销列表版本计数行
+// 计数行不存在时按已有变更记录的最大版本号创建，并发创建时以先写入的为准
+func lockRevocationCounter(tx *gorm.DB) (*model.RevocationCounter, error) {
+	var counter model.RevocationCounter
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&counter, model.RevocationCounterID).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock revocation version: %v", err)
+		}
+		return &counter, nil
+	}
+
+	var version int64
+	if err := tx.Model(&model.RevocationEntry{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revocation version: %v", err)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.RevocationCounter{ID: model.RevocationCounterID, Version: version}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create revocation version: %v", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&counter, model.RevocationCounterID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock revocation version: %v", err)
+	}
+	return &counter, nil
+}
+
+// GetRevocationList 获取吊销列表
+// since为0或不是有效的历史版本时返回全量列表，否则返回since之后的增量变更
+func GetRevocationList(since int64) (*model.RevocationList, error) {
+	var version int64
+	if err := database.GetDB().Model(&model.RevocationEntry{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revocation version: %v", err)
+	}
+
+	list := &model.RevocationList{
+		Type:     model.RevocationListType,
+		Version:  version,
+		IssuedAt: time.Now(),
+		Entries:  []model.RevocationListEntry{},
+	}
+
+	query := database.GetDB().Order("version")
+	if since > 0 && since <= version {
+		list.BaseVersion = since
+		query = query.Where("version > ? AND version <= ?", since, version)
+	} else {
+		list.Full = true
+		query = query.Where("version <= ?", version)
+	}
+
+	var entries []model.RevocationEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revocation entries: %v", err)
+	}
+
+	if !list.Full {
+		for _, entry := range entries {
+			list.Entries = append(list.Entries, model.RevocationListEntry{Type: entry.Type, Value: entry.Value, Action: entry.Action})
+		}
+		return list, nil
+	}
+
+	// 全量列表只保留最终仍处于吊销状态的对象
+	latest := make(map[string]model.RevocationEntry)
+	for _, entry := range entries {
+		latest[string(entry.Type)+":"+entry.Value] = entry
+	}
+	for _, entry := range entries {
+		if current := latest[string(entry.Type)+":"+entry.Value]; current.Version == entry.Version && entry.Action == model.RevocationActionAdd {
+			list.Entries = append(list.Entries, model.RevocationListEntry{Type: entry.Type, Value: entry.Value, Action: entry.Action})
+		}
+	}
+	return list, nil
+}
+
+// GetSignedRevocationList 获取使用当前默认签名密钥签名的吊销列表
+func GetSignedRevocationList(since int64) (*model.SignedRevocationList, error) {
+	list, err := GetRevocationList(since)
+	if err != nil {
+		return nil, err
+	}
+
+	_, privateKey, err := selectSigningKey("")
+	if err != nil {
+		return nil, err
+	}
+	return utils.SignRevocationList(list, privateKey)
+}
+
+// GetRevocationEntries 分页获取吊销列表变更记录
+func GetRevocationEntries(page, pageSize int) ([]model.RevocationEntry, int64, error) {
+	var total int64
+	if err := database.GetDB().Model(&model.RevocationEntry{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count revocation entries: %v", err)
+	}
+
+	entries := []model.RevocationEntry{}
+	if err := database.GetDB().
+		Order("version DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get revocation entries: %v", err)
+	}
+	return entries, total, nil
+}
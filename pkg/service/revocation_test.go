@@ -0,0 +1,112 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationList(t *testing.T) {
+	setupTest(t)
+	device, fingerprint := registerTestDevice(t, "001")
+
+	list, err := GetRevocationList(0)
+	require.NoError(t, err)
+	assert.True(t, list.Full)
+	assert.Equal(t, int64(0), list.Version)
+	assert.Empty(t, list.Entries)
+
+	require.NoError(t, RevokeLicenseCode("CODE-1", "refund"))
+	require.NoError(t, RevokeLicenseCode("CODE-1", "refund"))
+	require.NoError(t, RevokeDeviceFingerprint(device.ID, "abuse"))
+	require.NoError(t, RestoreDeviceFingerprint(device.ID, "resolved"))
+	// 对象状态未变化时不追加记录
+	require.NoError(t, RestoreDeviceFingerprint(device.ID, "resolved"))
+
+	// 全量列表只包含仍处于吊销状态的对象
+	list, err = GetRevocationList(0)
+	require.NoError(t, err)
+	assert.True(t, list.Full)
+	assert.Equal(t, int64(3), list.Version)
+	assert.Equal(t, []model.RevocationListEntry{{Type: model.RevocationTypeLicense, Value: "CODE-1", Action: model.RevocationActionAdd}}, list.Entries)
+
+	// 增量列表按顺序包含since之后的全部变更
+	list, err = GetRevocationList(1)
+	require.NoError(t, err)
+	assert.False(t, list.Full)
+	assert.Equal(t, int64(1), list.BaseVersion)
+	assert.Equal(t, []model.RevocationListEntry{
+		{Type: model.RevocationTypeDevice, Value: fingerprint, Action: model.RevocationActionAdd},
+		{Type: model.RevocationTypeDevice, Value: fingerprint, Action: model.RevocationActionRemove},
+	}, list.Entries)
+
+	// 客户端版本超出服务端版本时返回全量列表
+	list, err = GetRevocationList(10)
+	require.NoError(t, err)
+	assert.True(t, list.Full)
+}
+
+func TestSignedRevocationList(t *testing.T) {
+	setupTest(t)
+	// 禁用授权时将授权码加入吊销列表
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, DisableLicense(license.Code))
+
+	file, err := GetSignedRevocationList(0)
+	require.NoError(t, err)
+	publicKey, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	list, err := utils.VerifyRevocationList(file, publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, model.RevocationListType, list.Type)
+	assert.Equal(t, int64(1), list.Version)
+	require.Len(t, list.Entries, 1)
+	assert.Equal(t, license.Code, list.Entries[0].Value)
+}
+
+func TestRevocationVersionCounter(t *testing.T) {
+	setupTest(t)
+	db := database.GetDB()
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	// 升级前已有的变更记录没有计数行，版本号从已有的最大版本继续分配
+	require.NoError(t, RevokeLicenseCode("CODE-1", "refund"))
+	require.NoError(t, db.Where("id = ?", model.RevocationCounterID).Delete(&model.RevocationCounter{}).Error)
+	require.NoError(t, RevokeLicenseCode("CODE-2", "refund"))
+
+	// 并发追加的变更版本号连续且不重复，SQLite串行执行写事务，无法复现并发交错
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- RevokeLicenseCode(fmt.Sprintf("CODE-%d", i+3), "refund")
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var versions []int64
+	require.NoError(t, db.Model(&model.RevocationEntry{}).Order("version").Pluck("version", &versions).Error)
+	require.Len(t, versions, 12)
+	for i, version := range versions {
+		assert.Equal(t, int64(i+1), version)
+	}
+	var counter model.RevocationCounter
+	require.NoError(t, db.First(&counter, model.RevocationCounterID).Error)
+	assert.Equal(t, int64(12), counter.Version)
+}
@@ -270,6 +270,14 @@ func InitDefaultRoles() error {
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		},
+		{
+			ID:          partnerRoleID,
+			Name:        "合作伙伴",
+			Type:        model.RoleTypePartner,
+			Description: "经销商或合作伙伴，只能为本合作伙伴的客户签发授权",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		},
 	}
 
 	for _, role := range defaultRoles {
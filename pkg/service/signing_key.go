@@ -0,0 +1,334 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	ErrNoActiveSigningKey = errors.New("no active signing key")
+	ErrRootSigningKey     = errors.New("root signing key cannot be revoked")
+	ErrLastSigningKey     = errors.New("at least one default signing key must stay active")
+)
+
+// RotateSigningKeyRequest 轮换签名密钥请求
+type RotateSigningKeyRequest struct {
+	ProductID   string     `json:"product_id"`             // 为空时轮换默认密钥
+	ActivatesAt *time.Time `json:"activates_at,omitempty"` // 为空时立即启用
+	Description string     `json:"description"`
+}
+
+// getRootSigningKey 获取根签名密钥
+func getRootSigningKey() (ed25519.PrivateKey, error) {
+	licenseSignerMu.RLock()
+	defer licenseSignerMu.RUnlock()
+
+	if licenseSigningKey == nil {
+		return nil, errors.New("license signing key not initialized")
+	}
+	return licenseSigningKey, nil
+}
+
+// ensureRootSigningKey 将签名密钥文件中的根密钥登记到密钥库，首次启动时作为默认签名密钥
+func ensureRootSigningKey(root ed25519.PrivateKey) error {
+	publicKey := root.Public().(ed25519.PublicKey)
+	keyID := utils.PublicKeyID(publicKey)
+
+	var count int64
+	if err := database.GetDB().Model(&model.SigningKey{}).Where("id = ?", keyID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check root signing key: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	sealed, err := utils.SealSigningKey(root, root)
+	if err != nil {
+		return err
+	}
+	key := &model.SigningKey{
+		ID:          keyID,
+		Algorithm:   model.LicenseFileAlgorithm,
+		PublicKey:   utils.EncodePublicKey(publicKey),
+		PrivateKey:  sealed,
+		Root:        true,
+		Description: "root key",
+		ActivatesAt: time.Now(),
+	}
+	if err := database.GetDB().Create(key).Error; err != nil {
+		return fmt.Errorf("failed to save root signing key: %v", err)
+	}
+	return nil
+}
+
+// selectSigningKey 选择产品当前用于签发的密钥，产品没有生效的专属密钥时使用默认密钥
+// 同一范围内有多个生效的密钥时使用最晚启用的一个
+func selectSigningKey(productID string) (*model.SigningKey, ed25519.PrivateKey, error) {
+	root, err := getRootSigningKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	var key model.SigningKey
+	err = database.GetDB().
+		Where("product_id IN ? AND revoked_at IS NULL AND activates_at <= ?", []string{productID, ""}, now).
+		Where("retires_at IS NULL OR retires_at > ?", now).
+		Order("product_id DESC").Order("activates_at DESC").
+		First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNoActiveSigningKey
+		}
+		return nil, nil, fmt.Errorf("failed to get signing key: %v", err)
+	}
+
+	if key.Root {
+		return &key, root, nil
+	}
+	privateKey, err := utils.OpenSigningKey(key.PrivateKey, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &key, privateKey, nil
+}
+
+// getVerificationKey 按密钥ID获取校验公钥，已停用的密钥仍可校验，已作废的密钥不可用
+func getVerificationKey(keyID string) (ed25519.PublicKey, error) {
+	var key model.SigningKey
+	if err := database.GetDB().Where("id = ? AND revoked_at IS NULL", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get signing key: %v", err)
+	}
+	return utils.ParsePublicKey(key.PublicKey)
+}
+
+// ListSigningKeys 获取签名密钥列表，productID不为空时只返回该产品的专属密钥
+func ListSigningKeys(productID string) ([]model.SigningKey, error) {
+	query := database.GetDB().Model(&model.SigningKey{})
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	keys := []model.SigningKey{}
+	if err := query.Order("product_id").Order("activates_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %v", err)
+	}
+	now := time.Now()
+	for i := range keys {
+		keys[i].Status = keys[i].StatusAt(now)
+	}
+	return keys, nil
+}
+
+// GetSigningKeySet 获取客户端可用的签名公钥集合，包含产品专属密钥和默认密钥，不包含已作废的密钥
+// productID为空时返回全部密钥
+func GetSigningKeySet(productID string) (*model.SigningKeySet, error) {
+	query := database.GetDB().Where("revoked_at IS NULL")
+	if productID != "" {
+		query = query.Where("product_id IN ?", []string{productID, ""})
+	}
+
+	var keys []model.SigningKey
+	if err := query.Order("activates_at").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %v", err)
+	}
+
+	now := time.Now()
+	set := &model.SigningKeySet{Keys: make([]model.SigningKeyJWK, 0, len(keys))}
+	for i := range keys {
+		publicKey, err := utils.ParsePublicKey(keys[i].PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %s: %v", keys[i].ID, err)
+		}
+		keys[i].Status = keys[i].StatusAt(now)
+		set.Keys = append(set.Keys, utils.NewSigningKeyJWK(&keys[i], publicKey))
+	}
+	return set, nil
+}
+
+// GetSignedSigningKeySet 获取根密钥签名的签名公钥集合，同时列出已作废的密钥ID
+// 客户端只信任签名集合中的密钥和状态，无法通过修改本地缓存恢复已作废的密钥
+func GetSignedSigningKeySet(productID string) (*model.SignedSigningKeySet, error) {
+	root, err := getRootSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	set, err := GetSigningKeySet(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := database.GetDB().Model(&model.SigningKey{}).Where("revoked_at IS NOT NULL")
+	if productID != "" {
+		query = query.Where("product_id IN ?", []string{productID, ""})
+	}
+	if err := query.Order("revoked_at").Pluck("id", &set.Revoked).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revoked signing keys: %v", err)
+	}
+
+	now := time.Now()
+	set.Type = model.SigningKeySetType
+	set.IssuedAt = &now
+	return utils.SignSigningKeySet(set, root)
+}
+
+// RotateSigningKey 为产品或默认范围生成新的签名密钥，并在新密钥启用时停用同一范围内原有的密钥
+// 停用的密钥仍可校验此前签发的授权文件
+func RotateSigningKey(req *RotateSigningKeyRequest, operatorID, operatorName string) (*model.SigningKey, error) {
+	root, err := getRootSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if req.ProductID != "" {
+		if _, err := NewProductService().GetProductByID(req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	activatesAt := time.Now()
+	if req.ActivatesAt != nil {
+		if req.ActivatesAt.Before(activatesAt) {
+			return nil, errors.New("activation time must not be in the past")
+		}
+		activatesAt = *req.ActivatesAt
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %v", err)
+	}
+	sealed, err := utils.SealSigningKey(privateKey, root)
+	if err != nil {
+		return nil, err
+	}
+	key := &model.SigningKey{
+		ID:          utils.PublicKeyID(publicKey),
+		Algorithm:   model.LicenseFileAlgorithm,
+		ProductID:   req.ProductID,
+		PublicKey:   utils.EncodePublicKey(publicKey),
+		PrivateKey:  sealed,
+		Endorsement: utils.EndorseSigningKey(publicKey, req.ProductID, root),
+		Description: req.Description,
+		ActivatesAt: activatesAt,
+		CreatedBy:   operatorID,
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SigningKey{}).
+			Where("product_id = ? AND revoked_at IS NULL AND activates_at < ?", req.ProductID, activatesAt).
+			Where("retires_at IS NULL OR retires_at > ?", activatesAt).
+			Update("retires_at", activatesAt).Error; err != nil {
+			return fmt.Errorf("failed to retire signing keys: %v", err)
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create signing key: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "rotate_signing_key", "signing_key", key.ID, map[string]interface{}{
+		"product_id":   key.ProductID,
+		"activates_at": key.ActivatesAt,
+	})
+	key.Status = key.StatusAt(time.Now())
+	return key, nil
+}
+
+// RetireSigningKey 停用签名密钥，retiresAt为空时立即停用；停用后不再签发，已签发的授权文件仍可校验
+func RetireSigningKey(keyID string, retiresAt *time.Time, operatorID, operatorName string) (*model.SigningKey, error) {
+	key, err := getSigningKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("signing key %s is revoked", key.ID)
+	}
+	at := time.Now()
+	if retiresAt != nil && retiresAt.After(at) {
+		at = *retiresAt
+	}
+	if err := ensureDefaultSigningKey(key, at); err != nil {
+		return nil, err
+	}
+
+	if err := database.GetDB().Model(key).Update("retires_at", at).Error; err != nil {
+		return nil, fmt.Errorf("failed to retire signing key: %v", err)
+	}
+	LogOperation(operatorID, operatorName, "retire_signing_key", "signing_key", key.ID, map[string]interface{}{
+		"retires_at": at,
+	})
+	key.RetiresAt = &at
+	key.Status = key.StatusAt(time.Now())
+	return key, nil
+}
+
+// RevokeSigningKey 作废签名密钥，用于私钥泄露等情况
+// 作废后客户端不再信任该密钥，服务端保存的授权文件在下次获取时使用当前密钥重新签发
+func RevokeSigningKey(keyID string, operatorID, operatorName string) (*model.SigningKey, error) {
+	key, err := getSigningKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.Root {
+		return nil, ErrRootSigningKey
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+	now := time.Now()
+	if err := ensureDefaultSigningKey(key, now); err != nil {
+		return nil, err
+	}
+
+	if err := database.GetDB().Model(key).Update("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke signing key: %v", err)
+	}
+	LogOperation(operatorID, operatorName, "revoke_signing_key", "signing_key", key.ID, nil)
+	key.RevokedAt = &now
+	key.Status = key.StatusAt(now)
+	return key, nil
+}
+
+// getSigningKey 按密钥ID获取签名密钥
+func getSigningKey(keyID string) (*model.SigningKey, error) {
+	var key model.SigningKey
+	if err := database.GetDB().Where("id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get signing key: %v", err)
+	}
+	return &key, nil
+}
+
+// ensureDefaultSigningKey 停用或作废默认密钥前检查在at时仍有其他生效的默认密钥，避免无密钥可用于签发
+func ensureDefaultSigningKey(key *model.SigningKey, at time.Time) error {
+	if key.ProductID != "" {
+		return nil
+	}
+	var count int64
+	if err := database.GetDB().Model(&model.SigningKey{}).
+		Where("id <> ? AND product_id = '' AND revoked_at IS NULL AND activates_at <= ?", key.ID, at).
+		Where("retires_at IS NULL OR retires_at > ?", at).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count signing keys: %v", err)
+	}
+	if count == 0 {
+		return ErrLastSigningKey
+	}
+	return nil
+}
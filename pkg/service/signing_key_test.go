@@ -0,0 +1,92 @@
+package service
+
+import (
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activateTestLicense 创建授权并在按序号注册的设备上激活，返回授权和设备指纹
+func activateTestLicense(t *testing.T, productID string, serial string) (*model.License, string) {
+	license, err := GenerateProductLicense(productID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device, fingerprint := registerTestDevice(t, serial)
+	_, err = ActivateLicense(license.Code, device.ID, "")
+	require.NoError(t, err)
+	return license, fingerprint
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-keys")
+	root, err := GetLicensePublicKey()
+	require.NoError(t, err)
+	rootID := utils.PublicKeyID(root)
+	first, firstFingerprint := activateTestLicense(t, "", "001")
+	file, err := GetLicenseFile(first.ID, firstFingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, rootID, file.KeyID)
+
+	// 轮换默认密钥后，原密钥停止签发，此前签发的授权文件不变
+	rotated, err := RotateSigningKey(&RotateSigningKeyRequest{}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.SigningKeyStatusActive, rotated.Status)
+	keys, err := ListSigningKeys("")
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, key := range keys {
+		statuses[key.ID] = key.Status
+	}
+	assert.Equal(t, map[string]string{rootID: model.SigningKeyStatusRetired, rotated.ID: model.SigningKeyStatusActive}, statuses)
+	file, err = GetLicenseFile(first.ID, firstFingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, rootID, file.KeyID)
+
+	second, secondFingerprint := activateTestLicense(t, "", "002")
+	file, err = GetLicenseFile(second.ID, secondFingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, file.KeyID)
+
+	// 根密钥不能作废，唯一生效的默认密钥不能停用
+	_, err = RevokeSigningKey(rootID, "", "")
+	assert.ErrorIs(t, err, ErrRootSigningKey)
+	_, err = RetireSigningKey(rotated.ID, nil, "", "")
+	assert.ErrorIs(t, err, ErrLastSigningKey)
+
+	// 产品专属密钥只用于该产品的授权
+	productKey, err := RotateSigningKey(&RotateSigningKeyRequest{ProductID: product.ID}, "", "")
+	require.NoError(t, err)
+	third, thirdFingerprint := activateTestLicense(t, product.ID, "003")
+	file, err = GetLicenseFile(third.ID, thirdFingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, productKey.ID, file.KeyID)
+	set, err := GetSigningKeySet(product.ID)
+	require.NoError(t, err)
+	assert.Len(t, set.Keys, 3)
+	set, err = GetSigningKeySet("other")
+	require.NoError(t, err)
+	assert.Len(t, set.Keys, 2)
+
+	// 作废产品密钥后不再发布，已保存的授权文件改用默认密钥重新签发
+	_, err = RevokeSigningKey(productKey.ID, "", "")
+	require.NoError(t, err)
+	set, err = GetSigningKeySet(product.ID)
+	require.NoError(t, err)
+	assert.Len(t, set.Keys, 2)
+	file, err = GetLicenseFile(third.ID, thirdFingerprint)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, file.KeyID)
+
+	// 根密钥签名的集合列出已作废的密钥
+	signed, err := GetSignedSigningKeySet(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rootID, signed.KeyID)
+	signedSet, err := utils.VerifySigningKeySet(signed, root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{productKey.ID}, signedSet.Revoked)
+	assert.Len(t, signedSet.Keys, 2)
+}
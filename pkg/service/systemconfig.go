@@ -6,6 +6,8 @@ import (
 	"LVerity/pkg/database"
 	"LVerity/pkg/model"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +38,19 @@ func GetSystemConfigByName(name string) (*model.SystemConfig, error) {
 	return &config, nil
 }
 
+// GetSystemConfigInt 获取整数类型的系统配置，未配置或格式错误时返回默认值
+func GetSystemConfigInt(name string, defaultValue int) int {
+	config, err := GetSystemConfigByName(name)
+	if err != nil {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(config.Value))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // CreateSystemConfig 创建系统配置
 func CreateSystemConfig(name, value, description, group string, isSystem bool) (*model.SystemConfig, error) {
 	// 检查配置名称是否已存在
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTransferLimit      = 3
+	defaultTransferPeriodDays = 365
+)
+
+var (
+	ErrTransferLimitReached = errors.New("license transfer limit reached")
+	// ErrTransferActivationFailed 授权已转移给新客户，但在新授权上激活转入设备失败
+	ErrTransferActivationFailed = errors.New("license transferred but failed to activate target device")
+)
+
+// LicenseTransferRequest 授权转移请求
+type LicenseTransferRequest struct {
+	FromDeviceID string `json:"from_device_id"` // 转出设备，为空时使用授权主设备
+	ToDeviceID   string `json:"to_device_id"`   // 转入设备，转移给其他客户时可为空
+	ToCustomerID string `json:"to_customer_id"` // 转入客户，为空表示仅在设备间转移
+	Reason       string `json:"reason"`
+}
+
+// TransferLicense 转移授权
+// 设备间转移时将激活从原设备移到新设备，名额不变；转移给其他客户时原授权置为已转移，
+// 并为新客户重新签发条款相同的授权，指定了转入设备时在新授权上激活该设备；
+// 转移已提交但激活转入设备失败时，返回转移记录和ErrTransferActivationFailed
+func TransferLicense(licenseID string, req *LicenseTransferRequest, operatorID, operatorName string) (*model.LicenseTransfer, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch license.Status {
+	case model.LicenseStatusUnused, model.LicenseStatusUsed, model.LicenseStatusActive:
+	default:
+		return nil, fmt.Errorf("license is %s and cannot be transferred", license.Status)
+	}
+	if time.Now().After(license.ExpireTime) {
+		return nil, errors.New("license has expired")
+	}
+
+	toCustomer := req.ToCustomerID != "" && req.ToCustomerID != license.CustomerID
+	if !toCustomer && req.ToDeviceID == "" {
+		return nil, errors.New("target device or customer is required")
+	}
+	if IsFloatingLicense(license) && req.ToDeviceID != "" {
+		return nil, errors.New("floating license seats cannot be transferred between devices")
+	}
+
+	if toCustomer {
+		if _, err := NewCustomerService().GetCustomerByID(req.ToCustomerID); err != nil {
+			return nil, fmt.Errorf("target customer not found: %v", err)
+		}
+	}
+	if req.ToDeviceID != "" {
+		device, err := GetDevice(req.ToDeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("target device not found: %v", err)
+		}
+		if IsDeviceBlocked(device) {
+			return nil, errors.New("target device is blocked")
+		}
+	}
+
+	originID, err := getTransferOrigin(license.ID)
+	if err != nil {
+		return nil, err
+	}
+	quota := getTransferQuota()
+
+	transfer := &model.LicenseTransfer{
+		ID:              utils.GenerateUUID(),
+		OriginLicenseID: originID,
+		LicenseID:       license.ID,
+		FromCustomerID:  license.CustomerID,
+		ToCustomerID:    license.CustomerID,
+		Reason:          req.Reason,
+		OperatorID:      operatorID,
+		OperatorName:    operatorName,
+		CreatedAt:       time.Now(),
+	}
+
+	if toCustomer {
+		transfer.Type = model.LicenseTransferCustomer
+		transfer.ToCustomerID = req.ToCustomerID
+		transfer.FromDeviceID = req.FromDeviceID
+		transfer.ToDeviceID = req.ToDeviceID
+		err = transferToCustomer(license, transfer, quota)
+	} else {
+		transfer.Type = model.LicenseTransferDevice
+		transfer.FromDeviceID = req.FromDeviceID
+		if transfer.FromDeviceID == "" {
+			transfer.FromDeviceID = license.DeviceID
+		}
+		transfer.ToDeviceID = req.ToDeviceID
+		err = transferToDevice(license, transfer, quota)
+	}
+	if err != nil && !errors.Is(err, ErrTransferActivationFailed) {
+		return nil, err
+	}
+
+	LogOperation(operatorID, operatorName, "transfer", "license", license.ID, transfer)
+	return transfer, err
+}
+
+// transferToDevice 将授权在原设备上的激活移到新设备
+func transferToDevice(license *model.License, transfer *model.LicenseTransfer, quota transferQuota) error {
+	if transfer.FromDeviceID == "" {
+		return ErrLicenseNotActivated
+	}
+	if transfer.FromDeviceID == transfer.ToDeviceID {
+		return errors.New("source and target device are the same")
+	}
+	if _, err := GetActiveActivation(license.ID, transfer.ToDeviceID); err == nil {
+		return errors.New("license is already activated on the target device")
+	}
+
+	now := time.Now()
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := checkTransferLimit(tx, transfer.OriginLicenseID, quota); err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.LicenseActivation{}).
+			Where("license_id = ? AND device_id = ? AND status IN ?", license.ID, transfer.FromDeviceID, seatActivationStatuses).
+			Updates(map[string]interface{}{
+				"status":         model.ActivationStatusTransferred,
+				"deactivated_at": now,
+				"signed_file":    "",
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update license activation: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrLicenseNotActivated
+		}
+
+		// 名额随激活一起转移，不重新占用
+		var activation model.LicenseActivation
+		err := tx.Where("license_id = ? AND device_id = ?", license.ID, transfer.ToDeviceID).First(&activation).Error
+		if err == nil {
+			err = tx.Model(&activation).Updates(map[string]interface{}{
+				"status":         model.ActivationStatusActive,
+				"activated_at":   now,
+				"deactivated_at": nil,
+				"signed_file":    "",
+				"updated_at":     now,
+			}).Error
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Create(&model.LicenseActivation{
+				ID:          utils.GenerateUUID(),
+				LicenseID:   license.ID,
+				DeviceID:    transfer.ToDeviceID,
+				ActivatedAt: now,
+				Status:      model.ActivationStatusActive,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to activate target device: %v", err)
+		}
+
+		if license.DeviceID == transfer.FromDeviceID {
+			if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+				Updates(map[string]interface{}{"device_id": transfer.ToDeviceID, "updated_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to update license: %v", err)
+			}
+		}
+
+		if err := moveLicenseUsage(tx, license.ID, transfer.FromDeviceID, transfer.ToDeviceID, now); err != nil {
+			return err
+		}
+		return tx.Create(transfer).Error
+	})
+}
+
+// transferToCustomer 将授权转移给其他客户：原授权置为已转移并释放所有激活，为新客户签发新授权
+func transferToCustomer(license *model.License, transfer *model.LicenseTransfer, quota transferQuota) error {
+	code, err := GenerateLicenseCode(license.ProductID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	newLicense := &model.License{
+		ID:               utils.GenerateUUID(),
+		Code:             code,
+		Type:             license.Type,
+		Status:           model.LicenseStatusUnused,
+		MaxDevices:       license.MaxDevices,
+		SeatMode:         license.SeatMode,
+		LeaseDuration:    license.LeaseDuration,
+		StartTime:        license.StartTime,
+		ExpireTime:       license.ExpireTime,
+		Description:      license.Description,
+		GroupID:          license.GroupID,
+		CustomerID:       transfer.ToCustomerID,
+		ProductID:        license.ProductID,
+		Metadata:         license.Metadata,
+		Features:         license.Features,
+		FeaturesStr:      license.FeaturesStr,
+		UsageLimit:       license.UsageLimit,
+		UsageCount:       license.UsageCount,
+		PlanID:           license.PlanID,
+		PartnerID:        license.PartnerID,
+		ActivationPolicy: license.ActivationPolicy,
+		GracePeriodDays:  license.GracePeriodDays,
+		CreatedBy:        transfer.OperatorID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := signLicenseFile(newLicense, ""); err != nil {
+		return err
+	}
+	transfer.NewLicenseID = newLicense.ID
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := checkTransferLimit(tx, transfer.OriginLicenseID, quota); err != nil {
+			return err
+		}
+
+		before, err := loadLicenseSnapshot(tx, license.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+			Updates(map[string]interface{}{
+				"status":       model.LicenseStatusTransferred,
+				"used_devices": 0,
+				"device_id":    "",
+				"signed_file":  "",
+				"updated_at":   now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update license: %v", err)
+		}
+		// 原授权码已失效，离线客户端通过吊销列表得知
+		if err := addRevocation(tx, model.RevocationTypeLicense, license.Code, model.RevocationActionAdd, "license transferred"); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.LicenseActivation{}).
+			Where("license_id = ? AND status IN ?", license.ID, seatActivationStatuses).
+			Updates(map[string]interface{}{
+				"status":         model.ActivationStatusTransferred,
+				"deactivated_at": now,
+				"signed_file":    "",
+				"updated_at":     now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update license activations: %v", err)
+		}
+		if err := tx.Model(&model.LicenseLease{}).
+			Where("license_id = ? AND status = ?", license.ID, model.LeaseStatusActive).
+			Updates(map[string]interface{}{
+				"status":      model.LeaseStatusReleased,
+				"released_at": now,
+				"updated_at":  now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to release license leases: %v", err)
+		}
+		if err := tx.Model(&model.Device{}).Where("license_id = ?", license.ID).
+			Updates(map[string]interface{}{"license_id": "", "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to unbind devices: %v", err)
+		}
+		if err := tx.Model(&model.LicenseUsage{}).
+			Where("license_id = ? AND status = ?", license.ID, "active").
+			Updates(map[string]interface{}{"status": "inactive", "end_time": now, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to close license usage: %v", err)
+		}
+
+		if _, err := recordLicenseVersion(tx, license.ID, before, &model.LicenseVersion{
+			Action:       model.LicenseChangeTransfer,
+			Reason:       transfer.Reason,
+			OperatorID:   transfer.OperatorID,
+			OperatorName: transfer.OperatorName,
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Create(newLicense).Error; err != nil {
+			return fmt.Errorf("failed to create license: %v", err)
+		}
+		// 新授权沿用原授权的标签
+		var tagIDs []string
+		if err := tx.Model(&model.LicenseTagMapping{}).Where("license_id = ?", license.ID).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return fmt.Errorf("failed to get license tags: %v", err)
+		}
+		for _, tagID := range tagIDs {
+			if err := tx.Create(&model.LicenseTagMapping{LicenseID: newLicense.ID, TagID: tagID, CreatedAt: now}).Error; err != nil {
+				return fmt.Errorf("failed to copy license tags: %v", err)
+			}
+		}
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if transfer.ToDeviceID != "" && !IsFloatingLicense(newLicense) {
+		if _, err := ActivateLicense(newLicense.Code, transfer.ToDeviceID, ""); err != nil {
+			return fmt.Errorf("%w: %v", ErrTransferActivationFailed, err)
+		}
+	}
+	return nil
+}
+
+// moveLicenseUsage 结束原设备的使用记录并为新设备开始新的使用记录
+func moveLicenseUsage(tx *gorm.DB, licenseID, fromDeviceID, toDeviceID string, now time.Time) error {
+	if err := tx.Model(&model.LicenseUsage{}).
+		Where("license_id = ? AND device_id = ? AND status = ?", licenseID, fromDeviceID, "active").
+		Updates(map[string]interface{}{"status": "inactive", "end_time": now, "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to close license usage: %v", err)
+	}
+	if err := tx.Model(&model.Device{}).Where("id = ? AND license_id = ?", fromDeviceID, licenseID).
+		Updates(map[string]interface{}{"license_id": "", "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to unbind device: %v", err)
+	}
+	if err := tx.Model(&model.Device{}).Where("id = ?", toDeviceID).
+		Updates(map[string]interface{}{"license_id": licenseID, "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to bind device: %v", err)
+	}
+	return tx.Create(&model.LicenseUsage{
+		ID:        utils.GenerateUUID(),
+		LicenseID: licenseID,
+		DeviceID:  toDeviceID,
+		StartTime: now,
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error
+}
+
+// getTransferOrigin 获取授权流转链中最初的授权ID
+func getTransferOrigin(licenseID string) (string, error) {
+	var transfer model.LicenseTransfer
+	err := database.GetDB().Where("new_license_id = ?", licenseID).First(&transfer).Error
+	if err == nil {
+		return transfer.OriginLicenseID, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return licenseID, nil
+	}
+	return "", fmt.Errorf("failed to get license transfer: %v", err)
+}
+
+// transferQuota 流转链在统计周期内允许的转移次数，Limit不大于0表示不限制
+type transferQuota struct {
+	Limit      int
+	PeriodDays int
+}
+
+// getTransferQuota 获取授权转移次数限制的配置
+func getTransferQuota() transferQuota {
+	return transferQuota{
+		Limit:      GetSystemConfigInt(model.ConfigLicenseTransferLimit, defaultTransferLimit),
+		PeriodDays: GetSystemConfigInt(model.ConfigLicenseTransferPeriodDays, defaultTransferPeriodDays),
+	}
+}
+
+// checkTransferLimit 在转移事务中检查流转链在统计周期内的转移次数是否已达上限，删除客户时的改归不计入
+// 先锁定流转链最初的授权行，同一流转链的并发转移按顺序计数
+func checkTransferLimit(tx *gorm.DB, originID string, quota transferQuota) error {
+	if quota.Limit <= 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ?", originID).Find(&model.License{}).Error; err != nil {
+		return fmt.Errorf("failed to lock license: %v", err)
+	}
+
+	var count int64
+	if err := tx.Model(&model.LicenseTransfer{}).
+		Where("origin_license_id = ? AND created_at > ?", originID, time.Now().AddDate(0, 0, -quota.PeriodDays)).
+		Where("type <> ?", model.LicenseTransferReassign).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count license transfers: %v", err)
+	}
+	if count >= int64(quota.Limit) {
+		return fmt.Errorf("%w: %d transfers in %d days", ErrTransferLimitReached, quota.Limit, quota.PeriodDays)
+	}
+	return nil
+}
+
+// GetLicenseTransfers 获取授权所在流转链的全部转移记录
+func GetLicenseTransfers(licenseID string) ([]model.LicenseTransfer, error) {
+	if _, err := GetLicenseByID(licenseID); err != nil {
+		return nil, err
+	}
+
+	originID, err := getTransferOrigin(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []model.LicenseTransfer{}
+	if err := database.GetDB().
+		Where("origin_license_id = ?", originID).
+		Order("created_at").
+		Find(&transfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license transfers: %v", err)
+	}
+	return transfers, nil
+}
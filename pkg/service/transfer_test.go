@@ -0,0 +1,162 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestCustomer 创建测试客户
+func createTestCustomer(t *testing.T, id string) *model.Customer {
+	customer := &model.Customer{ID: id, Name: "Customer " + id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, NewCustomerService().CreateCustomer(customer))
+	return customer
+}
+
+func TestTransferToCustomerKeyFormat(t *testing.T) {
+	setupTest(t)
+	product := &model.Product{ID: "p-acme", Name: "Acme", KeyPrefix: "ACME"}
+	require.NoError(t, NewProductService().CreateProduct(product))
+	createTestCustomer(t, "c-to")
+
+	license, err := GenerateProductLicense(product.ID, model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	transfer, err := TransferLicense(license.ID, &LicenseTransferRequest{ToCustomerID: "c-to"}, "", "")
+	require.NoError(t, err)
+
+	// 转移后签发的新授权使用产品的带校验字符授权码
+	transferred, err := GetLicenseByID(transfer.NewLicenseID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(transferred.Code, "ACME-"))
+	assert.NoError(t, utils.CheckLicenseCode(transferred.Code))
+}
+
+func TestTransferToCustomerKeepsRestrictions(t *testing.T) {
+	setupTest(t)
+	createTestCustomer(t, "c-to")
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	policy := &model.ActivationPolicy{DeniedCountries: []string{"DE"}}
+	_, err = SetLicenseActivationPolicy(license.ID, policy, "", "")
+	require.NoError(t, err)
+	group, err := CreateLicenseGroup("Resellers", "", "admin")
+	require.NoError(t, err)
+	_, err = AssignLicensesToGroup(group.ID, []string{license.ID})
+	require.NoError(t, err)
+	tag, err := CreateLicenseTag("export", "#ff0000")
+	require.NoError(t, err)
+	_, _, err = BulkTagLicenses(&BulkTagRequest{LicenseIDs: []string{license.ID}, AddTagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&model.License{}).Where("id = ?", license.ID).Update("partner_id", "partner-1").Error)
+
+	// 新授权沿用原授权的激活限制、合作伙伴、授权组和标签
+	transfer, err := TransferLicense(license.ID, &LicenseTransferRequest{ToCustomerID: "c-to"}, "", "")
+	require.NoError(t, err)
+	transferred, err := GetLicenseByID(transfer.NewLicenseID)
+	require.NoError(t, err)
+	assert.Equal(t, policy, transferred.ActivationPolicy)
+	assert.Equal(t, "partner-1", transferred.PartnerID)
+	assert.Equal(t, group.ID, transferred.GroupID)
+	var tagIDs []string
+	require.NoError(t, database.GetDB().Model(&model.LicenseTagMapping{}).Where("license_id = ?", transferred.ID).Pluck("tag_id", &tagIDs).Error)
+	assert.Equal(t, []string{tag.ID}, tagIDs)
+}
+
+func TestTransferChainOfCustody(t *testing.T) {
+	setupTest(t)
+	first := createTestCustomer(t, "c-first")
+	second := createTestCustomer(t, "c-second")
+	createTestCustomer(t, "c-third")
+	from, _ := registerTestDevice(t, "001")
+	to, _ := registerTestDevice(t, "002")
+
+	license, err := GenerateLicenseWithOptions("", model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0,
+		LicenseOptions{CustomerID: first.ID})
+	require.NoError(t, err)
+	_, err = ActivateLicense(license.Code, from.ID, "")
+	require.NoError(t, err)
+
+	// 设备间转移：激活移到新设备，名额不变
+	moved, err := TransferLicense(license.ID, &LicenseTransferRequest{FromDeviceID: from.ID, ToDeviceID: to.ID, Reason: "replace"}, "u-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTransferDevice, moved.Type)
+	_, err = GetActiveActivation(license.ID, to.ID)
+	require.NoError(t, err)
+	_, err = GetActiveActivation(license.ID, from.ID)
+	assert.Error(t, err)
+	stored, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedDevices)
+
+	// 转移给其他客户：原授权置为已转移，新授权沿用流转链的起点
+	handover, err := TransferLicense(license.ID, &LicenseTransferRequest{ToCustomerID: second.ID}, "u-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTransferCustomer, handover.Type)
+	stored, err = GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusTransferred, stored.Status)
+	transferred, err := GetLicenseByID(handover.NewLicenseID)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, transferred.CustomerID)
+
+	// 删除客户时的改归记录在流转链中，但不计入转移次数
+	_, err = NewCustomerService().ReassignLicenses(second.ID, "c-third", "u-1", "admin")
+	require.NoError(t, err)
+
+	_, err = TransferLicense(transferred.ID, &LicenseTransferRequest{ToCustomerID: first.ID}, "u-1", "admin")
+	require.NoError(t, err)
+
+	// 默认一年内最多转移3次，按流转链统计
+	transfers, err := GetLicenseTransfers(transferred.ID)
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+	for _, transfer := range transfers {
+		assert.Equal(t, license.ID, transfer.OriginLicenseID)
+	}
+	assert.Equal(t, []model.LicenseTransferType{
+		model.LicenseTransferDevice, model.LicenseTransferCustomer, model.LicenseTransferReassign, model.LicenseTransferCustomer,
+	}, []model.LicenseTransferType{transfers[0].Type, transfers[1].Type, transfers[2].Type, transfers[3].Type})
+
+	latest := transfers[3].NewLicenseID
+	_, err = TransferLicense(latest, &LicenseTransferRequest{ToCustomerID: second.ID}, "u-1", "admin")
+	assert.ErrorIs(t, err, ErrTransferLimitReached)
+
+	// 流转链中任一授权都能查到完整记录
+	history, err := GetLicenseTransfers(license.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+	history, err = GetLicenseTransfers(latest)
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+}
+
+func TestTransferActivationFailure(t *testing.T) {
+	setupTest(t)
+	createTestCustomer(t, "c-to")
+	license, err := GenerateLicense(model.LicenseTypeStandard, 1, time.Now(), time.Now().AddDate(0, 1, 0), "", nil, 0)
+	require.NoError(t, err)
+	device, _ := registerTestDevice(t, "001")
+
+	// 转移提交后激活转入设备失败时仍返回转移记录，新授权可以重新激活
+	db := database.GetDB()
+	require.NoError(t, db.Exec("CREATE TRIGGER fail_activation BEFORE INSERT ON license_activations BEGIN SELECT RAISE(ABORT, 'activation failed'); END").Error)
+	transfer, err := TransferLicense(license.ID, &LicenseTransferRequest{ToCustomerID: "c-to", ToDeviceID: device.ID}, "", "")
+	assert.ErrorIs(t, err, ErrTransferActivationFailed)
+	require.NotNil(t, transfer)
+	require.NoError(t, db.Exec("DROP TRIGGER fail_activation").Error)
+
+	transferred, err := GetLicenseByID(transfer.NewLicenseID)
+	require.NoError(t, err)
+	assert.Equal(t, "c-to", transferred.CustomerID)
+	_, err = ActivateLicense(transferred.Code, device.ID, "")
+	require.NoError(t, err)
+	original, err := GetLicenseByID(license.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseStatusTransferred, original.Status)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"LVerity/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultTrialDays 未配置时自助试用授权的有效期(天)
+	defaultTrialDays = 14
+	// defaultTrialWindowDays 未配置时同一设备、网络或邮箱再次申请试用的间隔(天)
+	defaultTrialWindowDays = 365
+)
+
+var ErrNotTrialLicense = errors.New("license is not a trial license")
+
+// ConvertTrialRequest 试用授权转正式授权请求
+// 转换在原授权上进行，授权码、设备激活和变更历史保持不变
+type ConvertTrialRequest struct {
+	Type       model.LicenseType `json:"type" binding:"required"`
+	ExpireTime time.Time         `json:"expire_time" binding:"required"`
+	MaxDevices int               `json:"max_devices"`        // 为0时保持不变
+	Features   *[]string         `json:"features,omitempty"` // 为空时保持不变
+	CustomerID *string           `json:"customer_id,omitempty"`
+	Reason     string            `json:"reason"`
+}
+
+// trialIPRange 将IP归并为网段，IPv4按/24、IPv6按/64，同一网段内的地址视为同一来源
+// 本机回环地址不代表真实来源，不参与网段限制
+func trialIPRange(ipAddress string) string {
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil || ip.IsLoopback() {
+		return ""
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return (&net.IPNet{IP: ipv4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// findPreviousTrial 查找试用间隔windowDays天内该产品是否已有同一设备、网段或邮箱的试用记录
+// 设备按容错策略policy匹配，更换部分硬件不能绕过限制；返回命中的原因
+func findPreviousTrial(db *gorm.DB, productID string, trial *model.LicenseTrial, windowDays int, policy *model.FingerprintPolicy) (string, error) {
+	if windowDays <= 0 {
+		return "", nil
+	}
+
+	conditions := db.Where("fingerprint = ?", trial.Fingerprint).
+		Or("disk_id = ?", trial.DiskID).
+		Or("bios = ?", trial.BIOS).
+		Or("motherboard = ?", trial.Motherboard)
+	if trial.IPRange != "" {
+		conditions = conditions.Or("ip_range = ?", trial.IPRange)
+	}
+	if trial.Email != "" {
+		conditions = conditions.Or("email = ?", trial.Email)
+	}
+
+	var candidates []model.LicenseTrial
+	if err := db.Where("product_id = ? AND created_at > ?", productID, time.Now().AddDate(0, 0, -windowDays)).
+		Where(conditions).
+		Find(&candidates).Error; err != nil {
+		return "", fmt.Errorf("failed to get previous trials: %v", err)
+	}
+
+	reported := &model.Device{DiskID: trial.DiskID, BIOS: trial.BIOS, Motherboard: trial.Motherboard}
+	for _, candidate := range candidates {
+		switch {
+		case candidate.Fingerprint == trial.Fingerprint:
+			return "device", nil
+		case MatchDeviceFingerprint(&model.Device{
+			DiskID:      candidate.DiskID,
+			BIOS:        candidate.BIOS,
+			Motherboard: candidate.Motherboard,
+		}, reported, policy).IsMatch():
+			return "device", nil
+		case trial.Email != "" && candidate.Email == trial.Email:
+			return "email", nil
+		case trial.IPRange != "" && candidate.IPRange == trial.IPRange:
+			return "network", nil
+		}
+	}
+	return "", nil
+}
+
+// ClientStartTrial 客户端自助申请试用授权，为设备签发并激活一个限时的试用授权
+// 试用间隔内同一设备、IP网段或邮箱只能申请一次
+func ClientStartTrial(productID string, req *model.ClientTrialRequest, ipAddress string) (*model.ClientLicenseData, error) {
+	trialDays := GetSystemConfigInt(model.ConfigLicenseTrialDays, defaultTrialDays)
+	if trialDays <= 0 {
+		return nil, newClientError(model.ClientCodeTrialNotAllowed, "trials are not available")
+	}
+	product, err := NewProductService().GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	trial := &model.LicenseTrial{
+		ID:          utils.GenerateUUID(),
+		ProductID:   product.ID,
+		Fingerprint: utils.GenerateFingerprint(req.Device.DiskID, req.Device.BIOS, req.Device.Motherboard),
+		DiskID:      req.Device.DiskID,
+		BIOS:        req.Device.BIOS,
+		Motherboard: req.Device.Motherboard,
+		IPAddress:   ipAddress,
+		IPRange:     trialIPRange(ipAddress),
+		Email:       strings.ToLower(strings.TrimSpace(req.Email)),
+		Status:      model.TrialStatusActive,
+	}
+
+	device, err := resolveClientDevice(&req.Device, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	code, err := GenerateLicenseCode(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	license, err := newLicenseWithOptions(code, product.ID, model.LicenseTypeTrial, 1, now, now.AddDate(0, 0, trialDays), "", []string(product.Features), 0, LicenseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	trial.LicenseID = license.ID
+	trial.DeviceID = device.ID
+	trial.CreatedAt = now
+
+	// 检查试用记录、创建授权和试用记录、激活设备在同一事务中完成，任一步失败都不留下试用记录
+	windowDays := GetSystemConfigInt(model.ConfigLicenseTrialWindowDays, defaultTrialWindowDays)
+	policy := GetFingerprintPolicy()
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// 锁定产品行，同一产品的试用申请按顺序检查，并发请求不能重复领取试用
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", product.ID).First(&model.Product{}).Error; err != nil {
+			return fmt.Errorf("failed to lock product: %v", err)
+		}
+		reason, err := findPreviousTrial(tx, product.ID, trial, windowDays, policy)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return newClientError(model.ClientCodeTrialNotAllowed, "a trial has already been started from this %s", reason)
+		}
+
+		if err := tx.Create(license).Error; err != nil {
+			return fmt.Errorf("failed to create license: %v", err)
+		}
+		if err := tx.Create(trial).Error; err != nil {
+			return fmt.Errorf("failed to create trial: %v", err)
+		}
+		return occupyLicenseSeat(tx, license, device.ID, ipAddress, model.ActivationStatusActive, &model.LicenseActivation{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if license, err = GetLicenseByCode(license.Code); err != nil {
+		return nil, err
+	}
+
+	LogOperation("", "", "start_trial", "license", license.ID, map[string]string{
+		"product_id": product.ID,
+		"device_id":  device.ID,
+		"email":      trial.Email,
+		"ip_address": ipAddress,
+	})
+	return buildClientLicenseData(license, device)
+}
+
+// ListLicenseTrials 获取试用记录列表，按申请时间倒序
+func ListLicenseTrials(page, pageSize string, productID, status string) ([]model.LicenseTrial, int64, error) {
+	offset, limit := utils.GetPagination(page, pageSize)
+	query := database.GetDB().Model(&model.LicenseTrial{})
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count trials: %v", err)
+	}
+	trials := []model.LicenseTrial{}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&trials).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get trials: %v", err)
+	}
+	return trials, total, nil
+}
+
+// ConvertTrialLicense 将试用授权转为正式授权
+// 在原授权上修改类型、有效期和名额，设备激活保持不变，变更记录到授权历史，到期或宽限期中的试用恢复为可用
+func ConvertTrialLicense(licenseID string, req *ConvertTrialRequest, operatorID, operatorName string) (*model.License, error) {
+	license, err := GetLicenseByID(licenseID)
+	if err != nil {
+		return nil, err
+	}
+	if license.Type != model.LicenseTypeTrial {
+		return nil, ErrNotTrialLicense
+	}
+	switch license.Status {
+	case model.LicenseStatusRevoked, model.LicenseStatusTransferred, model.LicenseStatusDisabled:
+		return nil, fmt.Errorf("license is %s and cannot be converted", license.Status)
+	}
+	if !req.Type.IsValid() || req.Type == model.LicenseTypeTrial {
+		return nil, fmt.Errorf("invalid license type: %s", req.Type)
+	}
+	if !req.ExpireTime.After(time.Now()) {
+		return nil, errors.New("expire time must be in the future")
+	}
+	if req.MaxDevices < 0 || (req.MaxDevices > 0 && req.MaxDevices < license.UsedDevices) {
+		return nil, fmt.Errorf("max devices cannot be less than the %d devices in use", license.UsedDevices)
+	}
+
+	updates := map[string]interface{}{
+		"type":        req.Type,
+		"expire_time": req.ExpireTime,
+		"updated_at":  time.Now(),
+	}
+	if req.MaxDevices > 0 {
+		updates["max_devices"] = req.MaxDevices
+	}
+	if req.Features != nil {
+		featuresJSON, err := json.Marshal(*req.Features)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal features: %v", err)
+		}
+		updates["features"] = string(featuresJSON)
+	}
+	if req.CustomerID != nil {
+		if err := ValidateLicenseRelations(*req.CustomerID, ""); err != nil {
+			return nil, err
+		}
+		updates["customer_id"] = *req.CustomerID
+	}
+	if license.Status == model.LicenseStatusGrace || license.Status == model.LicenseStatusExpired {
+		updates["status"] = model.LicenseStatusUnused
+		if license.UsedDevices > 0 {
+			updates["status"] = model.LicenseStatusUsed
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "trial converted"
+	}
+	err = trackLicenseChange(license.ID, &model.LicenseVersion{
+		Action:       model.LicenseChangeConvert,
+		Reason:       reason,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to convert license: %v", err)
+		}
+		now := time.Now()
+		return tx.Model(&model.LicenseTrial{}).Where("license_id = ?", license.ID).
+			Updates(map[string]interface{}{
+				"status":       model.TrialStatusConverted,
+				"converted_at": now,
+				"converted_by": operatorID,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reissueLicenseFiles(license.ID); err != nil {
+		log.Printf("Failed to reissue license files for %s: %v", license.ID, err)
+	}
+	LogOperation(operatorID, operatorName, "convert_trial", "license", license.ID, req)
+	return GetLicenseByID(license.ID)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"LVerity/pkg/database"
+	"LVerity/pkg/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientStartTrialLimits(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-trial")
+	start := func(serial string, email string, ipAddress string) error {
+		_, err := ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: testClientDevice(serial), Email: email}, ipAddress)
+		return err
+	}
+
+	data, err := ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: testClientDevice("001"), Email: "User@Example.com"}, "127.0.0.1")
+	require.NoError(t, err)
+	license, err := GetLicenseByID(data.LicenseID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTypeTrial, license.Type)
+	assert.Equal(t, product.ID, license.ProductID)
+	assert.Equal(t, 1, license.UsedDevices)
+
+	// 同一设备、更换单个硬件的设备和同一邮箱都被拒绝，邮箱不区分大小写
+	assert.Equal(t, model.ClientCodeTrialNotAllowed, clientErrorCode(start("001", "other@example.com", "127.0.0.1")))
+	replaced := testClientDevice("001")
+	replaced.DiskID = "disk-replaced"
+	_, err = ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: replaced}, "127.0.0.1")
+	assert.Equal(t, model.ClientCodeTrialNotAllowed, clientErrorCode(err))
+	assert.Equal(t, model.ClientCodeTrialNotAllowed, clientErrorCode(start("002", "user@example.com", "127.0.0.1")))
+
+	// 同一/24网段的其他设备被拒绝，回环地址不参与网段限制
+	require.NoError(t, start("003", "", "203.0.113.10"))
+	assert.Equal(t, model.ClientCodeTrialNotAllowed, clientErrorCode(start("004", "", "203.0.113.77")))
+	require.NoError(t, start("005", "", "198.51.100.1"))
+	require.NoError(t, start("006", "", "127.0.0.1"))
+
+	// 其他产品的试用不受影响
+	other := createTestProduct(t, "p-other")
+	_, err = ClientStartTrial(other.ID, &model.ClientTrialRequest{Device: testClientDevice("001")}, "127.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestClientStartTrialAtomically(t *testing.T) {
+	setupTest(t)
+	db := database.GetDB()
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	product := createTestProduct(t, "p-trial")
+	countRecords := func(value interface{}) int64 {
+		var count int64
+		require.NoError(t, db.Model(value).Count(&count).Error)
+		return count
+	}
+
+	// 激活失败时回滚授权和试用记录，设备可以重新申请
+	require.NoError(t, db.Migrator().DropTable(&model.LicenseUsage{}))
+	_, err = ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: testClientDevice("001")}, "")
+	require.Error(t, err)
+	assert.Zero(t, countRecords(&model.LicenseTrial{}))
+	assert.Zero(t, countRecords(&model.License{}))
+	require.NoError(t, db.AutoMigrate(&model.LicenseUsage{}))
+
+	// 同一设备并发申请只签发一个试用授权，SQLite串行执行写事务，无法复现并发交错
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: testClientDevice("001")}, "")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), countRecords(&model.LicenseTrial{}))
+	assert.Equal(t, int64(1), countRecords(&model.License{}))
+}
+
+func TestConvertTrialLicense(t *testing.T) {
+	setupTest(t)
+	product := createTestProduct(t, "p-trial")
+	data, err := ClientStartTrial(product.ID, &model.ClientTrialRequest{Device: testClientDevice("001")}, "")
+	require.NoError(t, err)
+
+	// 转为正式授权后保留原授权码和设备激活
+	converted, err := ConvertTrialLicense(data.LicenseID, &ConvertTrialRequest{
+		Type:       model.LicenseTypePro,
+		ExpireTime: time.Now().AddDate(1, 0, 0),
+		MaxDevices: 3,
+	}, "u-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, model.LicenseTypePro, converted.Type)
+	assert.Equal(t, 3, converted.MaxDevices)
+	assert.Equal(t, data.Code, converted.Code)
+	activations, err := GetLicenseActivationsByID(data.LicenseID)
+	require.NoError(t, err)
+	require.Len(t, activations, 1)
+
+	versions, err := GetLicenseVersions(data.LicenseID)
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+	assert.Equal(t, model.LicenseChangeConvert, versions[0].Action)
+
+	trials, total, err := ListLicenseTrials("1", "10", product.ID, model.TrialStatusConverted)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, data.LicenseID, trials[0].LicenseID)
+
+	_, err = ConvertTrialLicense(data.LicenseID, &ConvertTrialRequest{
+		Type:       model.LicenseTypePro,
+		ExpireTime: time.Now().AddDate(1, 0, 0),
+	}, "u-1", "admin")
+	assert.ErrorIs(t, err, ErrNotTrialLicense)
+}
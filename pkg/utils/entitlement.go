@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"LVerity/pkg/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseEntitlement 解析单个功能项，格式为"key"或"key=value"
+// 整数值解析为数量限制，只有true和false解析为开关，其余作为取值
+func ParseEntitlement(feature string) (model.Entitlement, bool) {
+	key, value, hasValue := strings.Cut(strings.TrimSpace(feature), "=")
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if key == "" {
+		return model.Entitlement{}, false
+	}
+
+	entitlement := model.Entitlement{Key: key, Enabled: true}
+	if !hasValue {
+		return entitlement, true
+	}
+
+	if limit, err := strconv.ParseInt(value, 10, 64); err == nil {
+		entitlement.Limit = &limit
+	} else if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+		entitlement.Enabled = strings.EqualFold(value, "true")
+	} else {
+		entitlement.Value = value
+	}
+	return entitlement, true
+}
+
+// ParseEntitlements 解析功能列表，同名功能以后出现的为准
+func ParseEntitlements(features []string) map[string]model.Entitlement {
+	entitlements := make(map[string]model.Entitlement, len(features))
+	for _, feature := range features {
+		if entitlement, ok := ParseEntitlement(feature); ok {
+			entitlements[entitlement.Key] = entitlement
+		}
+	}
+	return entitlements
+}
+
+// MergeEntitlement 将附加模块的权益合并到基础权益
+// 任一授权开启即开启，数量限制累加，不限制的一方使合并结果不限制，取值以基础授权为准
+func MergeEntitlement(base model.Entitlement, addon model.Entitlement) model.Entitlement {
+	merged := base
+	merged.Sources = append(append([]string{}, base.Sources...), addon.Sources...)
+
+	switch {
+	case !base.Enabled:
+		merged.Enabled = addon.Enabled
+		merged.Limit = addon.Limit
+	case addon.Enabled:
+		if base.Limit == nil || addon.Limit == nil {
+			merged.Limit = nil
+		} else {
+			limit := *base.Limit + *addon.Limit
+			merged.Limit = &limit
+		}
+	}
+	if merged.Value == "" {
+		merged.Value = addon.Value
+	}
+	return merged
+}
+
+// SortedEntitlements 按功能名排序返回权益列表
+func SortedEntitlements(entitlements map[string]model.Entitlement) []model.Entitlement {
+	result := make([]model.Entitlement, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		result = append(result, entitlement)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntitlement(t *testing.T) {
+	limit := func(n int64) *int64 { return &n }
+	cases := []struct {
+		feature string
+		enabled bool
+		limit   *int64
+		value   string
+	}{
+		{"export", true, nil, ""},
+		{"max_users=0", true, limit(0), ""},
+		{"max_users=1", true, limit(1), ""},
+		{"max_users=10", true, limit(10), ""},
+		{"export=true", true, nil, ""},
+		{"export=false", false, nil, ""},
+		{"export=FALSE", false, nil, ""},
+		{"export=t", true, nil, "t"},
+		{"edition=pro", true, nil, "pro"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.feature, func(t *testing.T) {
+			entitlement, ok := ParseEntitlement(tc.feature)
+			assert.True(t, ok)
+			assert.Equal(t, tc.enabled, entitlement.Enabled)
+			assert.Equal(t, tc.limit, entitlement.Limit)
+			assert.Equal(t, tc.value, entitlement.Value)
+		})
+	}
+
+	_, ok := ParseEntitlement("=1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LicenseKeyAlphabet 授权码字符集，去除了易混淆的0、O、1、I，共32个字符
+const LicenseKeyAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// 授权码分组默认值
+const (
+	DefaultLicenseKeyGroups    = 4
+	DefaultLicenseKeyGroupSize = 5
+	maxLicenseKeyPrefixLength  = 8
+)
+
+var (
+	ErrInvalidLicenseKey  = errors.New("invalid license key format")
+	ErrLicenseKeyChecksum = errors.New("license key checksum mismatch")
+)
+
+// legacyLicenseKeyPattern 早期不含校验字符的授权码格式：
+// 服务端生成的XXXX-XXXX-XXXX-XXXX(A-Z0-9)，以及管理端生成的XXXXX-XXXXX-XXXXX-XXXXX(授权码字符集)
+var legacyLicenseKeyPattern = regexp.MustCompile(`^([A-Z0-9]{4}(-[A-Z0-9]{4}){3}|[` + LicenseKeyAlphabet + `]{5}(-[` + LicenseKeyAlphabet + `]{5}){3})$`)
+
+// LicenseKeyFormat 授权码格式，如前缀LV、4组、每组5个字符：LV-XXXXX-XXXXX-XXXXX-XXXXX
+// 最后一个字符为校验字符，校验范围包含前缀
+type LicenseKeyFormat struct {
+	Prefix    string
+	Groups    int
+	GroupSize int
+}
+
+// ValidateLicenseKeyPrefix 校验授权码前缀只包含授权码字符集中的字符
+func ValidateLicenseKeyPrefix(prefix string) error {
+	if len(prefix) > maxLicenseKeyPrefixLength {
+		return fmt.Errorf("license key prefix must be at most %d characters", maxLicenseKeyPrefixLength)
+	}
+	for _, r := range prefix {
+		if !strings.ContainsRune(LicenseKeyAlphabet, r) {
+			return fmt.Errorf("license key prefix contains invalid character %q", r)
+		}
+	}
+	return nil
+}
+
+// GenerateLicenseKey 按格式生成带校验字符的随机授权码
+func GenerateLicenseKey(format LicenseKeyFormat) (string, error) {
+	if format.Groups <= 0 {
+		format.Groups = DefaultLicenseKeyGroups
+	}
+	if format.GroupSize <= 0 {
+		format.GroupSize = DefaultLicenseKeyGroupSize
+	}
+	if err := ValidateLicenseKeyPrefix(format.Prefix); err != nil {
+		return "", err
+	}
+
+	length := format.Groups * format.GroupSize
+	random, err := GenerateRandomBytes(length - 1)
+	if err != nil {
+		return "", err
+	}
+	body := make([]byte, length)
+	for i, b := range random {
+		// 字符集恰好32个字符，取低5位即均匀分布
+		body[i] = LicenseKeyAlphabet[b&31]
+	}
+	body[length-1] = licenseKeyCheckChar(format.Prefix + string(body[:length-1]))
+
+	groups := make([]string, 0, format.Groups+1)
+	if format.Prefix != "" {
+		groups = append(groups, format.Prefix)
+	}
+	for i := 0; i < length; i += format.GroupSize {
+		groups = append(groups, string(body[i:i+format.GroupSize]))
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// NormalizeLicenseKey 规范化用户输入的授权码：去除空白并转为大写，旧版UUID授权码保持原样
+func NormalizeLicenseKey(key string) string {
+	key = strings.TrimSpace(key)
+	if IsLegacyLicenseCode(key) {
+		return key
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(key), ""))
+}
+
+// ValidateLicenseKey 校验授权码的字符集和校验字符，用于在查询数据库前拒绝输错的授权码
+func ValidateLicenseKey(key string) error {
+	chars := strings.ReplaceAll(NormalizeLicenseKey(key), "-", "")
+	if len(chars) < DefaultLicenseKeyGroupSize {
+		return ErrInvalidLicenseKey
+	}
+	for _, r := range chars {
+		if !strings.ContainsRune(LicenseKeyAlphabet, r) {
+			return ErrInvalidLicenseKey
+		}
+	}
+	if licenseKeyCheckChar(chars[:len(chars)-1]) != chars[len(chars)-1] {
+		return ErrLicenseKeyChecksum
+	}
+	return nil
+}
+
+// IsLegacyLicenseCode 判断是否为早期不含校验字符的授权码，包括UUID和legacyLicenseKeyPattern中的格式
+// 不带前缀的4组5字符新授权码与早期管理端生成的授权码无法区分，同样视为早期格式
+func IsLegacyLicenseCode(code string) bool {
+	if _, err := uuid.Parse(code); err == nil && len(code) == 36 {
+		return true
+	}
+	return legacyLicenseKeyPattern.MatchString(code)
+}
+
+// CheckLicenseCode 校验授权码，早期格式的授权码不含校验字符，直接通过
+func CheckLicenseCode(code string) error {
+	if IsLegacyLicenseCode(code) {
+		return nil
+	}
+	return ValidateLicenseKey(code)
+}
+
+// licenseKeyCheckChar 使用Luhn mod 32算法计算校验字符，可检出所有单字符错误和绝大多数相邻字符互换
+func licenseKeyCheckChar(chars string) byte {
+	n := len(LicenseKeyAlphabet)
+	factor := 2
+	sum := 0
+	for i := len(chars) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(LicenseKeyAlphabet, chars[i])
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+		sum += addend/n + addend%n
+	}
+	return LicenseKeyAlphabet[(n-sum%n)%n]
+}
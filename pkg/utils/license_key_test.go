@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseKeyChecksum(t *testing.T) {
+	key, err := GenerateLicenseKey(LicenseKeyFormat{Prefix: "LV"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^LV(-[2-9A-HJ-NP-Z]{5}){4}$`, key)
+	require.NoError(t, ValidateLicenseKey(key))
+
+	// 任意位置的单字符输错都被检出
+	for i := 3; i < len(key); i++ {
+		if key[i] == '-' {
+			continue
+		}
+		next := LicenseKeyAlphabet[(strings.IndexByte(LicenseKeyAlphabet, key[i])+1)%len(LicenseKeyAlphabet)]
+		mistyped := key[:i] + string(next) + key[i+1:]
+		assert.ErrorIs(t, ValidateLicenseKey(mistyped), ErrLicenseKeyChecksum, mistyped)
+	}
+
+	// 相邻字符互换被检出，Luhn mod 32无法检出首尾字符(2和Z)互换
+	for i := 3; i+1 < len(key); i++ {
+		a, b := key[i], key[i+1]
+		if a == '-' || b == '-' || a == b || (a == '2' && b == 'Z') || (a == 'Z' && b == '2') {
+			continue
+		}
+		swapped := key[:i] + string(b) + string(a) + key[i+2:]
+		assert.ErrorIs(t, ValidateLicenseKey(swapped), ErrLicenseKeyChecksum, swapped)
+	}
+
+	// 小写和空白规范化后仍然有效
+	assert.Equal(t, key, NormalizeLicenseKey(" "+strings.ToLower(key)+"\n"))
+	assert.NoError(t, ValidateLicenseKey(strings.ToLower(key)))
+	assert.ErrorIs(t, ValidateLicenseKey("LV-0000O"), ErrInvalidLicenseKey)
+	assert.ErrorIs(t, ValidateLicenseKey("AB"), ErrInvalidLicenseKey)
+}
+
+func TestLegacyLicenseCode(t *testing.T) {
+	legacy := GenerateUUID()
+	assert.True(t, IsLegacyLicenseCode(legacy))
+	assert.Equal(t, legacy, NormalizeLicenseKey(legacy))
+	assert.NoError(t, CheckLicenseCode(legacy))
+	assert.Error(t, CheckLicenseCode(strings.ReplaceAll(legacy, "-", "")))
+
+	// 早期服务端和管理端生成的授权码不含校验字符
+	for _, code := range []string{"A0B1-C2D3-E4F5-G6H7", "ABCDE-FGHJK-LMNPQ-RSTUV"} {
+		assert.True(t, IsLegacyLicenseCode(code), code)
+		assert.NoError(t, CheckLicenseCode(code), code)
+	}
+	assert.False(t, IsLegacyLicenseCode("ACME-ABCDE-FGHJK-LMNPQ-RSTUV"))
+}
+
+func TestValidateLicenseKeyPrefix(t *testing.T) {
+	assert.NoError(t, ValidateLicenseKeyPrefix(""))
+	assert.NoError(t, ValidateLicenseKeyPrefix("ACME"))
+	assert.Error(t, ValidateLicenseKeyPrefix("OLD"))
+	assert.Error(t, ValidateLicenseKeyPrefix("lv"))
+	assert.Error(t, ValidateLicenseKeyPrefix("ABCDEFGHJ"))
+	_, err := GenerateLicenseKey(LicenseKeyFormat{Prefix: "I"})
+	assert.Error(t, err)
+}
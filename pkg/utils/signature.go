@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"LVerity/pkg/model"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrFingerprintMismatch = errors.New("license is bound to another device")
+)
+
+// LoadOrCreateSigningKey 从文件加载Ed25519签名私钥，文件不存在时生成新密钥并保存
+func LoadOrCreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode signing key: %v", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, errors.New("invalid signing key size")
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read signing key: %v", err)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create signing key directory: %v", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(privateKey.Seed())
+	if err := os.WriteFile(path, []byte(encoded), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %v", err)
+	}
+
+	return privateKey, nil
+}
+
+// EncodePublicKey 将公钥编码为base64字符串，便于嵌入客户端
+func EncodePublicKey(publicKey ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(publicKey)
+}
+
+// ParsePublicKey 解析base64编码的Ed25519公钥
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %v", err)
+	}
+	if len(data) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key size")
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// PublicKeyID 根据公钥计算密钥ID
+func PublicKeyID(publicKey ed25519.PublicKey) string {
+	hash := sha256.Sum256(publicKey)
+	return hex.EncodeToString(hash[:8])
+}
+
+// SignLicenseClaims 使用私钥签名授权内容，生成离线授权文件
+func SignLicenseClaims(claims *model.LicenseClaims, privateKey ed25519.PrivateKey) (*model.LicenseFile, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal license claims: %v", err)
+	}
+	return signPayload(payload, privateKey)
+}
+
+// VerifyLicenseFile 使用公钥校验离线授权文件并返回其中的授权内容
+// fingerprint不为空时同时校验授权文件是否绑定到该设备
+func VerifyLicenseFile(file *model.LicenseFile, publicKey ed25519.PublicKey, fingerprint string) (*model.LicenseClaims, error) {
+	if file == nil {
+		return nil, errors.New("license file is empty")
+	}
+	payload, err := verifyPayload(file, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims model.LicenseClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal license claims: %v", err)
+	}
+	if claims.PayloadType != model.LicenseClaimsType {
+		return nil, errors.New("signed content is not a license")
+	}
+
+	if fingerprint != "" && claims.Fingerprint != "" && claims.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+
+	return &claims, nil
+}
+
+// SignRevocationList 使用私钥签名吊销列表
+func SignRevocationList(list *model.RevocationList, privateKey ed25519.PrivateKey) (*model.SignedRevocationList, error) {
+	payload, err := json.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal revocation list: %v", err)
+	}
+	return signPayload(payload, privateKey)
+}
+
+// VerifyRevocationList 使用公钥校验签名的吊销列表并返回其内容
+func VerifyRevocationList(file *model.SignedRevocationList, publicKey ed25519.PublicKey) (*model.RevocationList, error) {
+	if file == nil {
+		return nil, errors.New("revocation list is empty")
+	}
+	payload, err := verifyPayload(file, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var list model.RevocationList
+	if err := json.Unmarshal(payload, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revocation list: %v", err)
+	}
+	if list.Type != model.RevocationListType {
+		return nil, errors.New("signed content is not a revocation list")
+	}
+	return &list, nil
+}
+
+// SignServerTime 使用私钥签名服务端时间戳
+func SignServerTime(claims *model.ServerTimeClaims, privateKey ed25519.PrivateKey) (*model.LicenseFile, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal server time: %v", err)
+	}
+	return signPayload(payload, privateKey)
+}
+
+// VerifyServerTime 使用公钥校验签名的服务端时间戳，并校验其绑定的设备指纹
+func VerifyServerTime(file *model.LicenseFile, publicKey ed25519.PublicKey, fingerprint string) (*model.ServerTimeClaims, error) {
+	if file == nil {
+		return nil, errors.New("server time is empty")
+	}
+	payload, err := verifyPayload(file, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims model.ServerTimeClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal server time: %v", err)
+	}
+	if claims.Type != model.ServerTimeClaimsType {
+		return nil, errors.New("signed content is not a server time")
+	}
+	if claims.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	return &claims, nil
+}
+
+// SignSigningKeySet 使用根密钥签名签名公钥集合
+func SignSigningKeySet(set *model.SigningKeySet, root ed25519.PrivateKey) (*model.SignedSigningKeySet, error) {
+	payload, err := json.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signing key set: %v", err)
+	}
+	return signPayload(payload, root)
+}
+
+// VerifySigningKeySet 使用根公钥校验签名的签名公钥集合并返回其内容
+func VerifySigningKeySet(file *model.SignedSigningKeySet, root ed25519.PublicKey) (*model.SigningKeySet, error) {
+	if file == nil {
+		return nil, errors.New("signing key set is empty")
+	}
+	if file.KeyID != PublicKeyID(root) {
+		return nil, errors.New("signing key set is not signed by the root key")
+	}
+	payload, err := verifyPayload(file, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var set model.SigningKeySet
+	if err := json.Unmarshal(payload, &set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal signing key set: %v", err)
+	}
+	if set.Type != model.SigningKeySetType {
+		return nil, errors.New("signed content is not a signing key set")
+	}
+	return &set, nil
+}
+
+// signPayload 签名JSON内容并封装为签名文件
+func signPayload(payload []byte, privateKey ed25519.PrivateKey) (*model.LicenseFile, error) {
+	if privateKey == nil {
+		return nil, errors.New("signing key not initialized")
+	}
+
+	signature := ed25519.Sign(privateKey, payload)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	return &model.LicenseFile{
+		Version:   model.LicenseFileVersion,
+		Algorithm: model.LicenseFileAlgorithm,
+		KeyID:     PublicKeyID(publicKey),
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// verifyPayload 校验签名文件并返回被签名的JSON内容
+func verifyPayload(file *model.LicenseFile, publicKey ed25519.PublicKey) ([]byte, error) {
+	if file.Algorithm != model.LicenseFileAlgorithm {
+		return nil, fmt.Errorf("unsupported algorithm: %s", file.Algorithm)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(file.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %v", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(file.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %v", err)
+	}
+
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return payload, nil
+}
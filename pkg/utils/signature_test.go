@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"LVerity/pkg/model"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedPayloadTypes(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	license, err := SignLicenseClaims(&model.LicenseClaims{
+		PayloadType: model.LicenseClaimsType,
+		Code:        "CODE",
+		ExpireTime:  time.Now().AddDate(1, 0, 0),
+	}, privateKey)
+	require.NoError(t, err)
+	revocations, err := SignRevocationList(&model.RevocationList{Type: model.RevocationListType, Full: true}, privateKey)
+	require.NoError(t, err)
+	serverTime, err := SignServerTime(&model.ServerTimeClaims{Type: model.ServerTimeClaimsType, Time: time.Now()}, privateKey)
+	require.NoError(t, err)
+	untyped, err := SignLicenseClaims(&model.LicenseClaims{Code: "CODE"}, privateKey)
+	require.NoError(t, err)
+
+	_, err = VerifyLicenseFile(license, publicKey, "")
+	assert.NoError(t, err)
+	_, err = VerifyRevocationList(revocations, publicKey)
+	assert.NoError(t, err)
+
+	// 同一密钥签名的不同类型内容不能互相冒充
+	_, err = VerifyLicenseFile(revocations, publicKey, "")
+	assert.Error(t, err)
+	_, err = VerifyLicenseFile(serverTime, publicKey, "")
+	assert.Error(t, err)
+	_, err = VerifyLicenseFile(untyped, publicKey, "")
+	assert.Error(t, err)
+	_, err = VerifyRevocationList(license, publicKey)
+	assert.Error(t, err)
+	_, err = VerifyRevocationList(serverTime, publicKey)
+	assert.Error(t, err)
+	_, err = VerifyServerTime(revocations, publicKey, "")
+	assert.Error(t, err)
+}
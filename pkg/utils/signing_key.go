@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"LVerity/pkg/model"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// signingKeyEndorsementPrefix 背书签名内容的前缀，避免与其他签名内容混用
+const signingKeyEndorsementPrefix = "LVerity signing key\n"
+
+// SealSigningKey 使用根密钥派生的AES-GCM密钥加密签名私钥
+func SealSigningKey(privateKey ed25519.PrivateKey, root ed25519.PrivateKey) (string, error) {
+	aesGCM, err := signingKeyCipher(root)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := aesGCM.Seal(nonce, nonce, privateKey.Seed(), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenSigningKey 使用根密钥解密SealSigningKey加密的签名私钥
+func OpenSigningKey(sealed string, root ed25519.PrivateKey) (ed25519.PrivateKey, error) {
+	aesGCM, err := signingKeyCipher(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %v", err)
+	}
+	if len(data) < aesGCM.NonceSize() {
+		return nil, errors.New("sealed signing key too short")
+	}
+	seed, err := aesGCM.Open(nil, data[:aesGCM.NonceSize()], data[aesGCM.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key: %v", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("invalid signing key size")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// signingKeyCipher 根据根密钥派生加密签名私钥的AES-GCM
+func signingKeyCipher(root ed25519.PrivateKey) (cipher.AEAD, error) {
+	if root == nil {
+		return nil, errors.New("root signing key not initialized")
+	}
+	hash := sha256.Sum256(append([]byte("LVerity key encryption\n"), root.Seed()...))
+	block, err := aes.NewCipher(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// signingKeyEndorsementMessage 构造背书签名的内容，绑定密钥ID、所属产品和公钥
+func signingKeyEndorsementMessage(publicKey ed25519.PublicKey, productID string) []byte {
+	message := []byte(signingKeyEndorsementPrefix + PublicKeyID(publicKey) + "\n" + productID + "\n")
+	return append(message, publicKey...)
+}
+
+// EndorseSigningKey 使用根密钥为签名公钥背书
+func EndorseSigningKey(publicKey ed25519.PublicKey, productID string, root ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(root, signingKeyEndorsementMessage(publicKey, productID)))
+}
+
+// VerifySigningKeyEndorsement 使用根公钥校验签名公钥的背书
+func VerifySigningKeyEndorsement(publicKey ed25519.PublicKey, productID string, endorsement string, root ed25519.PublicKey) error {
+	signature, err := base64.StdEncoding.DecodeString(endorsement)
+	if err != nil {
+		return fmt.Errorf("failed to decode endorsement: %v", err)
+	}
+	if !ed25519.Verify(root, signingKeyEndorsementMessage(publicKey, productID), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewSigningKeyJWK 将签名公钥转换为JWK
+func NewSigningKeyJWK(key *model.SigningKey, publicKey ed25519.PublicKey) model.SigningKeyJWK {
+	return model.SigningKeyJWK{
+		KeyID:       key.ID,
+		KeyType:     "OKP",
+		Curve:       "Ed25519",
+		X:           base64.RawURLEncoding.EncodeToString(publicKey),
+		Use:         "sig",
+		Algorithm:   "EdDSA",
+		ProductID:   key.ProductID,
+		Status:      key.Status,
+		Root:        key.Root,
+		Endorsement: key.Endorsement,
+		ActivatesAt: key.ActivatesAt,
+		RetiresAt:   key.RetiresAt,
+	}
+}
+
+// ParseSigningKeyJWK 解析JWK中的Ed25519公钥，并校验密钥ID与公钥一致
+func ParseSigningKeyJWK(jwk *model.SigningKeyJWK) (ed25519.PublicKey, error) {
+	if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" {
+		return nil, fmt.Errorf("unsupported key type: %s/%s", jwk.KeyType, jwk.Curve)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %v", err)
+	}
+	if len(data) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key size")
+	}
+	publicKey := ed25519.PublicKey(data)
+	if PublicKeyID(publicKey) != jwk.KeyID {
+		return nil, errors.New("key id does not match public key")
+	}
+	return publicKey, nil
+}
@@ -28,8 +28,9 @@ export interface License {
   createdBy?: string;  // 前端字段名，与created_by同义
   updated_by?: string;
   updatedBy?: string;  // 前端字段名，与updated_by同义
-  group_id?: string;  // 客户ID
-  customerId?: string;  // 前端字段名，与group_id同义
+  group_id?: string;  // 授权组ID
+  customer_id?: string;  // 客户ID
+  customerId?: string;  // 前端字段名，与customer_id同义
   customerName?: string;  // 客户端显示用
   productId?: string;  // 前端字段名，产品ID
   productName?: string;  // 客户端显示用
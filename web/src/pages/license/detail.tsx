@@ -70,7 +70,7 @@ const LicenseDetailPage: React.FC = () => {
       licenseData.expiryDate = licenseData.expiryDate || licenseData.expire_time;
       licenseData.maxActivations = licenseData.maxActivations || licenseData.usage_limit || licenseData.max_devices;
       licenseData.activationCount = licenseData.activationCount || licenseData.usage_count;
-      licenseData.customerId = licenseData.customerId || licenseData.group_id;
+      licenseData.customerId = licenseData.customerId || licenseData.customer_id;
       licenseData.createdAt = licenseData.createdAt || licenseData.created_at;
       licenseData.updatedAt = licenseData.updatedAt || licenseData.updated_at;
       licenseData.notes = licenseData.notes || licenseData.description;
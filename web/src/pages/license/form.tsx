@@ -118,7 +118,7 @@ const LicenseForm: React.FC<LicenseFormProps> = ({ mode }) => {
         // 填充表单数据
         form.setFieldsValue({
           code: license.code || license.key,  
-          customerId: license.group_id || license.customerId,  
+          customerId: license.customer_id || license.customerId,  
           productId: license.product_id || license.productId,  
           maxActivations: license.max_devices || license.maxActivations || license.usage_limit,  
           notes: license.description || license.notes,  
@@ -200,7 +200,7 @@ const LicenseForm: React.FC<LicenseFormProps> = ({ mode }) => {
         max_devices: parseInt(values.max_devices || values.maxDevices || 1, 10),
         code: values.code || values.key, // 确保code字段有值
         product_id: values.product_id || values.productId,
-        group_id: values.group_id || values.customerId
+        customer_id: values.customer_id || values.customerId
       };
 
       console.log('提交表单数据:', formData);
@@ -235,36 +235,17 @@ const LicenseForm: React.FC<LicenseFormProps> = ({ mode }) => {
     try {
       setIsGenerating(true);
       
-      // 使用更复杂的随机密钥生成算法
-      
-      // 定义允许的字符集（排除容易混淆的字符如0,O,1,I等）
-      const validChars = 'ABCDEFGHJKLMNPQRSTUVWXYZ23456789';
-      
-      // 生成四段，每段5个字符的随机密钥
-      const generateSegment = () => {
-        let segment = '';
-        for (let i = 0; i < 5; i++) {
-          const randomIndex = Math.floor(Math.random() * validChars.length);
-          segment += validChars.charAt(randomIndex);
-        }
-        return segment;
-      };
-      
-      // 生成具有字母数字混合的复杂密钥
-      const seg1 = generateSegment();
-      const seg2 = generateSegment();
-      const seg3 = generateSegment();
-      const seg4 = generateSegment();
-      
-      // 组装最终密钥
-      const enhancedKey = `${seg1}-${seg2}-${seg3}-${seg4}`;
-      
-      console.log('生成的增强密钥:', enhancedKey);
+      // 由服务端按授权码格式生成带校验字符且不重复的密钥
+      const response = await licenseService.generateLicenseKey();
+      if (!response.success || !response.data?.key) {
+        throw new Error(response.message || '生成授权密钥失败');
+      }
+      const key = response.data.key;
       
       // 设置表单字段值
       form.setFieldsValue({ 
-        code: enhancedKey, 
-        key: enhancedKey 
+        code: key, 
+        key: key 
       });
       
       message.success('成功生成授权密钥');
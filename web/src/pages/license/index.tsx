@@ -208,7 +208,7 @@ const LicensePage: React.FC = () => {
       status: params.status || '',
       
       // 客户过滤
-      customerId: params.group_id || '',
+      customerId: params.customer_id || '',
       
       // 排序
       sortBy: params.sortBy || '',
@@ -507,15 +507,15 @@ const LicensePage: React.FC = () => {
     },
     {
       title: '客户',
-      dataIndex: 'group_id',
-      key: 'group_id',
+      dataIndex: 'customer_id',
+      key: 'customer_id',
       ellipsis: true,
       width: 150,
       filters: customers.map(c => ({ text: c.name, value: c.id })),
       filterSearch: true,
       render: (_, record) => {
-        const customer = customers.find(c => c.id === (record.group_id || record.customerId));
-        return <Tag color="blue">{customer?.name || record.group_id || record.customerId || '-'}</Tag>;
+        const customer = customers.find(c => c.id === (record.customer_id || record.customerId));
+        return <Tag color="blue">{customer?.name || record.customer_id || record.customerId || '-'}</Tag>;
       },
     },
     {
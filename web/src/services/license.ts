@@ -18,7 +18,7 @@ export const licenseService = {
       pageSize: query.pageSize || 10,
       // 其他查询参数
       status: query.status || '',
-      group_id: query.customerId || '',
+      customer_id: query.customerId || '',
       keyword: query.keyword || '',
       _t: new Date().getTime()  // 防止缓存
     };
@@ -70,7 +70,7 @@ export const licenseService = {
       max_devices: license.max_devices || license.maxDevices || 1,
       code: license.code || license.key, // 确保code字段有值
       product_id: license.product_id || license.productId,
-      group_id: license.group_id || license.customerId,
+      customer_id: license.customer_id || license.customerId,
       description: license.description || ''
     };
 
@@ -97,7 +97,7 @@ export const licenseService = {
       max_devices: license.max_devices || license.maxDevices || 1,
       code: license.code || license.key, // 确保code字段有值
       product_id: license.product_id || license.productId,
-      group_id: license.group_id || license.customerId,
+      customer_id: license.customer_id || license.customerId,
       description: license.description || ''
     };
 
@@ -240,7 +240,7 @@ export const licenseService = {
     const apiParams = {
       count: params.count,
       type: params.type,
-      group_id: params.customerId,
+      customer_id: params.customerId,
       product_id: params.productId,
       ExpiresAt: params.expiresAt,
       max_devices: params.maxDevices